@@ -0,0 +1,49 @@
+package accounts
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"aiforum/models"
+)
+
+// 头像保存目录，文件名以用户ID开头
+const avatarDir = "images/avatars"
+
+// 注销账户：内容转给占位账户并删除个人数据，随后删除上传的文件和头像
+func Delete(userID int) error {
+	// 注销后无法再查到文件属于谁，先记录下来
+	uploads, err := models.GetUserUploads(userID)
+	if err != nil {
+		return err
+	}
+	avatars, err := filepath.Glob(filepath.Join(avatarDir, strconv.Itoa(userID)+"_*"))
+	if err != nil {
+		return err
+	}
+
+	if err := models.AnonymizeUser(userID); err != nil {
+		return err
+	}
+
+	for _, path := range append(uploads, avatars...) {
+		removeUpload(path)
+	}
+	log.Printf("已注销用户 %d", userID)
+	return nil
+}
+
+// 删除一个上传文件，只处理上传目录下的路径
+func removeUpload(path string) {
+	cleaned := filepath.Clean(strings.TrimPrefix(path, "/"))
+	if !strings.HasPrefix(cleaned, "uploads"+string(filepath.Separator)) &&
+		!strings.HasPrefix(cleaned, avatarDir+string(filepath.Separator)) {
+		return
+	}
+	if err := os.Remove(cleaned); err != nil && !os.IsNotExist(err) {
+		log.Printf("删除上传文件失败: %v", err)
+	}
+}
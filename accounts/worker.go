@@ -0,0 +1,41 @@
+package accounts
+
+import (
+	"log"
+	"time"
+
+	"aiforum/models"
+)
+
+// 注销任务的轮询间隔
+const workerInterval = time.Hour
+
+// 每轮处理的账户数量上限
+const deletionBatchSize = 20
+
+// 启动后台注销任务：注销等待期已过的账户
+func StartWorker() {
+	go func() {
+		ticker := time.NewTicker(workerInterval)
+		defer ticker.Stop()
+
+		for {
+			RunOnce()
+			<-ticker.C
+		}
+	}()
+}
+
+// 执行一轮注销任务
+func RunOnce() {
+	ids, err := models.GetDueAccountDeletions(deletionBatchSize)
+	if err != nil {
+		log.Printf("获取待注销账户失败: %v", err)
+		return
+	}
+	for _, id := range ids {
+		if err := Delete(id); err != nil {
+			log.Printf("注销账户 %d 失败: %v", id, err)
+		}
+	}
+}
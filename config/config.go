@@ -12,6 +12,22 @@ type Config struct {
 	DBName     string
 	JWTSecret  string
 	ServerPort string
+
+	// 邮件发送（SMTPHost为空时不启动邮件投递）
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUser     string
+	SMTPPassword string
+	MailFrom     string
+	SiteURL      string
+
+	// 第三方登录（ClientID为空时不启用对应的登录方式）
+	OIDCIssuer         string
+	OIDCClientID       string
+	OIDCClientSecret   string
+	OIDCDisplayName    string
+	GitHubClientID     string
+	GitHubClientSecret string
 }
 
 var AppConfig *Config
@@ -25,6 +41,20 @@ func Init() {
 		DBName:     getEnv("DB_NAME", "aiforum"),
 		JWTSecret:  getEnv("JWT_SECRET", "your-secret-key"),
 		ServerPort: getEnv("SERVER_PORT", "8080"),
+
+		SMTPHost:     getEnv("SMTP_HOST", ""),
+		SMTPPort:     getEnv("SMTP_PORT", "25"),
+		SMTPUser:     getEnv("SMTP_USER", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		MailFrom:     getEnv("MAIL_FROM", "AI论坛 <noreply@aiforum.local>"),
+		SiteURL:      getEnv("SITE_URL", "http://localhost:8080"),
+
+		OIDCIssuer:         getEnv("OIDC_ISSUER", ""),
+		OIDCClientID:       getEnv("OIDC_CLIENT_ID", ""),
+		OIDCClientSecret:   getEnv("OIDC_CLIENT_SECRET", ""),
+		OIDCDisplayName:    getEnv("OIDC_DISPLAY_NAME", "企业账号"),
+		GitHubClientID:     getEnv("GITHUB_CLIENT_ID", ""),
+		GitHubClientSecret: getEnv("GITHUB_CLIENT_SECRET", ""),
 	}
 }
 
@@ -0,0 +1,245 @@
+package exporter
+
+import (
+	"archive/zip"
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"aiforum/models"
+)
+
+//go:embed templates/markdown.tmpl
+var templateFS embed.FS
+
+var markdownTemplates = template.Must(template.New("markdown").Funcs(template.FuncMap{
+	"date": func(t time.Time) string { return t.Format("2006-01-02 15:04") },
+	"yesno": func(b bool) string {
+		if b {
+			return "是"
+		}
+		return "否"
+	},
+	"commentType": func(t string) string { return commentTypeNames[t] },
+}).ParseFS(templateFS, "templates/markdown.tmpl"))
+
+// 评论类型的中文名称
+var commentTypeNames = map[string]string{
+	"reply":            "帖子回复",
+	"article_comment":  "文章评论",
+	"resource_comment": "资料评论",
+}
+
+// 允许打包进导出文件的本地目录，避免数据库中的异常路径读取到其他文件
+var exportableRoots = []string{"uploads/", "images/avatars/"}
+
+// 导出文件中的一个本地文件
+type exportedFile struct {
+	Source  string `json:"source"`            // 站内原路径
+	Archive string `json:"archive,omitempty"` // 在压缩包中的路径，文件不存在时为空
+}
+
+// 打包用户数据
+type archive struct {
+	zip   *zip.Writer
+	files []exportedFile
+}
+
+// 将用户的全部数据写成ZIP
+func Build(userID int, w io.Writer) error {
+	data, err := models.CollectUserExportData(userID)
+	if err != nil {
+		return err
+	}
+
+	a := &archive{zip: zip.NewWriter(w)}
+	if err := a.writeData(data); err != nil {
+		a.zip.Close()
+		return err
+	}
+	return a.zip.Close()
+}
+
+func (a *archive) writeData(data *models.UserExportData) error {
+	// 先复制文件，Markdown中需要引用文件在压缩包中的路径
+	if avatar := a.addFile(data.Profile.Avatar, "files/avatar"); avatar != "" {
+		data.Profile.Avatar = avatar
+	}
+
+	resourceFiles := make(map[int][]string)
+	for _, r := range data.Resources {
+		dir := "files/resources/" + strconv.Itoa(r.ID)
+		for _, p := range r.FilePaths {
+			if archived := a.addFile(p, dir); archived != "" {
+				resourceFiles[r.ID] = append(resourceFiles[r.ID], archived)
+			}
+		}
+		a.addFile(r.CoverImage, dir+"/cover")
+	}
+	for _, article := range data.Articles {
+		a.addFile(article.CoverImage, "files/articles/"+strconv.Itoa(article.ID))
+	}
+
+	attachmentFiles := make(map[int]string)
+	for _, c := range data.Conversations {
+		for _, m := range c.Messages {
+			for _, attachment := range m.Attachments {
+				dir := fmt.Sprintf("files/attachments/%d/%d", c.ID, attachment.ID)
+				attachmentFiles[attachment.ID] = a.addFile(attachment.FilePath, dir)
+			}
+		}
+	}
+
+	sections := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile", data.Profile},
+		{"questions", data.Questions},
+		{"answers", data.Answers},
+		{"posts", data.Posts},
+		{"articles", data.Articles},
+		{"resources", data.Resources},
+		{"comments", data.Comments},
+		{"bookmarks", data.Bookmarks},
+		{"collections", data.Collections},
+		{"following", data.Following},
+		{"followers", data.Followers},
+		{"notifications", data.Notifications},
+		{"conversations", data.Conversations},
+		{"search_history", data.SearchHistory},
+		{"files", a.files},
+	}
+	for _, section := range sections {
+		if err := a.writeJSON("json/"+section.name+".json", section.data); err != nil {
+			return err
+		}
+	}
+
+	if err := a.writeMarkdown("README.md", "readme", data); err != nil {
+		return err
+	}
+	if err := a.writeMarkdown("markdown/profile.md", "profile", data.Profile); err != nil {
+		return err
+	}
+	for _, q := range data.Questions {
+		if err := a.writeMarkdown(fmt.Sprintf("markdown/questions/%d.md", q.ID), "post", q); err != nil {
+			return err
+		}
+	}
+	for _, answer := range data.Answers {
+		if err := a.writeMarkdown(fmt.Sprintf("markdown/answers/%d.md", answer.ID), "answer", answer); err != nil {
+			return err
+		}
+	}
+	for _, p := range data.Posts {
+		if err := a.writeMarkdown(fmt.Sprintf("markdown/posts/%d.md", p.ID), "post", p); err != nil {
+			return err
+		}
+	}
+	for _, article := range data.Articles {
+		if err := a.writeMarkdown(fmt.Sprintf("markdown/articles/%d.md", article.ID), "post", article); err != nil {
+			return err
+		}
+	}
+	for _, r := range data.Resources {
+		view := map[string]interface{}{"Resource": r, "Files": resourceFiles[r.ID]}
+		if err := a.writeMarkdown(fmt.Sprintf("markdown/resources/%d.md", r.ID), "resource", view); err != nil {
+			return err
+		}
+	}
+	if err := a.writeMarkdown("markdown/comments.md", "comments", data.Comments); err != nil {
+		return err
+	}
+	if err := a.writeMarkdown("markdown/bookmarks.md", "bookmarks", data); err != nil {
+		return err
+	}
+	for _, c := range data.Conversations {
+		view := map[string]interface{}{"Conversation": c, "Files": attachmentFiles}
+		if err := a.writeMarkdown(fmt.Sprintf("markdown/conversations/%d.md", c.ID), "conversation", view); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *archive) writeJSON(name string, v interface{}) error {
+	w, err := a.zip.Create(name)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func (a *archive) writeMarkdown(name, tmpl string, data interface{}) error {
+	var buf bytes.Buffer
+	if err := markdownTemplates.ExecuteTemplate(&buf, tmpl, data); err != nil {
+		return err
+	}
+	w, err := a.zip.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(bytes.TrimLeft(buf.Bytes(), "\n"))
+	return err
+}
+
+// 把站内文件复制到压缩包的dir目录下，返回压缩包中的路径（文件不可导出或不存在时返回空）
+func (a *archive) addFile(source, dir string) string {
+	local, ok := localPath(source)
+	if !ok {
+		return ""
+	}
+
+	entry := exportedFile{Source: source}
+	defer func() { a.files = append(a.files, entry) }()
+
+	f, err := os.Open(local)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	if info, err := f.Stat(); err != nil || info.IsDir() {
+		return ""
+	}
+
+	name := path.Join(dir, filepath.Base(local))
+	w, err := a.zip.Create(name)
+	if err != nil {
+		return ""
+	}
+	if _, err := io.Copy(w, f); err != nil {
+		return ""
+	}
+	entry.Archive = name
+	return name
+}
+
+// 把站内路径（如/images/avatars/1.jpg）转换为本地文件路径
+func localPath(source string) (string, bool) {
+	source = strings.TrimSpace(source)
+	if source == "" || strings.Contains(source, "://") {
+		return "", false
+	}
+	cleaned := path.Clean(strings.TrimPrefix(source, "/"))
+	if strings.HasPrefix(cleaned, exportDir+"/") {
+		return "", false
+	}
+	for _, root := range exportableRoots {
+		if strings.HasPrefix(cleaned, root) {
+			return filepath.FromSlash(cleaned), true
+		}
+	}
+	return "", false
+}
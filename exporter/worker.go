@@ -0,0 +1,122 @@
+package exporter
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"aiforum/models"
+)
+
+// 导出任务的轮询间隔
+const workerInterval = time.Minute
+
+// 导出文件的存放目录
+const exportDir = "uploads/exports"
+
+// 有新任务时唤醒后台任务，不必等到下一次轮询
+var wake = make(chan struct{}, 1)
+
+// 启动后台导出任务：生成导出文件并清理过期文件
+func StartWorker() {
+	go func() {
+		ticker := time.NewTicker(workerInterval)
+		defer ticker.Stop()
+
+		for {
+			RunOnce()
+			select {
+			case <-ticker.C:
+			case <-wake:
+			}
+		}
+	}()
+}
+
+// 通知后台任务有新的导出请求
+func Wake() {
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
+
+// 执行一轮导出任务
+func RunOnce() {
+	for {
+		export, err := models.ClaimDataExport()
+		if err != nil {
+			log.Printf("领取导出任务失败: %v", err)
+			break
+		}
+		if export == nil {
+			break
+		}
+		process(export)
+	}
+
+	paths, err := models.ExpireDataExports()
+	if err != nil {
+		log.Printf("清理过期导出失败: %v", err)
+	}
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Printf("删除导出文件失败: %v", err)
+		}
+	}
+}
+
+// 生成一个导出文件
+func process(export *models.DataExport) {
+	path, size, err := writeExport(export)
+	if err != nil {
+		log.Printf("生成导出文件失败(%d): %v", export.ID, err)
+		if err := models.FailDataExport(export.ID, "生成导出文件失败，请稍后重试"); err != nil {
+			log.Printf("更新导出任务失败: %v", err)
+		}
+		return
+	}
+
+	if err := models.CompleteDataExport(export.ID, path, size); err != nil {
+		log.Printf("更新导出任务失败: %v", err)
+		os.Remove(path)
+	}
+}
+
+func writeExport(export *models.DataExport) (string, int64, error) {
+	dir := filepath.Join(exportDir, fmt.Sprint(export.UserID))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", 0, err
+	}
+
+	// 文件名带随机后缀，避免被猜到
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", 0, err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%d-%s.zip", export.ID, hex.EncodeToString(suffix)))
+
+	f, err := os.Create(path)
+	if err != nil {
+		return "", 0, err
+	}
+	if err := Build(export.UserID, f); err != nil {
+		f.Close()
+		os.Remove(path)
+		return "", 0, err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(path)
+		return "", 0, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", 0, err
+	}
+	return path, info.Size(), nil
+}
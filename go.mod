@@ -6,6 +6,7 @@ require (
 	github.com/gin-gonic/gin v1.9.1
 	github.com/go-sql-driver/mysql v1.7.1
 	github.com/golang-jwt/jwt/v5 v5.0.0
+	github.com/graph-gophers/graphql-go v1.5.0
 	golang.org/x/crypto v0.14.0
 	github.com/mattn/go-sqlite3 v1.14.17
 )
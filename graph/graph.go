@@ -0,0 +1,56 @@
+package graph
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+//go:embed schema.graphql
+var schemaString string
+
+// 限制查询嵌套深度；并发解析的字段数同时决定了每批最多合并多少个键
+var schema = graphql.MustParseSchema(schemaString, &queryResolver{},
+	graphql.MaxDepth(10),
+	graphql.MaxParallelism(maxBatchSize),
+)
+
+// GraphQL请求
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// GraphQL接口，GET请求通过query、operationName、variables参数传递，POST请求使用JSON请求体
+func Handler(c *gin.Context) {
+	var req request
+	if c.Request.Method == http.MethodGet {
+		req.Query = c.Query("query")
+		req.OperationName = c.Query("operationName")
+		if variables := c.Query("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				badRequest(c, "variables必须是JSON对象")
+				return
+			}
+		}
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		badRequest(c, "请求体格式错误")
+		return
+	}
+	if req.Query == "" {
+		badRequest(c, "缺少query")
+		return
+	}
+
+	ctx := withLoaders(c.Request.Context(), newLoaders(c.GetInt("user_id")))
+	c.JSON(http.StatusOK, schema.Exec(ctx, req.Query, req.OperationName, req.Variables))
+}
+
+// 请求无法执行时按GraphQL的错误格式返回
+func badRequest(c *gin.Context, message string) {
+	c.JSON(http.StatusBadRequest, gin.H{"errors": []gin.H{{"message": message}}})
+}
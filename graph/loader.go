@@ -0,0 +1,160 @@
+package graph
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"aiforum/models"
+)
+
+// 批量加载参数：同一请求中并发解析的字段先把要查询的键放进同一批，
+// 等待batchWait（或攒满maxBatchSize个键）后只查询一次
+const (
+	batchWait    = time.Millisecond
+	maxBatchSize = 100
+)
+
+// 批量加载器，结果在单个请求内缓存
+type loader[K comparable, V any] struct {
+	fetch func(keys []K) (map[K]V, error)
+
+	mu      sync.Mutex
+	batches map[K]*batch[K, V] // 每个键所在的批次（包括已完成的）
+	pending *batch[K, V]
+}
+
+type batch[K comparable, V any] struct {
+	keys   []K
+	done   chan struct{}
+	values map[K]V
+	err    error
+}
+
+func newLoader[K comparable, V any](fetch func(keys []K) (map[K]V, error)) *loader[K, V] {
+	return &loader[K, V]{fetch: fetch, batches: make(map[K]*batch[K, V])}
+}
+
+// 加载单个键，查询结果中没有的键返回零值
+func (l *loader[K, V]) Load(key K) (V, error) {
+	values, err := l.LoadAll([]K{key})
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+	return values[0], nil
+}
+
+// 加载多个键，结果与keys一一对应
+func (l *loader[K, V]) LoadAll(keys []K) ([]V, error) {
+	waits := make([]*batch[K, V], len(keys))
+	l.mu.Lock()
+	for i, key := range keys {
+		b, ok := l.batches[key]
+		if !ok {
+			b = l.enqueue(key)
+		}
+		waits[i] = b
+	}
+	l.mu.Unlock()
+
+	values := make([]V, len(keys))
+	for i, b := range waits {
+		<-b.done
+		if b.err != nil {
+			return nil, b.err
+		}
+		values[i] = b.values[keys[i]]
+	}
+	return values, nil
+}
+
+// 把键加入等待中的批次，调用时需持有锁
+func (l *loader[K, V]) enqueue(key K) *batch[K, V] {
+	if l.pending == nil {
+		b := &batch[K, V]{done: make(chan struct{})}
+		l.pending = b
+		time.AfterFunc(batchWait, func() {
+			l.mu.Lock()
+			if l.pending != b {
+				// 已经攒满提前执行
+				l.mu.Unlock()
+				return
+			}
+			l.pending = nil
+			l.mu.Unlock()
+			l.run(b)
+		})
+	}
+
+	b := l.pending
+	b.keys = append(b.keys, key)
+	l.batches[key] = b
+	if len(b.keys) >= maxBatchSize {
+		l.pending = nil
+		go l.run(b)
+	}
+	return b
+}
+
+func (l *loader[K, V]) run(b *batch[K, V]) {
+	b.values, b.err = l.fetch(b.keys)
+	close(b.done)
+}
+
+// 单个请求使用的加载器，点赞和收藏状态针对当前用户
+type loaders struct {
+	viewerID int
+
+	users     *loader[int, *models.User]
+	questions *loader[int, *models.Question]
+	answers   *loader[int, []*models.Answer] // 按问题ID
+	comments  *loader[int, []models.Comment] // 按文章ID
+
+	likedAnswers       *loader[int, bool]
+	likedComments      *loader[int, bool]
+	likedArticles      *loader[int, bool]
+	favoritedQuestions *loader[int, bool]
+	favoritedArticles  *loader[int, bool]
+	favoritedResources *loader[int, bool]
+	visibleProfiles    *loader[int, bool] // 当前用户能否看到对方主页的完整信息
+}
+
+func newLoaders(viewerID int) *loaders {
+	viewer := func(fetch func(userID int, ids []int) (map[int]bool, error)) *loader[int, bool] {
+		return newLoader(func(ids []int) (map[int]bool, error) {
+			return fetch(viewerID, ids)
+		})
+	}
+	favorites := func(contentType string) *loader[int, bool] {
+		return viewer(func(userID int, ids []int) (map[int]bool, error) {
+			return models.GetBookmarkedIDs(userID, contentType, ids)
+		})
+	}
+
+	return &loaders{
+		viewerID:  viewerID,
+		users:     newLoader(models.GetUsersByIDs),
+		questions: newLoader(models.GetQuestionsByIDs),
+		answers:   newLoader(models.GetMergedAnswersByQuestionIDs),
+		comments:  newLoader(models.GetArticleCommentsByArticleIDs),
+
+		likedAnswers:       viewer(models.GetLikedAnswerIDs),
+		likedComments:      viewer(models.GetLikedCommentIDs),
+		likedArticles:      viewer(models.GetLikedArticleIDs),
+		favoritedQuestions: favorites(models.ContentTypeQuestion),
+		favoritedArticles:  favorites(models.ContentTypeArticle),
+		favoritedResources: favorites(models.ContentTypeResource),
+		visibleProfiles:    viewer(models.GetVisibleProfileIDs),
+	}
+}
+
+type loadersKey struct{}
+
+func withLoaders(ctx context.Context, l *loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
@@ -0,0 +1,227 @@
+package graph
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+
+	graphql "github.com/graph-gophers/graphql-go"
+
+	"aiforum/models"
+	"aiforum/utils"
+)
+
+var errInvalidID = errors.New("无效的ID")
+
+// 查询入口
+type queryResolver struct{}
+
+func parseID(id graphql.ID) (int, error) {
+	n, err := strconv.Atoi(string(id))
+	if err != nil || n <= 0 {
+		return 0, errInvalidID
+	}
+	return n, nil
+}
+
+// 分页参数，limit超出范围时使用默认值
+func pageQuery(limit int32, cursor *string) (models.PageQuery, error) {
+	page := models.PageQuery{Limit: int(limit)}
+	if cursor != nil {
+		c, err := utils.DecodeCursor(*cursor)
+		if err != nil {
+			return page, err
+		}
+		page.Cursor = c
+	}
+	return page, nil
+}
+
+// 列表查询出错时，游标错误原样返回，其他错误使用统一的提示
+func listError(err error, message string) error {
+	if errors.Is(err, utils.ErrInvalidCursor) {
+		return err
+	}
+	return errors.New(message)
+}
+
+func (q *queryResolver) Me(ctx context.Context) (*userResolver, error) {
+	viewerID := loadersFrom(ctx).viewerID
+	if viewerID == 0 {
+		return nil, nil
+	}
+	return loadUser(ctx, viewerID)
+}
+
+func (q *queryResolver) User(ctx context.Context, args struct{ ID graphql.ID }) (*userResolver, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	return loadUser(ctx, id)
+}
+
+func (q *queryResolver) Question(ctx context.Context, args struct{ ID graphql.ID }) (*questionResolver, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	return loadQuestion(ctx, id)
+}
+
+func (q *queryResolver) Questions(args struct {
+	CategoryID *int32
+	Sort       string
+	Limit      int32
+	Cursor     *string
+}) (*questionConnection, error) {
+	page, err := pageQuery(args.Limit, args.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	var categoryID int
+	if args.CategoryID != nil {
+		categoryID = int(*args.CategoryID)
+	}
+
+	questions, info, err := models.GetQuestions(categoryID, strings.ToLower(args.Sort), page)
+	if err != nil {
+		return nil, listError(err, "获取问题列表失败")
+	}
+	conn := &questionConnection{pageInfo: info}
+	for _, question := range questions {
+		conn.nodes = append(conn.nodes, &questionResolver{question})
+	}
+	return conn, nil
+}
+
+func (q *queryResolver) Article(args struct{ ID graphql.ID }) (*articleResolver, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	article, err := models.GetTechArticleByID(id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.New("获取文章失败")
+	}
+	return &articleResolver{article}, nil
+}
+
+func (q *queryResolver) Articles(ctx context.Context, args struct {
+	Category *string
+	Query    *string
+	Topic    *string
+	Sort     string
+	Limit    int32
+	Cursor   *string
+}) (*articleConnection, error) {
+	page, err := pageQuery(args.Limit, args.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	articles, info, err := models.GetTechArticles(deref(args.Category), deref(args.Query), strings.ToLower(args.Sort), deref(args.Topic), loadersFrom(ctx).viewerID, page)
+	if err != nil {
+		return nil, listError(err, "获取文章列表失败")
+	}
+	conn := &articleConnection{pageInfo: info}
+	for _, article := range articles {
+		conn.nodes = append(conn.nodes, &articleResolver{article})
+	}
+	return conn, nil
+}
+
+func (q *queryResolver) Resource(args struct{ ID graphql.ID }) (*resourceResolver, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	resource, err := models.GetLearningResourceByID(strconv.Itoa(id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.New("获取资料失败")
+	}
+	return &resourceResolver{resource}, nil
+}
+
+func (q *queryResolver) Resources(ctx context.Context, args struct {
+	Query    *string
+	Type     *string
+	Level    *string
+	Category *string
+	Limit    int32
+	Cursor   *string
+}) (*resourceConnection, error) {
+	page, err := pageQuery(args.Limit, args.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	resources, info, err := models.GetLearningResources(deref(args.Query), deref(args.Type), deref(args.Level), "", "", deref(args.Category), loadersFrom(ctx).viewerID, page)
+	if err != nil {
+		return nil, listError(err, "获取资料列表失败")
+	}
+	conn := &resourceConnection{pageInfo: info}
+	for _, resource := range resources {
+		conn.nodes = append(conn.nodes, &resourceResolver{resource})
+	}
+	return conn, nil
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// 分页信息
+type pageInfoResolver struct {
+	info models.PageInfo
+}
+
+func (p *pageInfoResolver) NextCursor() *string {
+	return optional(p.info.NextCursor)
+}
+
+func (p *pageInfoResolver) PrevCursor() *string {
+	return optional(p.info.PrevCursor)
+}
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+type questionConnection struct {
+	nodes    []*questionResolver
+	pageInfo models.PageInfo
+}
+
+func (c *questionConnection) Nodes() []*questionResolver  { return c.nodes }
+func (c *questionConnection) PageInfo() *pageInfoResolver { return &pageInfoResolver{c.pageInfo} }
+
+type articleConnection struct {
+	nodes    []*articleResolver
+	pageInfo models.PageInfo
+}
+
+func (c *articleConnection) Nodes() []*articleResolver   { return c.nodes }
+func (c *articleConnection) PageInfo() *pageInfoResolver { return &pageInfoResolver{c.pageInfo} }
+
+type resourceConnection struct {
+	nodes    []*resourceResolver
+	pageInfo models.PageInfo
+}
+
+func (c *resourceConnection) Nodes() []*resourceResolver  { return c.nodes }
+func (c *resourceConnection) PageInfo() *pageInfoResolver { return &pageInfoResolver{c.pageInfo} }
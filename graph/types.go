@@ -0,0 +1,274 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	graphql "github.com/graph-gophers/graphql-go"
+
+	"aiforum/models"
+)
+
+func toID(id int) graphql.ID {
+	return graphql.ID(strconv.Itoa(id))
+}
+
+func tagList(tags string) []string {
+	names := models.ParseTagNames(tags)
+	if names == nil {
+		return []string{}
+	}
+	return names
+}
+
+// 查询当前用户的点赞或收藏状态（未登录时为false）
+func viewerState(l *loader[int, bool], id int, message string) (bool, error) {
+	state, err := l.Load(id)
+	if err != nil {
+		return false, errors.New(message)
+	}
+	return state, nil
+}
+
+// 用户
+type userResolver struct {
+	user *models.User
+}
+
+func loadUser(ctx context.Context, id int) (*userResolver, error) {
+	user, err := loadersFrom(ctx).users.Load(id)
+	if err != nil {
+		return nil, errors.New("获取用户失败")
+	}
+	if user == nil {
+		return nil, nil
+	}
+	return &userResolver{user}, nil
+}
+
+func (r *userResolver) ID() graphql.ID   { return toID(r.user.ID) }
+func (r *userResolver) Username() string { return r.user.Username }
+func (r *userResolver) Avatar() string   { return r.user.Avatar }
+func (r *userResolver) Level() int32     { return int32(models.GetUserLevel(r.user.Points)) }
+
+// 积分和注册时间按主页隐私设置隐藏，与REST的公开主页一致
+func (r *userResolver) Points(ctx context.Context) (*int32, error) {
+	visible, err := viewerState(loadersFrom(ctx).visibleProfiles, r.user.ID, "获取用户失败")
+	if err != nil || !visible {
+		return nil, err
+	}
+	points := int32(r.user.Points)
+	return &points, nil
+}
+
+func (r *userResolver) CreatedAt(ctx context.Context) (*graphql.Time, error) {
+	visible, err := viewerState(loadersFrom(ctx).visibleProfiles, r.user.ID, "获取用户失败")
+	if err != nil || !visible {
+		return nil, err
+	}
+	return &graphql.Time{Time: r.user.CreatedAt}, nil
+}
+
+// 问题
+type questionResolver struct {
+	question *models.Question
+}
+
+func loadQuestion(ctx context.Context, id int) (*questionResolver, error) {
+	question, err := loadersFrom(ctx).questions.Load(id)
+	if err != nil {
+		return nil, errors.New("获取问题失败")
+	}
+	if question == nil {
+		return nil, nil
+	}
+	return &questionResolver{question}, nil
+}
+
+func (r *questionResolver) ID() graphql.ID          { return toID(r.question.ID) }
+func (r *questionResolver) Title() string           { return r.question.Title }
+func (r *questionResolver) Content() string         { return r.question.Content }
+func (r *questionResolver) Summary() string         { return r.question.Summary }
+func (r *questionResolver) CategoryID() int32       { return int32(r.question.CategoryID) }
+func (r *questionResolver) Tags() []string          { return tagList(r.question.Tags) }
+func (r *questionResolver) ViewCount() int32        { return int32(r.question.ViewCount) }
+func (r *questionResolver) AnswerCount() int32      { return int32(r.question.AnswerCount) }
+func (r *questionResolver) LikeCount() int32        { return int32(r.question.LikeCount) }
+func (r *questionResolver) Reward() int32           { return int32(r.question.Reward) }
+func (r *questionResolver) IsSolved() bool          { return r.question.IsSolved }
+func (r *questionResolver) Status() string          { return r.question.Status }
+func (r *questionResolver) CreatedAt() graphql.Time { return graphql.Time{Time: r.question.CreatedAt} }
+func (r *questionResolver) UpdatedAt() graphql.Time { return graphql.Time{Time: r.question.UpdatedAt} }
+
+func (r *questionResolver) Author(ctx context.Context) (*userResolver, error) {
+	return loadUser(ctx, r.question.UserID)
+}
+
+func (r *questionResolver) DuplicateOf(ctx context.Context) (*questionResolver, error) {
+	if r.question.DuplicateOf == 0 {
+		return nil, nil
+	}
+	return loadQuestion(ctx, r.question.DuplicateOf)
+}
+
+func (r *questionResolver) Answers(ctx context.Context) ([]*answerResolver, error) {
+	answers, err := loadersFrom(ctx).answers.Load(r.question.ID)
+	if err != nil {
+		return nil, errors.New("获取回答失败")
+	}
+	resolvers := make([]*answerResolver, len(answers))
+	for i, answer := range answers {
+		resolvers[i] = &answerResolver{answer}
+	}
+	return resolvers, nil
+}
+
+func (r *questionResolver) RelatedQuestions(ctx context.Context, args struct{ Limit int32 }) ([]*questionResolver, error) {
+	limit := int(args.Limit)
+	if limit < 1 || limit > 20 {
+		limit = 5
+	}
+	related, err := models.GetRelatedQuestions(r.question.ID, limit)
+	if err != nil {
+		return nil, errors.New("获取相关问题失败")
+	}
+
+	// 相关问题只包含列表字段，完整内容通过加载器批量获取
+	ids := make([]int, len(related))
+	for i, question := range related {
+		ids[i] = question.ID
+	}
+	questions, err := loadersFrom(ctx).questions.LoadAll(ids)
+	if err != nil {
+		return nil, errors.New("获取相关问题失败")
+	}
+	resolvers := make([]*questionResolver, 0, len(questions))
+	for _, question := range questions {
+		if question != nil {
+			resolvers = append(resolvers, &questionResolver{question})
+		}
+	}
+	return resolvers, nil
+}
+
+func (r *questionResolver) ViewerHasFavorited(ctx context.Context) (bool, error) {
+	return viewerState(loadersFrom(ctx).favoritedQuestions, r.question.ID, "获取收藏状态失败")
+}
+
+// 回答
+type answerResolver struct {
+	answer *models.Answer
+}
+
+func (r *answerResolver) ID() graphql.ID          { return toID(r.answer.ID) }
+func (r *answerResolver) Content() string         { return r.answer.Content }
+func (r *answerResolver) LikeCount() int32        { return int32(r.answer.LikeCount) }
+func (r *answerResolver) IsAccepted() bool        { return r.answer.IsAccepted }
+func (r *answerResolver) CreatedAt() graphql.Time { return graphql.Time{Time: r.answer.CreatedAt} }
+
+func (r *answerResolver) Author(ctx context.Context) (*userResolver, error) {
+	return loadUser(ctx, r.answer.UserID)
+}
+
+func (r *answerResolver) Question(ctx context.Context) (*questionResolver, error) {
+	return loadQuestion(ctx, r.answer.QuestionID)
+}
+
+func (r *answerResolver) ViewerHasLiked(ctx context.Context) (bool, error) {
+	return viewerState(loadersFrom(ctx).likedAnswers, r.answer.ID, "获取点赞状态失败")
+}
+
+// 技术文章
+type articleResolver struct {
+	article *models.TechArticle
+}
+
+func (r *articleResolver) ID() graphql.ID          { return toID(r.article.ID) }
+func (r *articleResolver) Title() string           { return r.article.Title }
+func (r *articleResolver) Summary() string         { return r.article.Summary }
+func (r *articleResolver) Content() string         { return r.article.Content }
+func (r *articleResolver) Category() string        { return r.article.Category }
+func (r *articleResolver) CategoryName() string    { return r.article.CategoryName }
+func (r *articleResolver) CoverImage() string      { return r.article.CoverImage }
+func (r *articleResolver) Tags() []string          { return tagList(r.article.Tags) }
+func (r *articleResolver) Topic() string           { return r.article.TopicSlug }
+func (r *articleResolver) ViewCount() int32        { return int32(r.article.ViewCount) }
+func (r *articleResolver) LikeCount() int32        { return int32(r.article.LikeCount) }
+func (r *articleResolver) CommentCount() int32     { return int32(r.article.CommentCount) }
+func (r *articleResolver) CreatedAt() graphql.Time { return graphql.Time{Time: r.article.CreatedAt} }
+func (r *articleResolver) UpdatedAt() graphql.Time { return graphql.Time{Time: r.article.UpdatedAt} }
+
+func (r *articleResolver) Author(ctx context.Context) (*userResolver, error) {
+	return loadUser(ctx, r.article.UserID)
+}
+
+func (r *articleResolver) Comments(ctx context.Context) ([]*commentResolver, error) {
+	comments, err := loadersFrom(ctx).comments.Load(r.article.ID)
+	if err != nil {
+		return nil, errors.New("获取评论失败")
+	}
+	return commentResolvers(comments), nil
+}
+
+func (r *articleResolver) ViewerHasLiked(ctx context.Context) (bool, error) {
+	return viewerState(loadersFrom(ctx).likedArticles, r.article.ID, "获取点赞状态失败")
+}
+
+func (r *articleResolver) ViewerHasFavorited(ctx context.Context) (bool, error) {
+	return viewerState(loadersFrom(ctx).favoritedArticles, r.article.ID, "获取收藏状态失败")
+}
+
+// 文章评论
+type commentResolver struct {
+	comment *models.Comment
+}
+
+func commentResolvers(comments []models.Comment) []*commentResolver {
+	resolvers := make([]*commentResolver, len(comments))
+	for i := range comments {
+		resolvers[i] = &commentResolver{&comments[i]}
+	}
+	return resolvers
+}
+
+func (r *commentResolver) ID() graphql.ID              { return toID(r.comment.ID) }
+func (r *commentResolver) Content() string             { return r.comment.Content }
+func (r *commentResolver) LikeCount() int32            { return int32(r.comment.LikeCount) }
+func (r *commentResolver) CreatedAt() graphql.Time     { return graphql.Time{Time: r.comment.CreatedAt} }
+func (r *commentResolver) Replies() []*commentResolver { return commentResolvers(r.comment.Replies) }
+
+func (r *commentResolver) Author(ctx context.Context) (*userResolver, error) {
+	return loadUser(ctx, r.comment.UserID)
+}
+
+func (r *commentResolver) ViewerHasLiked(ctx context.Context) (bool, error) {
+	return viewerState(loadersFrom(ctx).likedComments, r.comment.ID, "获取点赞状态失败")
+}
+
+// 学习资料
+type resourceResolver struct {
+	resource *models.LearningResource
+}
+
+func (r *resourceResolver) ID() graphql.ID          { return toID(r.resource.ID) }
+func (r *resourceResolver) Title() string           { return r.resource.Title }
+func (r *resourceResolver) Description() string     { return r.resource.Description }
+func (r *resourceResolver) Type() string            { return r.resource.Type }
+func (r *resourceResolver) Level() string           { return r.resource.Level }
+func (r *resourceResolver) Category() string        { return r.resource.Category }
+func (r *resourceResolver) CategoryName() string    { return r.resource.CategoryName }
+func (r *resourceResolver) CoverImage() string      { return r.resource.CoverImage }
+func (r *resourceResolver) Tags() []string          { return tagList(r.resource.Tags) }
+func (r *resourceResolver) Rating() float64         { return r.resource.Rating }
+func (r *resourceResolver) DownloadCount() int32    { return int32(r.resource.DownloadCount) }
+func (r *resourceResolver) CommentCount() int32     { return int32(r.resource.CommentCount) }
+func (r *resourceResolver) CreatedAt() graphql.Time { return graphql.Time{Time: r.resource.CreatedAt} }
+
+func (r *resourceResolver) Uploader(ctx context.Context) (*userResolver, error) {
+	return loadUser(ctx, r.resource.UserID)
+}
+
+func (r *resourceResolver) ViewerHasFavorited(ctx context.Context) (bool, error) {
+	return viewerState(loadersFrom(ctx).favoritedResources, r.resource.ID, "获取收藏状态失败")
+}
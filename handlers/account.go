@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"aiforum/accounts"
+	"aiforum/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 查询注销申请
+func GetAccountDeletion(c *gin.Context) {
+	scheduledAt, err := models.GetAccountDeletion(c.GetInt("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "获取注销申请失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":      true,
+		"scheduled_at": scheduledAt,
+	})
+}
+
+// 申请注销账户，等待期结束后内容转为匿名并删除个人数据
+func RequestAccountDeletion(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var req struct {
+		Password string `json:"password"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "请输入密码确认注销",
+		})
+		return
+	}
+
+	user, err := models.GetUserByID(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "获取用户信息失败",
+		})
+		return
+	}
+	// 通过第三方登录注册的账户没有密码，无需输入
+	hasPassword, err := models.HasPassword(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "获取用户信息失败",
+		})
+		return
+	}
+	if hasPassword && req.Password == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "请输入密码确认注销",
+		})
+		return
+	}
+	if hasPassword && !models.CheckPassword(req.Password, user.Password) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "密码错误",
+		})
+		return
+	}
+
+	scheduledAt, err := models.ScheduleAccountDeletion(userID)
+	if err == models.ErrGhostAccount {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "申请注销失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":      true,
+		"message":      "账户将在 " + scheduledAt.Format("2006-01-02 15:04") + " 注销，在此之前可以撤销",
+		"scheduled_at": scheduledAt,
+	})
+}
+
+// 撤销注销申请
+func CancelAccountDeletion(c *gin.Context) {
+	err := models.CancelAccountDeletion(c.GetInt("user_id"))
+	if err == models.ErrAccountDeletionNotFound {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "撤销注销失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "已撤销注销申请",
+	})
+}
+
+// 管理员立即注销用户
+func AdminDeleteUser(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的用户ID",
+		})
+		return
+	}
+	if userID == c.GetInt("user_id") {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "不能在此注销自己的账户",
+		})
+		return
+	}
+
+	err = accounts.Delete(userID)
+	switch err {
+	case nil:
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "用户已注销",
+		})
+	case sql.ErrNoRows:
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "用户不存在",
+		})
+	case models.ErrGhostAccount:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "注销用户失败",
+		})
+	}
+}
@@ -45,9 +45,36 @@ func ProfilePage(c *gin.Context) {
 		return
 	}
 
+	emailDigest, err := models.GetEmailDigest(userID)
+	if err != nil {
+		emailDigest = models.EmailDigestOff
+	}
+
+	// 未申请注销或查询失败时不显示注销时间
+	deletionScheduledAt, _ := models.GetAccountDeletion(userID)
+
+	// 查询失败时不显示第三方账号设置
+	accounts, _ := linkedAccounts(userID)
+	hasPassword, err := models.HasPassword(userID)
+	if err != nil {
+		hasPassword = true
+	}
+	mfaStatus, err := models.GetMFAStatus(userID)
+	if err != nil {
+		mfaStatus = &models.MFAStatus{}
+	}
+	personalTokens, _ := models.GetPersonalTokens(userID)
+
 	c.HTML(http.StatusOK, "profile.html", gin.H{
-		"title": "个人资料",
-		"user":  user,
+		"title":               "个人资料",
+		"user":                user,
+		"emailDigest":         emailDigest,
+		"deletionScheduledAt": deletionScheduledAt,
+		"linkedAccounts":      accounts,
+		"hasPassword":         hasPassword,
+		"mfa":                 mfaStatus,
+		"personalTokens":      personalTokens,
+		"tokenScopes":         models.PersonalTokenScopes,
 	})
 }
 
@@ -92,6 +92,26 @@ func Login(c *gin.Context) {
 		return
 	}
 
+	// 开启了两步验证的用户先返回两步验证令牌，验证码通过后再签发token
+	mfaToken, required, err := startMFALogin(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "登录失败"})
+		return
+	}
+	if required {
+		c.JSON(http.StatusOK, gin.H{
+			"message":      "请输入两步验证码",
+			"mfa_required": true,
+			"mfa_token":    mfaToken,
+		})
+		return
+	}
+
+	respondLogin(c, user)
+}
+
+// 签发登录token并返回用户信息
+func respondLogin(c *gin.Context, user *models.User) {
 	// 生成JWT token
 	token, err := utils.GenerateToken(user.ID, user.Username)
 	if err != nil {
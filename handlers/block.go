@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"aiforum/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 屏蔽和静音
+const (
+	relationBlock = "block"
+	relationMute  = "mute"
+)
+
+// 获取屏蔽和静音列表
+func GetUserBlocks(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	blocks, err := models.GetBlockedUsers(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "获取屏蔽列表失败",
+		})
+		return
+	}
+	mutes, err := models.GetMutedUsers(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "获取静音列表失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"blocks":  blocks,
+		"mutes":   mutes,
+	})
+}
+
+// 屏蔽或静音用户
+func AddUserBlock(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var req struct {
+		UserID int    `json:"user_id" binding:"required"`
+		Kind   string `json:"kind"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "请求参数错误",
+		})
+		return
+	}
+	if req.Kind == "" {
+		req.Kind = relationBlock
+	}
+	if req.Kind != relationBlock && req.Kind != relationMute {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的类型",
+		})
+		return
+	}
+
+	if _, err := models.GetUserByID(req.UserID); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "用户不存在",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "操作失败",
+		})
+		return
+	}
+
+	var err error
+	message := "已屏蔽该用户"
+	if req.Kind == relationBlock {
+		err = models.BlockUser(userID, req.UserID)
+	} else {
+		err = models.MuteUser(userID, req.UserID)
+		message = "已静音该用户"
+	}
+	if err == models.ErrCannotBlockSelf || err == models.ErrCannotMuteSelf {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "操作失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": message,
+	})
+}
+
+// 取消屏蔽或静音
+func RemoveUserBlock(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	targetUserID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的用户ID",
+		})
+		return
+	}
+
+	message := "已取消屏蔽"
+	switch c.DefaultQuery("kind", relationBlock) {
+	case relationBlock:
+		err = models.UnblockUser(userID, targetUserID)
+	case relationMute:
+		err = models.UnmuteUser(userID, targetUserID)
+		message = "已取消静音"
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的类型",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "操作失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": message,
+	})
+}
@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"aiforum/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 添加收藏（已收藏时更新收藏夹和备注）
+func AddBookmark(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var req struct {
+		ContentType  string `json:"content_type" binding:"required"`
+		ContentID    int    `json:"content_id" binding:"required"`
+		CollectionID int    `json:"collection_id"`
+		Note         string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "请求参数错误",
+		})
+		return
+	}
+	if !models.ValidBookmarkType(req.ContentType) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   models.ErrInvalidBookmarkType.Error(),
+		})
+		return
+	}
+
+	err := models.AddBookmark(userID, req.ContentType, req.ContentID, req.CollectionID, req.Note)
+	if err != nil {
+		respondBookmarkError(c, err, "收藏失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "收藏成功",
+	})
+}
+
+// 修改收藏的收藏夹和备注
+func UpdateBookmark(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	bookmarkID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的收藏ID",
+		})
+		return
+	}
+
+	var req struct {
+		CollectionID int    `json:"collection_id"`
+		Note         string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "请求参数错误",
+		})
+		return
+	}
+
+	if err := models.UpdateBookmark(userID, bookmarkID, req.CollectionID, req.Note); err != nil {
+		respondBookmarkError(c, err, "修改收藏失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "收藏已更新",
+	})
+}
+
+// 获取自己的收藏夹
+func GetUserCollections(c *gin.Context) {
+	collections, err := models.GetUserCollections(c.GetInt("user_id"), false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "获取收藏夹失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"collections": collections,
+	})
+}
+
+// 收藏夹请求参数
+type collectionRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	IsPublic    bool   `json:"is_public"`
+}
+
+// 创建收藏夹
+func CreateCollection(c *gin.Context) {
+	var req collectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "请输入收藏夹名称",
+		})
+		return
+	}
+
+	id, err := models.CreateCollection(c.GetInt("user_id"), req.Name, req.Description, req.IsPublic)
+	if err != nil {
+		respondBookmarkError(c, err, "创建收藏夹失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "收藏夹已创建",
+		"id":      id,
+	})
+}
+
+// 修改收藏夹
+func UpdateCollection(c *gin.Context) {
+	collectionID, ok := collectionIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req collectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "请输入收藏夹名称",
+		})
+		return
+	}
+
+	err := models.UpdateCollection(c.GetInt("user_id"), collectionID, req.Name, req.Description, req.IsPublic)
+	if err != nil {
+		respondBookmarkError(c, err, "修改收藏夹失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "收藏夹已更新",
+	})
+}
+
+// 删除收藏夹（其中的收藏会保留）
+func DeleteCollection(c *gin.Context) {
+	collectionID, ok := collectionIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := models.DeleteCollection(c.GetInt("user_id"), collectionID); err != nil {
+		respondBookmarkError(c, err, "删除收藏夹失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "收藏夹已删除",
+	})
+}
+
+// 查看收藏夹及其中的收藏（公开收藏夹或自己的收藏夹）
+func GetCollection(c *gin.Context) {
+	collectionID, ok := collectionIDParam(c)
+	if !ok {
+		return
+	}
+
+	collection, err := models.GetCollection(collectionID, c.GetInt("user_id"))
+	if err != nil {
+		respondBookmarkError(c, err, "获取收藏夹失败")
+		return
+	}
+	bookmarks, err := models.GetCollectionBookmarks(collectionID)
+	if err != nil {
+		respondBookmarkError(c, err, "获取收藏夹失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"collection": collection,
+		"bookmarks":  bookmarks,
+	})
+}
+
+// 获取用户的公开收藏夹
+func GetPublicCollections(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的用户ID",
+		})
+		return
+	}
+
+	collections, err := models.GetUserCollections(userID, true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "获取收藏夹失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"collections": collections,
+	})
+}
+
+func collectionIDParam(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的收藏夹ID",
+		})
+		return 0, false
+	}
+	return id, true
+}
+
+// 把收藏相关错误转换为响应
+func respondBookmarkError(c *gin.Context, err error, fallback string) {
+	status := http.StatusInternalServerError
+	message := fallback
+	switch err {
+	case sql.ErrNoRows:
+		status, message = http.StatusNotFound, "内容不存在"
+	case models.ErrBookmarkNotFound, models.ErrCollectionNotFound:
+		status, message = http.StatusNotFound, err.Error()
+	case models.ErrInvalidBookmarkType, models.ErrCollectionNameEmpty:
+		status, message = http.StatusBadRequest, err.Error()
+	case models.ErrCollectionNameExists:
+		status, message = http.StatusConflict, err.Error()
+	}
+
+	c.JSON(status, gin.H{
+		"success": false,
+		"error":   message,
+	})
+}
@@ -0,0 +1,370 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"aiforum/models"
+	"github.com/gin-gonic/gin"
+)
+
+// 私信附件限制
+const (
+	maxMessageAttachments    = 5
+	maxMessageAttachmentSize = 10 * 1024 * 1024
+)
+
+// 每页私信条数上限
+const maxConversationPageSize = 100
+
+// 获取会话列表
+func GetConversations(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	conversations, err := models.GetUserConversations(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "获取会话失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":       true,
+		"conversations": conversations,
+	})
+}
+
+// 发起私信（按用户ID或用户名找到对方，已有会话时直接复用）
+func StartConversation(c *gin.Context) {
+	var req struct {
+		UserID   int    `json:"user_id"`
+		Username string `json:"username"`
+		Content  string `json:"content" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "请填写私信内容",
+		})
+		return
+	}
+
+	sender, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var recipient *models.User
+	var err error
+	if req.UserID > 0 {
+		recipient, err = models.GetUserByID(req.UserID)
+	} else {
+		recipient, err = models.GetUserByUsername(strings.TrimSpace(req.Username))
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "用户不存在",
+		})
+		return
+	}
+
+	if err := models.CanMessageUser(sender, recipient.ID); err != nil {
+		respondDirectMessageError(c, err)
+		return
+	}
+
+	conversationID, err := models.GetOrCreateConversation(sender.ID, recipient.ID)
+	if err != nil {
+		respondDirectMessageError(c, err)
+		return
+	}
+
+	message, err := models.SendDirectMessage(conversationID, sender, req.Content, nil)
+	if err != nil {
+		respondDirectMessageError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":         true,
+		"conversation_id": conversationID,
+		"data":            message,
+	})
+}
+
+// 获取会话消息
+func GetConversationMessages(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	conversationID, ok := conversationIDParam(c)
+	if !ok {
+		return
+	}
+
+	before, _ := strconv.Atoi(c.Query("before"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "30"))
+	if limit < 1 || limit > maxConversationPageSize {
+		limit = 30
+	}
+
+	messages, err := models.GetConversationMessages(conversationID, userID, before, limit)
+	if err != nil {
+		respondDirectMessageError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"messages": messages,
+		"has_more": len(messages) == limit,
+	})
+}
+
+// 发送私信（支持JSON或带附件的multipart表单）
+func SendDirectMessage(c *gin.Context) {
+	conversationID, ok := conversationIDParam(c)
+	if !ok {
+		return
+	}
+
+	sender, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var content string
+	var attachments []*models.MessageAttachment
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		// 先确认是会话成员再保存附件
+		if _, err := models.GetConversationPeer(conversationID, sender.ID); err != nil {
+			respondDirectMessageError(c, err)
+			return
+		}
+		content = c.PostForm("content")
+
+		form, err := c.MultipartForm()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "附件上传失败",
+			})
+			return
+		}
+		attachments, err = saveMessageAttachments(conversationID, sender.ID, form.File["attachments"])
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+	} else {
+		var req struct {
+			Content string `json:"content"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "请填写私信内容",
+			})
+			return
+		}
+		content = req.Content
+	}
+
+	message, err := models.SendDirectMessage(conversationID, sender, content, attachments)
+	if err != nil {
+		removeMessageAttachments(attachments)
+		respondDirectMessageError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    message,
+	})
+}
+
+// 标记会话已读
+func MarkConversationRead(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	conversationID, ok := conversationIDParam(c)
+	if !ok {
+		return
+	}
+
+	lastReadID, err := models.MarkConversationRead(conversationID, userID)
+	if err != nil {
+		respondDirectMessageError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":              true,
+		"last_read_message_id": lastReadID,
+	})
+}
+
+// 屏蔽会话对方
+func BlockConversationPeer(c *gin.Context) {
+	setConversationBlock(c, true)
+}
+
+// 取消屏蔽会话对方
+func UnblockConversationPeer(c *gin.Context) {
+	setConversationBlock(c, false)
+}
+
+func setConversationBlock(c *gin.Context, block bool) {
+	userID := c.GetInt("user_id")
+	conversationID, ok := conversationIDParam(c)
+	if !ok {
+		return
+	}
+
+	peerID, err := models.GetConversationPeer(conversationID, userID)
+	if err != nil {
+		respondDirectMessageError(c, err)
+		return
+	}
+
+	message := "已屏蔽对方"
+	if block {
+		err = models.BlockUser(userID, peerID)
+	} else {
+		err = models.UnblockUser(userID, peerID)
+		message = "已取消屏蔽"
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "操作失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": message,
+	})
+}
+
+// 下载私信附件
+func DownloadMessageAttachment(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	conversationID, ok := conversationIDParam(c)
+	if !ok {
+		return
+	}
+	attachmentID, err := strconv.Atoi(c.Param("attachment_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的附件ID",
+		})
+		return
+	}
+
+	attachment, err := models.GetMessageAttachment(conversationID, attachmentID, userID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "附件不存在",
+		})
+		return
+	}
+	if err != nil {
+		respondDirectMessageError(c, err)
+		return
+	}
+
+	c.FileAttachment(attachment.FilePath, attachment.FileName)
+}
+
+// 获取当前登录用户
+func currentUser(c *gin.Context) (*models.User, bool) {
+	user, err := models.GetUserByID(c.GetInt("user_id"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "用户不存在",
+		})
+		return nil, false
+	}
+	return user, true
+}
+
+func conversationIDParam(c *gin.Context) (int, bool) {
+	conversationID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的会话ID",
+		})
+		return 0, false
+	}
+	return conversationID, true
+}
+
+// 保存私信附件，每条消息的附件放在独立目录中避免重名覆盖
+func saveMessageAttachments(conversationID, userID int, files []*multipart.FileHeader) ([]*models.MessageAttachment, error) {
+	if len(files) > maxMessageAttachments {
+		return nil, fmt.Errorf("每条私信最多%d个附件", maxMessageAttachments)
+	}
+
+	base := fmt.Sprintf("messages/%d/%d-%d", conversationID, userID, time.Now().UnixNano())
+	var attachments []*models.MessageAttachment
+	for i, file := range files {
+		if file.Size > maxMessageAttachmentSize {
+			removeMessageAttachments(attachments)
+			return nil, fmt.Errorf("附件大小不能超过%dMB", maxMessageAttachmentSize/1024/1024)
+		}
+
+		path, err := models.UploadFile(file, fmt.Sprintf("%s/%d", base, i))
+		if err != nil {
+			removeMessageAttachments(attachments)
+			return nil, fmt.Errorf("附件上传失败")
+		}
+		attachments = append(attachments, &models.MessageAttachment{
+			FileName:    filepath.Base(file.Filename),
+			FilePath:    path,
+			FileSize:    file.Size,
+			ContentType: file.Header.Get("Content-Type"),
+		})
+	}
+	return attachments, nil
+}
+
+// 删除已保存的附件文件
+func removeMessageAttachments(attachments []*models.MessageAttachment) {
+	for _, attachment := range attachments {
+		os.Remove(attachment.FilePath)
+	}
+}
+
+// 私信错误对应的响应
+func respondDirectMessageError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	message := "私信操作失败"
+	switch err {
+	case models.ErrConversationNotFound:
+		status, message = http.StatusNotFound, err.Error()
+	case models.ErrMessageBlocked, models.ErrMessageNotAllowed:
+		status, message = http.StatusForbidden, err.Error()
+	case models.ErrCannotMessageSelf, models.ErrEmptyDirectMessage:
+		status, message = http.StatusBadRequest, err.Error()
+	}
+	c.JSON(status, gin.H{
+		"success": false,
+		"error":   message,
+	})
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"aiforum/exporter"
+	"aiforum/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 导出任务的响应数据，可下载时附带下载地址
+func exportResponse(export *models.DataExport) gin.H {
+	if export == nil {
+		return nil
+	}
+	data := gin.H{
+		"id":           export.ID,
+		"status":       export.Status,
+		"file_size":    export.FileSize,
+		"created_at":   export.CreatedAt,
+		"completed_at": export.CompletedAt,
+		"expires_at":   export.ExpiresAt,
+	}
+	if export.Error != "" {
+		data["error"] = export.Error
+	}
+	if export.Downloadable() {
+		data["download_url"] = fmt.Sprintf("/api/user/export/%d/download", export.ID)
+	}
+	return data
+}
+
+// 申请导出个人数据
+func RequestDataExport(c *gin.Context) {
+	export, err := models.RequestDataExport(c.GetInt("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "申请导出失败",
+		})
+		return
+	}
+	exporter.Wake()
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"message": "导出任务已创建，完成后可在此下载",
+		"export":  exportResponse(export),
+	})
+}
+
+// 获取最近一次导出任务
+func GetLatestDataExport(c *gin.Context) {
+	export, err := models.GetLatestDataExport(c.GetInt("user_id"))
+	if err != nil && err != sql.ErrNoRows {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "获取导出任务失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"export":  exportResponse(export),
+	})
+}
+
+// 查询导出任务状态
+func GetDataExport(c *gin.Context) {
+	export, ok := dataExportParam(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"export":  exportResponse(export),
+	})
+}
+
+// 下载导出文件
+func DownloadDataExport(c *gin.Context) {
+	export, ok := dataExportParam(c)
+	if !ok {
+		return
+	}
+	if !export.Downloadable() {
+		c.JSON(http.StatusGone, gin.H{
+			"success": false,
+			"error":   "导出文件尚未生成或已过期",
+		})
+		return
+	}
+
+	name := "aiforum-export"
+	if user, err := models.GetUserByID(export.UserID); err == nil {
+		name += "-" + user.Username
+	}
+	c.FileAttachment(export.FilePath, name+"-"+export.CreatedAt.Format("20060102")+".zip")
+}
+
+// 读取路径中的导出任务，只能访问自己的任务
+func dataExportParam(c *gin.Context) (*models.DataExport, bool) {
+	exportID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的导出任务ID",
+		})
+		return nil, false
+	}
+
+	export, err := models.GetDataExport(c.GetInt("user_id"), exportID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "导出任务不存在",
+		})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "获取导出任务失败",
+		})
+		return nil, false
+	}
+	return export, true
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"aiforum/models"
+	"aiforum/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// 动态流每页最大条数
+const maxFeedLimit = 50
+
+// 获取个性化动态流
+func GetFeed(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	cursor, err := utils.DecodeCursor(c.Query("cursor"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > maxFeedLimit {
+		limit = 20
+	}
+
+	items, err := models.GetUserFeed(userID, cursor, limit, c.Query("unseen") == "1")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "获取动态失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"items":       items,
+		"next_cursor": nextFeedCursor(items, limit),
+	})
+}
+
+// 生成下一页游标（没有更多内容时为空）
+func nextFeedCursor(items []*models.FeedItem, limit int) string {
+	if len(items) < limit {
+		return ""
+	}
+	last := items[len(items)-1]
+	return utils.EncodeCursor(utils.Cursor{CreatedAt: last.CreatedAt, ID: last.ID, Type: last.Type})
+}
+
+// 标记动态为已读
+func MarkFeedSeen(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var req struct {
+		Items []models.FeedItemRef `json:"items" binding:"required,max=100"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "请求参数错误",
+		})
+		return
+	}
+
+	if err := models.MarkFeedItemsSeen(userID, req.Items); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "标记已读失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// 获取关注的标签和分类
+func GetUserInterests(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	interests, err := models.GetUserInterests(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "获取关注列表失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"interests": interests,
+	})
+}
+
+// 关注标签或分类（标签可按名称关注）
+func FollowInterest(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var req struct {
+		Type string `json:"type" binding:"required"`
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "请求参数错误",
+		})
+		return
+	}
+
+	if req.Type == models.InterestTypeTag && req.ID == 0 && req.Name != "" {
+		tag, err := models.GetTagByName(req.Name)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "标签不存在",
+			})
+			return
+		}
+		req.ID = tag.ID
+	}
+
+	err := models.FollowInterest(userID, req.Type, req.ID)
+	if err == models.ErrInvalidInterestType {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "关注对象不存在",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "关注失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "关注成功",
+	})
+}
+
+// 取消关注标签或分类
+func UnfollowInterest(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	targetID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的关注对象ID",
+		})
+		return
+	}
+
+	err = models.UnfollowInterest(userID, c.Param("type"), targetID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "取消关注失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "已取消关注",
+	})
+}
@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"aiforum/models"
@@ -11,7 +10,13 @@ import (
 // LearningResourcesPage 学习资料页面
 func LearningResourcesPage(c *gin.Context) {
 	// 获取查询参数
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	page, err := pageQuery(c, 12)
+	if err != nil {
+		c.HTML(http.StatusBadRequest, "error.html", gin.H{
+			"error": "无效的分页参数",
+		})
+		return
+	}
 	keyword := c.Query("keyword")
 	resourceType := c.Query("type")
 	level := c.Query("level")
@@ -19,14 +24,13 @@ func LearningResourcesPage(c *gin.Context) {
 	rating := c.Query("rating")
 	category := c.Query("category")
 	
-	// 设置默认值
-	if page < 1 {
-		page = 1
+	// 记录搜索词
+	if keyword != "" {
+		go models.RecordSearchQuery(c.GetInt("user_id"), keyword, "resource")
 	}
-	limit := 12
 	
 	// 获取学习资料列表
-	resources, total, err := models.GetLearningResources(page, limit, keyword, resourceType, level, timeFilter, rating, category)
+	resources, pageInfo, err := models.GetLearningResources(keyword, resourceType, level, timeFilter, rating, category, c.GetInt("user_id"), page)
 	if err != nil {
 		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
 			"error": "获取资料列表失败",
@@ -52,16 +56,13 @@ func LearningResourcesPage(c *gin.Context) {
 		user, _ = models.GetUserByID(userID.(int))
 	}
 	
-	// 计算总页数
-	totalPages := (total + limit - 1) / limit
-	
 	c.HTML(http.StatusOK, "learning_resources.html", gin.H{
 		"title":              "学习资料",
 		"resources":          resources,
 		"latestResources":    latestResources,
 		"topRatedResources":  topRatedResources,
-		"currentPage":        page,
-		"totalPages":         totalPages,
+		"nextCursor":         pageInfo.NextCursor,
+		"prevCursor":         pageInfo.PrevCursor,
 		"keyword":            keyword,
 		"type":               resourceType,
 		"level":              level,
@@ -86,13 +87,15 @@ func CategoryPage(c *gin.Context) {
 	}
 	
 	// 获取该分类下的资料
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	if page < 1 {
-		page = 1
+	page, err := pageQuery(c, 12)
+	if err != nil {
+		c.HTML(http.StatusBadRequest, "error.html", gin.H{
+			"error": "无效的分页参数",
+		})
+		return
 	}
-	limit := 12
 	
-	resources, total, err := models.GetLearningResources(page, limit, "", "", "", "", "", category)
+	resources, pageInfo, err := models.GetLearningResources("", "", "", "", "", category, 0, page)
 	if err != nil {
 		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
 			"error": "获取资料列表失败",
@@ -112,18 +115,58 @@ func CategoryPage(c *gin.Context) {
 		topRatedResources = []models.LearningResource{}
 	}
 	
-	// 计算总页数
-	totalPages := (total + limit - 1) / limit
+	var user *models.User
+	if userID, exists := c.Get("user_id"); exists {
+		user, _ = models.GetUserByID(userID.(int))
+	}
 	
 	c.HTML(http.StatusOK, "learning_resources.html", gin.H{
 		"title":              categoryInfo.Name,
 		"resources":          resources,
 		"latestResources":    latestResources,
 		"topRatedResources":  topRatedResources,
-		"currentPage":        page,
-		"totalPages":         totalPages,
+		"nextCursor":         pageInfo.NextCursor,
+		"prevCursor":         pageInfo.PrevCursor,
 		"currentCategory":    category,
 		"categoryInfo":       categoryInfo,
+		"user":               user,
+	})
+}
+
+// LearningResourceDetailPage 学习资料详情页面
+func LearningResourceDetailPage(c *gin.Context) {
+	resourceID := c.Param("id")
+	
+	resource, err := models.GetLearningResourceByID(resourceID)
+	if err != nil {
+		c.HTML(http.StatusNotFound, "error.html", gin.H{
+			"error": "资料不存在",
+		})
+		return
+	}
+	
+	comments, err := models.GetResourceComments(resourceID, 50)
+	if err != nil {
+		comments = []models.ResourceComment{}
+	}
+	
+	var user *models.User
+	if userID, exists := c.Get("user_id"); exists {
+		user, _ = models.GetUserByID(userID.(int))
+	}
+	
+	// 一次查出评论中提及的用户
+	var contents []string
+	for _, comment := range comments {
+		contents = append(contents, comment.Content)
+	}
+	
+	c.HTML(http.StatusOK, "learning_resource_detail.html", gin.H{
+		"title":          resource.Title,
+		"resource":       resource,
+		"comments":       comments,
+		"mentionedUsers": models.LoadMentionedUsers(contents...),
+		"user":           user,
 	})
 }
 
@@ -302,6 +345,10 @@ func CommentLearningResource(c *gin.Context) {
 	
 	// 提交评论
 	err := models.CommentLearningResource(resourceID, userID.(int), req.Content)
+	if err == models.ErrBlocked {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "评论失败"})
 		return
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+
+	"aiforum/models"
+	"aiforum/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// 退订邮件页面（邮件中的退订链接）。只显示确认表单，不修改设置，
+// 避免邮件安全扫描等预取链接的请求误退订
+func UnsubscribePage(c *gin.Context) {
+	token := c.Query("token")
+	userID, err := utils.ValidateUnsubscribeToken(token)
+	if err != nil {
+		c.HTML(http.StatusBadRequest, "error.html", gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	user, err := models.GetUserByID(userID)
+	if err != nil {
+		c.HTML(http.StatusNotFound, "error.html", gin.H{
+			"error": "用户不存在",
+		})
+		return
+	}
+
+	c.HTML(http.StatusOK, "unsubscribe.html", gin.H{
+		"username": user.Username,
+		"token":    token,
+	})
+}
+
+// 退订邮件。确认表单提交后显示结果页面；
+// 邮件客户端根据List-Unsubscribe-Post发起的一键退订返回JSON
+func Unsubscribe(c *gin.Context) {
+	oneClick := c.PostForm("List-Unsubscribe") == "One-Click"
+	fail := func(status int, message string) {
+		if oneClick {
+			c.JSON(status, gin.H{
+				"success": false,
+				"error":   message,
+			})
+			return
+		}
+		c.HTML(status, "error.html", gin.H{
+			"error": message,
+		})
+	}
+
+	token := c.Query("token")
+	if token == "" {
+		token = c.PostForm("token")
+	}
+	userID, err := utils.ValidateUnsubscribeToken(token)
+	if err != nil {
+		fail(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	switch err := models.UnsubscribeEmail(userID); err {
+	case nil:
+	case sql.ErrNoRows:
+		fail(http.StatusNotFound, "用户不存在")
+		return
+	default:
+		fail(http.StatusInternalServerError, "退订失败，请稍后重试")
+		return
+	}
+
+	if oneClick {
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "已退订邮件",
+		})
+		return
+	}
+
+	user, err := models.GetUserByID(userID)
+	if err != nil {
+		c.HTML(http.StatusNotFound, "error.html", gin.H{
+			"error": "用户不存在",
+		})
+		return
+	}
+
+	c.HTML(http.StatusOK, "unsubscribe.html", gin.H{
+		"username":     user.Username,
+		"unsubscribed": true,
+	})
+}
@@ -0,0 +1,438 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"aiforum/models"
+	"aiforum/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 两步验证码连续错误的次数上限，超过后需要等待
+const (
+	maxMFAFailures = 5
+	mfaLockout     = 5 * time.Minute
+)
+
+// 登录流程中保存两步验证令牌的Cookie（第三方登录回调后使用）
+const (
+	mfaPendingCookie = "mfa_pending"
+	mfaCookiePath    = "/auth/mfa"
+)
+
+// 验证器App中显示的发行方
+const totpIssuer = "AI论坛"
+
+var (
+	errInvalidMFACode     = errors.New("验证码错误")
+	errTooManyMFAFailures = errors.New("验证码错误次数过多，请稍后再试")
+)
+
+// 每个用户最近的验证码错误次数
+var mfaFailures = struct {
+	sync.Mutex
+	byUser map[int]*mfaFailure
+}{byUser: map[int]*mfaFailure{}}
+
+type mfaFailure struct {
+	count int
+	since time.Time
+}
+
+// 校验TOTP验证码或恢复码，验证码只能使用一次
+func verifySecondFactor(userID int, code string) error {
+	mfaFailures.Lock()
+	failure := mfaFailures.byUser[userID]
+	if failure != nil && time.Since(failure.since) > mfaLockout {
+		delete(mfaFailures.byUser, userID)
+		failure = nil
+	}
+	locked := failure != nil && failure.count >= maxMFAFailures
+	mfaFailures.Unlock()
+	if locked {
+		return errTooManyMFAFailures
+	}
+
+	ok, err := checkSecondFactor(userID, strings.TrimSpace(code))
+	if err != nil {
+		return err
+	}
+
+	mfaFailures.Lock()
+	defer mfaFailures.Unlock()
+	if ok {
+		delete(mfaFailures.byUser, userID)
+		return nil
+	}
+	if failure = mfaFailures.byUser[userID]; failure == nil {
+		failure = &mfaFailure{since: time.Now()}
+		mfaFailures.byUser[userID] = failure
+	}
+	failure.count++
+	return errInvalidMFACode
+}
+
+// 6位数字按TOTP验证码校验，其他按恢复码校验
+func checkSecondFactor(userID int, code string) (bool, error) {
+	secret, enabled, err := models.GetTOTPSecret(userID)
+	if err != nil {
+		return false, err
+	}
+	if !enabled {
+		return false, models.ErrMFANotEnabled
+	}
+
+	if len(code) == 6 && strings.Trim(code, "0123456789") == "" {
+		step, ok := utils.ValidateTOTP(secret, code, time.Now())
+		if !ok {
+			return false, nil
+		}
+		return models.UseTOTPStep(userID, step)
+	}
+	if code == "" {
+		return false, nil
+	}
+	return models.UseRecoveryCode(userID, utils.HashRecoveryCode(code))
+}
+
+// 返回两步验证错误
+func respondMFAError(c *gin.Context, err error) {
+	switch err {
+	case errInvalidMFACode, models.ErrMFANotEnabled:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+	case errTooManyMFAFailures:
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "两步验证失败",
+		})
+	}
+}
+
+// 开启了两步验证的用户登录时需要输入验证码的页面（第三方登录回调后跳转）
+func MFAPage(c *gin.Context) {
+	token, err := c.Cookie(mfaPendingCookie)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/auth/login")
+		return
+	}
+	if _, err := utils.ValidateMFAPendingToken(token); err != nil {
+		c.Redirect(http.StatusFound, "/auth/login")
+		return
+	}
+
+	c.HTML(http.StatusOK, "mfa.html", gin.H{
+		"title": "两步验证",
+	})
+}
+
+// 登录第二步：校验验证码后签发登录token
+func VerifyMFALogin(c *gin.Context) {
+	var req struct {
+		MFAToken string `json:"mfa_token"`
+		Code     string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请输入验证码"})
+		return
+	}
+	if req.MFAToken == "" {
+		req.MFAToken, _ = c.Cookie(mfaPendingCookie)
+	}
+
+	userID, err := utils.ValidateMFAPendingToken(req.MFAToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "登录已过期，请重新登录"})
+		return
+	}
+
+	if err := verifySecondFactor(userID, req.Code); err != nil {
+		switch err {
+		case errInvalidMFACode, models.ErrMFANotEnabled:
+			c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidMFACode.Error()})
+		case errTooManyMFAFailures:
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "登录失败"})
+		}
+		return
+	}
+
+	user, err := models.GetUserByID(userID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "登录已过期，请重新登录"})
+		return
+	}
+	c.SetCookie(mfaPendingCookie, "", -1, mfaCookiePath, "", false, true)
+	respondLogin(c, user)
+}
+
+// 开启了两步验证时签发两步验证令牌，返回是否需要两步验证
+func startMFALogin(userID int) (string, bool, error) {
+	enabled, err := models.TOTPEnabled(userID)
+	if err != nil || !enabled {
+		return "", false, err
+	}
+	token, err := utils.GenerateMFAPendingToken(userID)
+	if err != nil {
+		return "", false, err
+	}
+	return token, true, nil
+}
+
+// 获取两步验证设置
+func GetMFAStatus(c *gin.Context) {
+	status, err := models.GetMFAStatus(c.GetInt("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "获取两步验证设置失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"mfa":     status,
+	})
+}
+
+// 生成TOTP密钥，返回验证器App扫码使用的地址
+func EnrollMFA(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "生成密钥失败",
+		})
+		return
+	}
+	err = models.SetPendingTOTPSecret(userID, secret)
+	if err == models.ErrMFAAlreadyEnabled {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "生成密钥失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":          true,
+		"secret":           secret,
+		"provisioning_uri": utils.TOTPProvisioningURI(secret, c.GetString("username"), totpIssuer),
+	})
+}
+
+// 输入验证器App中的验证码确认开启两步验证，返回恢复码（只显示一次）
+func EnableMFA(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var req struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "请输入验证码",
+		})
+		return
+	}
+
+	secret, enabled, err := models.GetTOTPSecret(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "开启两步验证失败",
+		})
+		return
+	}
+	if enabled {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   models.ErrMFAAlreadyEnabled.Error(),
+		})
+		return
+	}
+	if secret == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   models.ErrMFANotEnrolled.Error(),
+		})
+		return
+	}
+
+	step, ok := utils.ValidateTOTP(secret, strings.TrimSpace(req.Code), time.Now())
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   errInvalidMFACode.Error(),
+		})
+		return
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "开启两步验证失败",
+		})
+		return
+	}
+	err = models.EnableTOTP(userID, step, hashes)
+	if err == models.ErrMFAAlreadyEnabled {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "开启两步验证失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":        true,
+		"message":        "已开启两步验证，请妥善保存恢复码",
+		"recovery_codes": codes,
+	})
+}
+
+// 关闭两步验证，需要重新输入密码和验证码
+func DisableMFA(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var req struct {
+		Password string `json:"password"`
+		Code     string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "请输入验证码",
+		})
+		return
+	}
+
+	user, err := models.GetUserByID(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "获取用户信息失败",
+		})
+		return
+	}
+	if models.MFARequired(user.Role) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error":   "管理员和版主必须开启两步验证",
+		})
+		return
+	}
+
+	// 通过第三方登录注册的账户没有密码，只校验验证码
+	hasPassword, err := models.HasPassword(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "获取用户信息失败",
+		})
+		return
+	}
+	if hasPassword && !utils.CheckPassword(req.Password, user.Password) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "密码错误",
+		})
+		return
+	}
+
+	if err := verifySecondFactor(userID, req.Code); err != nil {
+		respondMFAError(c, err)
+		return
+	}
+	if err := models.DisableTOTP(userID); err != nil {
+		respondMFAError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "已关闭两步验证",
+	})
+}
+
+// 重新生成恢复码
+func RegenerateRecoveryCodes(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var req struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "请输入验证码",
+		})
+		return
+	}
+	if err := verifySecondFactor(userID, req.Code); err != nil {
+		respondMFAError(c, err)
+		return
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err == nil {
+		err = models.ReplaceRecoveryCodes(userID, hashes)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "生成恢复码失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":        true,
+		"message":        "已重新生成恢复码，旧的恢复码已失效",
+		"recovery_codes": codes,
+	})
+}
+
+// 生成恢复码及其哈希
+func newRecoveryCodes() ([]string, []string, error) {
+	codes, err := utils.GenerateRecoveryCodes()
+	if err != nil {
+		return nil, nil, err
+	}
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = utils.HashRecoveryCode(code)
+	}
+	return codes, hashes, nil
+}
@@ -0,0 +1,408 @@
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"net/mail"
+	"strings"
+
+	"aiforum/models"
+	"aiforum/oauth"
+	"aiforum/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 第三方登录过程中使用的Cookie
+const (
+	oauthStateCookie  = "oauth_state"
+	oauthSignupCookie = "oauth_signup"
+	oauthCookiePath   = "/auth/oauth"
+)
+
+// 一种登录方式及当前用户的绑定情况
+type linkedAccount struct {
+	Provider    string               `json:"provider"`
+	DisplayName string               `json:"display_name"`
+	Identity    *models.UserIdentity `json:"identity"`
+}
+
+// 获取启用的第三方登录方式
+func OAuthProviders(c *gin.Context) {
+	list := []gin.H{}
+	for _, p := range oauth.Providers() {
+		list = append(list, gin.H{
+			"name":         p.Name(),
+			"display_name": p.DisplayName(),
+			"login_url":    "/auth/oauth/" + p.Name(),
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"providers": list})
+}
+
+// 跳转到第三方授权页面登录
+func OAuthLogin(c *gin.Context) {
+	authURL, err := startOAuth(c, c.Param("provider"), 0)
+	if err == oauth.ErrUnknownProvider {
+		c.HTML(http.StatusNotFound, "error.html", gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("跳转第三方登录失败 (%s): %v", c.Param("provider"), err)
+		c.HTML(http.StatusBadGateway, "error.html", gin.H{
+			"error": "第三方登录暂时不可用，请稍后重试",
+		})
+		return
+	}
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// 生成授权状态并保存到Cookie，返回第三方授权页面地址
+func startOAuth(c *gin.Context, providerName string, linkUserID int) (string, error) {
+	provider, err := oauth.Get(providerName)
+	if err != nil {
+		return "", err
+	}
+
+	state := &utils.OAuthState{Provider: provider.Name(), LinkUserID: linkUserID}
+	for _, v := range []*string{&state.State, &state.Nonce, &state.Verifier} {
+		if *v, err = oauth.RandomString(); err != nil {
+			return "", err
+		}
+	}
+	authURL, err := provider.AuthCodeURL(state.State, state.Nonce, state.Verifier)
+	if err != nil {
+		return "", err
+	}
+
+	token, err := utils.GenerateOAuthState(state)
+	if err != nil {
+		return "", err
+	}
+	c.SetCookie(oauthStateCookie, token, int(utils.OAuthFlowTTL.Seconds()), oauthCookiePath, "", false, true)
+	return authURL, nil
+}
+
+// 第三方授权回调：已绑定的账号直接登录，绑定流程中绑定到当前用户，
+// 新账号跳转到注册页面选择用户名
+func OAuthCallback(c *gin.Context) {
+	stateCookie, _ := c.Cookie(oauthStateCookie)
+	c.SetCookie(oauthStateCookie, "", -1, oauthCookiePath, "", false, true)
+
+	if c.Query("error") != "" {
+		c.HTML(http.StatusBadRequest, "error.html", gin.H{
+			"error": "已取消第三方授权",
+		})
+		return
+	}
+
+	state, err := utils.ValidateOAuthState(stateCookie)
+	if err != nil || state.Provider != c.Param("provider") || state.State != c.Query("state") {
+		c.HTML(http.StatusBadRequest, "error.html", gin.H{
+			"error": "登录请求已过期，请重新登录",
+		})
+		return
+	}
+
+	provider, err := oauth.Get(state.Provider)
+	if err != nil {
+		c.HTML(http.StatusBadRequest, "error.html", gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	identity, err := provider.Exchange(c.Request.Context(), c.Query("code"), state.Nonce, state.Verifier)
+	if err != nil {
+		log.Printf("第三方登录失败 (%s): %v", state.Provider, err)
+		c.HTML(http.StatusBadGateway, "error.html", gin.H{
+			"error": "第三方登录失败，请稍后重试",
+		})
+		return
+	}
+
+	if state.LinkUserID > 0 {
+		err := models.LinkIdentity(state.LinkUserID, identity.Provider, identity.Subject, identity.Email)
+		if err == models.ErrIdentityLinked || err == models.ErrProviderLinked {
+			c.HTML(http.StatusConflict, "error.html", gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if err != nil {
+			c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+				"error": "绑定失败",
+			})
+			return
+		}
+		c.Redirect(http.StatusFound, "/profile")
+		return
+	}
+
+	user, err := models.FindIdentityUser(identity.Provider, identity.Subject)
+	if err != nil && err != sql.ErrNoRows {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"error": "登录失败",
+		})
+		return
+	}
+	if err == nil {
+		mfaToken, required, err := startMFALogin(user.ID)
+		if err != nil {
+			c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+				"error": "登录失败",
+			})
+			return
+		}
+		if required {
+			c.SetCookie(mfaPendingCookie, mfaToken, int(utils.MFAPendingTTL.Seconds()), mfaCookiePath, "", false, true)
+			c.Redirect(http.StatusFound, "/auth/mfa")
+			return
+		}
+		if err := setLoginCookie(c, user); err != nil {
+			c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+				"error": "登录失败",
+			})
+			return
+		}
+		c.Redirect(http.StatusFound, "/")
+		return
+	}
+
+	// 首次登录，保存第三方账号信息等待选择用户名
+	email := identity.Email
+	if !identity.EmailVerified {
+		email = ""
+	}
+	signup, err := utils.GenerateOAuthSignup(&utils.OAuthSignup{
+		Provider:  identity.Provider,
+		Subject:   identity.Subject,
+		Email:     email,
+		Username:  identity.Username,
+		AvatarURL: identity.AvatarURL,
+	})
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"error": "登录失败",
+		})
+		return
+	}
+	c.SetCookie(oauthSignupCookie, signup, int(utils.OAuthFlowTTL.Seconds()), oauthCookiePath, "", false, true)
+	c.Redirect(http.StatusFound, "/auth/oauth/complete")
+}
+
+// 首次第三方登录时选择用户名的页面
+func OAuthCompletePage(c *gin.Context) {
+	signup, err := oauthSignup(c)
+	if err != nil {
+		c.Redirect(http.StatusFound, "/auth/login")
+		return
+	}
+
+	displayName := signup.Provider
+	if provider, err := oauth.Get(signup.Provider); err == nil {
+		displayName = provider.DisplayName()
+	}
+
+	c.HTML(http.StatusOK, "oauth_complete.html", gin.H{
+		"title":    "完成注册",
+		"provider": displayName,
+		"username": signup.Username,
+		"email":    signup.Email,
+	})
+}
+
+// 首次第三方登录：创建用户并绑定第三方账号
+func OAuthComplete(c *gin.Context) {
+	signup, err := oauthSignup(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "登录请求已过期，请重新登录"})
+		return
+	}
+
+	var req struct {
+		Username string `json:"username" binding:"required,max=50"`
+		Email    string `json:"email"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请填写用户名"})
+		return
+	}
+	req.Username = strings.TrimSpace(req.Username)
+
+	// 第三方账号提供了已验证的邮箱时使用该邮箱
+	email := signup.Email
+	if email == "" {
+		email = strings.TrimSpace(req.Email)
+	}
+	if req.Username == "" || email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请填写用户名和邮箱"})
+		return
+	}
+	if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "邮箱格式不正确"})
+		return
+	}
+
+	exists, err := models.UsernameExists(req.Username)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "服务器错误"})
+		return
+	}
+	if exists {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "用户名已存在"})
+		return
+	}
+
+	// 不按邮箱自动合并账号，已有账号需要登录后在个人中心绑定
+	exists, err = models.EmailExists(email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "服务器错误"})
+		return
+	}
+	if exists {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "邮箱已被注册，请登录后在个人中心绑定该账号"})
+		return
+	}
+
+	userID, err := models.CreateUserWithIdentity(req.Username, email, signup.AvatarURL, signup.Provider, signup.Subject)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "注册失败"})
+		return
+	}
+	user, err := models.GetUserByID(userID)
+	if err != nil || setLoginCookie(c, user) != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "登录失败"})
+		return
+	}
+	c.SetCookie(oauthSignupCookie, "", -1, oauthCookiePath, "", false, true)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "注册成功",
+		"user": gin.H{
+			"id":       user.ID,
+			"username": user.Username,
+			"avatar":   user.Avatar,
+		},
+	})
+}
+
+// 读取待注册的第三方账号
+func oauthSignup(c *gin.Context) (*utils.OAuthSignup, error) {
+	token, err := c.Cookie(oauthSignupCookie)
+	if err != nil {
+		return nil, err
+	}
+	return utils.ValidateOAuthSignup(token)
+}
+
+// 生成登录token并写入Cookie
+func setLoginCookie(c *gin.Context, user *models.User) error {
+	token, err := utils.GenerateToken(user.ID, user.Username)
+	if err != nil {
+		return err
+	}
+	c.SetCookie("token", token, 24*60*60, "/", "", false, true)
+	return nil
+}
+
+// 获取当前用户各登录方式的绑定情况
+func linkedAccounts(userID int) ([]*linkedAccount, error) {
+	identities, err := models.GetUserIdentities(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := []*linkedAccount{}
+	for _, p := range oauth.Providers() {
+		account := &linkedAccount{Provider: p.Name(), DisplayName: p.DisplayName()}
+		for _, identity := range identities {
+			if identity.Provider == p.Name() {
+				account.Identity = identity
+			}
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, nil
+}
+
+// 获取绑定的第三方账号
+func GetLinkedAccounts(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	accounts, err := linkedAccounts(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "获取绑定账号失败",
+		})
+		return
+	}
+	hasPassword, err := models.HasPassword(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "获取绑定账号失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":      true,
+		"accounts":     accounts,
+		"has_password": hasPassword,
+	})
+}
+
+// 开始绑定第三方账号，返回授权页面地址
+func LinkAccount(c *gin.Context) {
+	authURL, err := startOAuth(c, c.Param("provider"), c.GetInt("user_id"))
+	if err == oauth.ErrUnknownProvider {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("跳转第三方登录失败 (%s): %v", c.Param("provider"), err)
+		c.JSON(http.StatusBadGateway, gin.H{
+			"success": false,
+			"error":   "第三方登录暂时不可用，请稍后重试",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"auth_url": authURL,
+	})
+}
+
+// 解除绑定第三方账号
+func UnlinkAccount(c *gin.Context) {
+	err := models.UnlinkIdentity(c.GetInt("user_id"), c.Param("provider"))
+	switch err {
+	case nil:
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "已解除绑定",
+		})
+	case models.ErrIdentityNotFound:
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+	case models.ErrLastLoginMethod:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "解除绑定失败",
+		})
+	}
+}
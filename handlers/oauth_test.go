@@ -0,0 +1,520 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"html/template"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"aiforum/config"
+	"aiforum/models"
+	"aiforum/oauth"
+	"aiforum/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testClientID     = "forum"
+	testClientSecret = "forum-secret"
+)
+
+// 模拟的OpenID Connect身份提供方：发现文档、JWKS和令牌接口。
+// 授权页面由测试直接调用authorize代替，返回授权码
+type mockOIDC struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+
+	mu     sync.Mutex
+	grants map[string]*mockGrant
+}
+
+// 一次授权：授权时记录的PKCE challenge和nonce，以及登录的账号
+type mockGrant struct {
+	challenge   string
+	redirectURI string
+	nonce       string
+	subject     string
+	email       string
+	username    string
+}
+
+func newMockOIDC(t *testing.T) *mockOIDC {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &mockOIDC{key: key, grants: map[string]*mockGrant{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 m.URL,
+			"authorization_endpoint": m.URL + "/authorize",
+			"token_endpoint":         m.URL + "/token",
+			"jwks_uri":               m.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kid": "test-key",
+				"kty": "RSA",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", m.token)
+
+	m.Server = httptest.NewServer(mux)
+	t.Cleanup(m.Close)
+	return m
+}
+
+// 令牌接口：授权码只能使用一次，code_verifier必须与授权时的challenge匹配
+func (m *mockOIDC) token(w http.ResponseWriter, r *http.Request) {
+	fail := func(code string) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": code})
+	}
+	if r.PostFormValue("grant_type") != "authorization_code" {
+		fail("unsupported_grant_type")
+		return
+	}
+	if r.PostFormValue("client_id") != testClientID || r.PostFormValue("client_secret") != testClientSecret {
+		fail("invalid_client")
+		return
+	}
+
+	m.mu.Lock()
+	grant := m.grants[r.PostFormValue("code")]
+	delete(m.grants, r.PostFormValue("code"))
+	m.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	if grant == nil || grant.redirectURI != r.PostFormValue("redirect_uri") ||
+		base64.RawURLEncoding.EncodeToString(sum[:]) != grant.challenge {
+		fail("invalid_grant")
+		return
+	}
+
+	now := time.Now()
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":                m.URL,
+		"aud":                testClientID,
+		"sub":                grant.subject,
+		"iat":                now.Unix(),
+		"exp":                now.Add(time.Hour).Unix(),
+		"nonce":              grant.nonce,
+		"email":              grant.email,
+		"email_verified":     true,
+		"preferred_username": grant.username,
+	})
+	idToken.Header["kid"] = "test-key"
+	signed, err := idToken.SignedString(m.key)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]string{
+		"access_token": "access-" + grant.subject,
+		"token_type":   "Bearer",
+		"id_token":     signed,
+	})
+}
+
+// 模拟用户在授权页面登录并同意授权，返回授权码和state
+func (m *mockOIDC) authorize(t *testing.T, authURL, subject, username string) (code, state string) {
+	t.Helper()
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(authURL, m.URL+"/authorize?") {
+		t.Fatalf("没有跳转到授权页面: %s", authURL)
+	}
+	q := u.Query()
+	if q.Get("response_type") != "code" || q.Get("client_id") != testClientID {
+		t.Fatalf("授权参数不正确: %s", u.RawQuery)
+	}
+	if q.Get("code_challenge") == "" || q.Get("code_challenge_method") != "S256" {
+		t.Fatalf("授权请求没有使用PKCE: %s", u.RawQuery)
+	}
+	if q.Get("state") == "" || q.Get("nonce") == "" {
+		t.Fatalf("授权请求缺少state或nonce: %s", u.RawQuery)
+	}
+
+	code = "code-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	m.mu.Lock()
+	m.grants[code] = &mockGrant{
+		challenge:   q.Get("code_challenge"),
+		redirectURI: q.Get("redirect_uri"),
+		nonce:       q.Get("nonce"),
+		subject:     subject,
+		email:       username + "@idp.test",
+		username:    username,
+	}
+	m.mu.Unlock()
+	return code, q.Get("state")
+}
+
+// 修改已签发授权码对应的授权，模拟攻击者替换授权码或nonce
+func (m *mockOIDC) tamper(code string, fn func(*mockGrant)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	fn(m.grants[code])
+}
+
+// 保存Cookie的测试客户端
+type testBrowser struct {
+	t       *testing.T
+	router  *gin.Engine
+	cookies map[string]string
+}
+
+func (b *testBrowser) do(method, target, body string) *httptest.ResponseRecorder {
+	b.t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for name, value := range b.cookies {
+		req.AddCookie(&http.Cookie{Name: name, Value: value})
+	}
+	rec := httptest.NewRecorder()
+	b.router.ServeHTTP(rec, req)
+
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.MaxAge < 0 {
+			delete(b.cookies, cookie.Name)
+		} else {
+			b.cookies[cookie.Name] = cookie.Value
+		}
+	}
+	return rec
+}
+
+// 发起登录（userID大于0时为绑定），返回授权页面地址
+func (b *testBrowser) start(userID int) string {
+	b.t.Helper()
+	var rec *httptest.ResponseRecorder
+	if userID > 0 {
+		rec = b.do(http.MethodPost, "/api/user/identities/oidc?as="+strconv.Itoa(userID), "")
+		var resp struct {
+			AuthURL string `json:"auth_url"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		return resp.AuthURL
+	}
+	rec = b.do(http.MethodGet, "/auth/oauth/oidc", "")
+	if rec.Code != http.StatusFound {
+		b.t.Fatalf("发起登录返回%d: %s", rec.Code, rec.Body.String())
+	}
+	return rec.Header().Get("Location")
+}
+
+func (b *testBrowser) callback(code, state string) *httptest.ResponseRecorder {
+	return b.do(http.MethodGet, "/auth/oauth/oidc/callback?code="+url.QueryEscape(code)+"&state="+url.QueryEscape(state), "")
+}
+
+// 注册模拟身份提供方并创建只包含第三方登录路由的测试服务
+func newOAuthTest(t *testing.T) (*mockOIDC, *testBrowser) {
+	gin.SetMode(gin.TestMode)
+	config.AppConfig = &config.Config{JWTSecret: "test-secret", SiteURL: "http://forum.test"}
+
+	idp := newMockOIDC(t)
+	oauth.Register(oauth.NewOIDCProvider("oidc", "Test IdP", idp.URL,
+		testClientID, testClientSecret, oauth.RedirectURL("oidc")))
+
+	r := gin.New()
+	r.SetHTMLTemplate(template.Must(template.New("").Parse(
+		`{{define "error.html"}}{{.error}}{{end}}{{define "oauth_complete.html"}}{{.username}}{{end}}`)))
+	r.GET("/auth/oauth/:provider", OAuthLogin)
+	r.GET("/auth/oauth/:provider/callback", OAuthCallback)
+	r.POST("/auth/oauth/complete", OAuthComplete)
+
+	// 个人中心接口的登录用户由查询参数as指定
+	user := r.Group("/api/user", func(c *gin.Context) {
+		id, _ := strconv.Atoi(c.Query("as"))
+		c.Set("user_id", id)
+	})
+	user.POST("/identities/:provider", LinkAccount)
+	user.DELETE("/identities/:provider", UnlinkAccount)
+
+	return idp, &testBrowser{t: t, router: r, cookies: map[string]string{}}
+}
+
+// 用授权状态中保存的nonce和verifier兑换授权码，校验ID Token后得到身份信息
+func TestOIDCExchange(t *testing.T) {
+	idp, browser := newOAuthTest(t)
+	provider, err := oauth.Get("oidc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, _ := idp.authorize(t, browser.start(0), "sub-exchange", "exchange")
+	state, err := utils.ValidateOAuthState(browser.cookies[oauthStateCookie])
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity, err := provider.Exchange(context.Background(), code, state.Nonce, state.Verifier)
+	if err != nil {
+		t.Fatalf("兑换授权码失败: %v", err)
+	}
+	if identity.Subject != "sub-exchange" || identity.Username != "exchange" || !identity.EmailVerified {
+		t.Fatalf("身份信息不正确: %+v", identity)
+	}
+
+	// 授权码只能使用一次
+	if _, err := provider.Exchange(context.Background(), code, state.Nonce, state.Verifier); err == nil {
+		t.Fatal("同一个授权码兑换了两次")
+	}
+
+	// 错误的PKCE verifier
+	code, _ = idp.authorize(t, browser.start(0), "sub-exchange", "exchange")
+	state, err = utils.ValidateOAuthState(browser.cookies[oauthStateCookie])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := provider.Exchange(context.Background(), code, state.Nonce, state.Verifier+"x"); err == nil {
+		t.Fatal("错误的code_verifier兑换成功")
+	}
+}
+
+func TestOAuthCallbackRejectsWrongState(t *testing.T) {
+	idp, browser := newOAuthTest(t)
+
+	code, _ := idp.authorize(t, browser.start(0), "sub-state", "state")
+	rec := browser.callback(code, "forged-state")
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("state不匹配时返回%d，期望400", rec.Code)
+	}
+
+	// 回调后state Cookie即被清除，同一次授权不能重放
+	if _, ok := browser.cookies[oauthStateCookie]; ok {
+		t.Fatal("回调后没有清除state Cookie")
+	}
+}
+
+func TestOAuthCallbackRequiresStateCookie(t *testing.T) {
+	idp, browser := newOAuthTest(t)
+
+	code, state := idp.authorize(t, browser.start(0), "sub-cookie", "cookie")
+	delete(browser.cookies, oauthStateCookie)
+	if rec := browser.callback(code, state); rec.Code != http.StatusBadRequest {
+		t.Fatalf("缺少state Cookie时返回%d，期望400", rec.Code)
+	}
+}
+
+func TestOAuthCallbackRejectsWrongNonce(t *testing.T) {
+	idp, browser := newOAuthTest(t)
+
+	code, state := idp.authorize(t, browser.start(0), "sub-nonce", "nonce")
+	idp.tamper(code, func(g *mockGrant) { g.nonce = "replayed-nonce" })
+	if rec := browser.callback(code, state); rec.Code != http.StatusBadGateway {
+		t.Fatalf("ID Token的nonce不匹配时返回%d，期望502", rec.Code)
+	}
+}
+
+// 授权码是为另一次登录（另一个PKCE challenge）签发的，令牌接口拒绝兑换
+func TestOAuthCallbackRejectsInjectedCode(t *testing.T) {
+	idp, browser := newOAuthTest(t)
+
+	code, state := idp.authorize(t, browser.start(0), "sub-pkce", "pkce")
+	idp.tamper(code, func(g *mockGrant) { g.challenge = "challenge-of-another-login" })
+	if rec := browser.callback(code, state); rec.Code != http.StatusBadGateway {
+		t.Fatalf("PKCE校验失败时返回%d，期望502", rec.Code)
+	}
+}
+
+// 以下测试需要MySQL：设置AIFORUM_TEST_DSN（如root:@tcp(localhost:3306)/aiforum_test?parseTime=true），
+// 测试会在该库中执行init_db.sql建表
+var schemaOnce sync.Once
+
+func openTestDB(t *testing.T) {
+	t.Helper()
+	dsn := os.Getenv("AIFORUM_TEST_DSN")
+	if dsn == "" {
+		t.Skip("未设置AIFORUM_TEST_DSN，跳过需要MySQL的测试")
+	}
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var schemaErr error
+	schemaOnce.Do(func() {
+		script, err := os.ReadFile("../init_db.sql")
+		if err != nil {
+			schemaErr = err
+			return
+		}
+		for _, stmt := range strings.Split(string(script), ";") {
+			stmt = strings.TrimSpace(stmt)
+			lines := strings.Split(stmt, "\n")
+			for len(lines) > 0 && strings.HasPrefix(strings.TrimSpace(lines[0]), "--") {
+				lines = lines[1:]
+			}
+			stmt = strings.TrimSpace(strings.Join(lines, "\n"))
+			if stmt == "" || strings.HasPrefix(stmt, "CREATE DATABASE") || strings.HasPrefix(stmt, "USE ") {
+				continue
+			}
+			if _, err := db.Exec(stmt); err != nil {
+				schemaErr = err
+				return
+			}
+		}
+	})
+	if schemaErr != nil {
+		t.Fatalf("初始化测试数据库失败: %v", schemaErr)
+	}
+	models.DB = db
+}
+
+// 每次运行使用不同的用户名和第三方账号，避免与之前的数据冲突
+func uniqueName(prefix string) string {
+	return prefix + strconv.FormatInt(time.Now().UnixNano()%1e12, 36)
+}
+
+// 首次登录跳转到选择用户名页面，完成注册后再次登录直接进入首页
+func TestOAuthFirstLoginSignup(t *testing.T) {
+	idp, browser := newOAuthTest(t)
+	openTestDB(t)
+
+	subject, username := uniqueName("sub-"), uniqueName("u")
+	code, state := idp.authorize(t, browser.start(0), subject, username)
+	rec := browser.callback(code, state)
+	if rec.Code != http.StatusFound || rec.Header().Get("Location") != "/auth/oauth/complete" {
+		t.Fatalf("首次登录应跳转到注册页面，返回%d %s", rec.Code, rec.Header().Get("Location"))
+	}
+	if _, ok := browser.cookies["token"]; ok {
+		t.Fatal("选择用户名之前不应登录")
+	}
+
+	rec = browser.do(http.MethodPost, "/auth/oauth/complete", `{"username":"`+username+`"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("完成注册返回%d: %s", rec.Code, rec.Body.String())
+	}
+	if browser.cookies["token"] == "" {
+		t.Fatal("注册后没有登录")
+	}
+	user, err := models.GetUserByUsername(username)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Email != username+"@idp.test" {
+		t.Fatalf("没有使用第三方账号已验证的邮箱: %s", user.Email)
+	}
+
+	// 待注册令牌只能使用一次
+	if rec := browser.do(http.MethodPost, "/auth/oauth/complete", `{"username":"`+username+`x"}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("重复提交注册返回%d，期望400", rec.Code)
+	}
+
+	delete(browser.cookies, "token")
+	code, state = idp.authorize(t, browser.start(0), subject, username)
+	rec = browser.callback(code, state)
+	if rec.Code != http.StatusFound || rec.Header().Get("Location") != "/" || browser.cookies["token"] == "" {
+		t.Fatalf("已绑定的账号应直接登录，返回%d %s", rec.Code, rec.Header().Get("Location"))
+	}
+}
+
+// 已登录用户绑定第三方账号，绑定后可以用它登录；已被其他用户绑定的账号不能再绑定
+func TestOAuthLinkIdentity(t *testing.T) {
+	idp, browser := newOAuthTest(t)
+	openTestDB(t)
+
+	username := uniqueName("u")
+	if err := models.CreateUser(username, username+"@forum.test", "password123"); err != nil {
+		t.Fatal(err)
+	}
+	user, err := models.GetUserByUsername(username)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	subject := uniqueName("sub-")
+	code, state := idp.authorize(t, browser.start(user.ID), subject, username)
+	rec := browser.callback(code, state)
+	if rec.Code != http.StatusFound || rec.Header().Get("Location") != "/profile" {
+		t.Fatalf("绑定后应返回个人中心，返回%d %s", rec.Code, rec.Header().Get("Location"))
+	}
+
+	identities, err := models.GetUserIdentities(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(identities) != 1 || identities[0].Provider != "oidc" || identities[0].Subject != subject {
+		t.Fatalf("绑定结果不正确: %+v", identities)
+	}
+
+	code, state = idp.authorize(t, browser.start(0), subject, username)
+	if rec := browser.callback(code, state); rec.Header().Get("Location") != "/" {
+		t.Fatalf("绑定的账号应能直接登录，返回%d %s", rec.Code, rec.Header().Get("Location"))
+	}
+
+	other := uniqueName("u")
+	if err := models.CreateUser(other, other+"@forum.test", "password123"); err != nil {
+		t.Fatal(err)
+	}
+	otherUser, err := models.GetUserByUsername(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, state = idp.authorize(t, browser.start(otherUser.ID), subject, username)
+	if rec := browser.callback(code, state); rec.Code != http.StatusConflict {
+		t.Fatalf("绑定已被其他用户绑定的账号返回%d，期望409", rec.Code)
+	}
+}
+
+// 没有密码的用户不能解除唯一的第三方账号，设置了密码的用户可以解除
+func TestOAuthUnlinkLastLoginMethod(t *testing.T) {
+	_, browser := newOAuthTest(t)
+	openTestDB(t)
+
+	username := uniqueName("u")
+	userID, err := models.CreateUserWithIdentity(username, username+"@idp.test", "", "oidc", uniqueName("sub-"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := "/api/user/identities/oidc?as=" + strconv.Itoa(userID)
+	if rec := browser.do(http.MethodDelete, target, ""); rec.Code != http.StatusBadRequest {
+		t.Fatalf("解除唯一的登录方式返回%d，期望400", rec.Code)
+	}
+
+	other := uniqueName("u")
+	if err := models.CreateUser(other, other+"@forum.test", "password123"); err != nil {
+		t.Fatal(err)
+	}
+	user, err := models.GetUserByUsername(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := models.LinkIdentity(user.ID, "oidc", uniqueName("sub-"), ""); err != nil {
+		t.Fatal(err)
+	}
+	target = "/api/user/identities/oidc?as=" + strconv.Itoa(user.ID)
+	if rec := browser.do(http.MethodDelete, target, ""); rec.Code != http.StatusOK {
+		t.Fatalf("有密码的用户解除绑定返回%d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := browser.do(http.MethodDelete, target, ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("解除未绑定的登录方式返回%d，期望404", rec.Code)
+	}
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"strconv"
+
+	"aiforum/models"
+	"aiforum/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 从查询参数读取分页游标（cursor）和每页数量（limit）
+func pageQuery(c *gin.Context, defaultLimit int) (models.PageQuery, error) {
+	cursor, err := utils.DecodeCursor(c.Query("cursor"))
+	if err != nil {
+		return models.PageQuery{}, err
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultLimit)))
+	if err != nil {
+		limit = defaultLimit
+	}
+	return models.PageQuery{Cursor: cursor, Limit: limit}, nil
+}
+
+// 是否需要返回总数（统计总数需要扫描全部符合条件的内容，默认不返回）
+func includeTotal(c *gin.Context) bool {
+	value := c.Query("include_total")
+	return value == "1" || value == "true"
+}
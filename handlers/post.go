@@ -11,7 +11,7 @@ import (
 // 首页
 func HomePage(c *gin.Context) {
 	// 获取最新帖子
-	posts, err := models.GetPosts(1, 10, 0)
+	posts, _, err := models.GetPosts(0, models.PageQuery{Limit: 10})
 	if err != nil {
 		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
 			"error": "获取帖子失败",
@@ -37,11 +37,24 @@ func HomePage(c *gin.Context) {
 		return
 	}
 
+	// 登录用户显示关注动态
+	var user *models.User
+	var feed []*models.FeedItem
+	var feedCursor string
+	if userID := c.GetInt("user_id"); userID > 0 {
+		user, _ = models.GetUserByID(userID)
+		feed, _ = models.GetUserFeed(userID, nil, 10, false)
+		feedCursor = nextFeedCursor(feed, 10)
+	}
+
 	c.HTML(http.StatusOK, "index.html", gin.H{
 		"title":      "AI论坛 - 知识分享与交流平台",
 		"posts":      posts,
 		"hotPosts":   hotPosts,
 		"categories": categories,
+		"user":       user,
+		"feed":       feed,
+		"feedCursor": feedCursor,
 	})
 }
 
@@ -148,6 +161,10 @@ func CreateReply(c *gin.Context) {
 
 	// 创建回复
 	replyID, err := models.CreateReply(postID, userID, req.Content)
+	if err == models.ErrBlocked {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "回复失败"})
 		return
@@ -161,21 +178,33 @@ func CreateReply(c *gin.Context) {
 
 // 获取帖子列表（API）
 func GetPosts(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	page, err := pageQuery(c, 10)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的分页参数"})
+		return
+	}
 	categoryID, _ := strconv.Atoi(c.DefaultQuery("category_id", "0"))
 
-	posts, err := models.GetPosts(page, limit, categoryID)
+	posts, pageInfo, err := models.GetPosts(categoryID, page)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取帖子失败"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"posts": posts,
-		"page":  page,
-		"limit": limit,
-	})
+	result := gin.H{
+		"posts":       posts,
+		"next_cursor": pageInfo.NextCursor,
+		"prev_cursor": pageInfo.PrevCursor,
+	}
+	if includeTotal(c) {
+		total, err := models.GetPostCount(categoryID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "获取帖子失败"})
+			return
+		}
+		result["total"] = total
+	}
+	c.JSON(http.StatusOK, result)
 }
 
 // 获取单个帖子（API）
@@ -205,19 +234,25 @@ func Search(c *gin.Context) {
 		return
 	}
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	page, err := pageQuery(c, 10)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的分页参数"})
+		return
+	}
+
+	// 记录搜索词
+	go models.RecordSearchQuery(c.GetInt("user_id"), keyword, "post")
 
-	posts, err := models.SearchPosts(keyword, page, limit)
+	posts, pageInfo, err := models.SearchPosts(keyword, c.GetInt("user_id"), page)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "搜索失败"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"posts":   posts,
-		"keyword": keyword,
-		"page":    page,
-		"limit":   limit,
+		"posts":       posts,
+		"keyword":     keyword,
+		"next_cursor": pageInfo.NextCursor,
+		"prev_cursor": pageInfo.PrevCursor,
 	})
 } 
\ No newline at end of file
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"database/sql"
 	"net/http"
 	"strconv"
 	"time"
@@ -13,8 +14,16 @@ import (
 func GetUserActivity(c *gin.Context) {
 	userID := c.GetInt("user_id")
 	filter := c.Query("filter")
+	page, err := pageQuery(c, models.DefaultPageSize)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的分页参数",
+		})
+		return
+	}
 
-	activities, err := models.GetUserActivity(userID, filter)
+	activities, pageInfo, err := models.GetUserActivity(userID, filter, page)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -24,16 +33,26 @@ func GetUserActivity(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"success":    true,
-		"activities": activities,
+		"success":     true,
+		"activities":  activities,
+		"next_cursor": pageInfo.NextCursor,
+		"prev_cursor": pageInfo.PrevCursor,
 	})
 }
 
 // 获取用户提问
 func GetUserQuestions(c *gin.Context) {
 	userID := c.GetInt("user_id")
+	page, err := pageQuery(c, models.DefaultPageSize)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的分页参数",
+		})
+		return
+	}
 
-	questions, err := models.GetUserQuestions(userID)
+	questions, pageInfo, err := models.GetUserQuestions(userID, page)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -43,16 +62,26 @@ func GetUserQuestions(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"success":  true,
-		"questions": questions,
+		"success":     true,
+		"questions":   questions,
+		"next_cursor": pageInfo.NextCursor,
+		"prev_cursor": pageInfo.PrevCursor,
 	})
 }
 
 // 获取用户回答
 func GetUserAnswers(c *gin.Context) {
 	userID := c.GetInt("user_id")
+	page, err := pageQuery(c, models.DefaultPageSize)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的分页参数",
+		})
+		return
+	}
 
-	answers, err := models.GetUserAnswers(userID)
+	answers, pageInfo, err := models.GetUserAnswers(userID, page)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -62,16 +91,26 @@ func GetUserAnswers(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"answers": answers,
+		"success":     true,
+		"answers":     answers,
+		"next_cursor": pageInfo.NextCursor,
+		"prev_cursor": pageInfo.PrevCursor,
 	})
 }
 
 // 获取用户分享
 func GetUserShares(c *gin.Context) {
 	userID := c.GetInt("user_id")
+	page, err := pageQuery(c, models.DefaultPageSize)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的分页参数",
+		})
+		return
+	}
 
-	shares, err := models.GetUserShares(userID)
+	shares, pageInfo, err := models.GetUserShares(userID, page)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -81,16 +120,26 @@ func GetUserShares(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"shares":  shares,
+		"success":     true,
+		"shares":      shares,
+		"next_cursor": pageInfo.NextCursor,
+		"prev_cursor": pageInfo.PrevCursor,
 	})
 }
 
 // 获取用户资料
 func GetUserResources(c *gin.Context) {
 	userID := c.GetInt("user_id")
+	page, err := pageQuery(c, models.DefaultPageSize)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的分页参数",
+		})
+		return
+	}
 
-	resources, err := models.GetUserResources(userID)
+	resources, pageInfo, err := models.GetUserResources(userID, page)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -100,8 +149,10 @@ func GetUserResources(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"success":   true,
-		"resources": resources,
+		"success":     true,
+		"resources":   resources,
+		"next_cursor": pageInfo.NextCursor,
+		"prev_cursor": pageInfo.PrevCursor,
 	})
 }
 
@@ -109,7 +160,8 @@ func GetUserResources(c *gin.Context) {
 func GetUserFavorites(c *gin.Context) {
 	userID := c.GetInt("user_id")
 
-	favorites, err := models.GetUserFavorites(userID)
+	collectionID, _ := strconv.Atoi(c.Query("collection"))
+	favorites, err := models.GetUserBookmarks(userID, collectionID, c.Query("type"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -127,8 +179,16 @@ func GetUserFavorites(c *gin.Context) {
 // 获取用户关注
 func GetUserFollowing(c *gin.Context) {
 	userID := c.GetInt("user_id")
+	page, err := pageQuery(c, models.DefaultPageSize)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的分页参数",
+		})
+		return
+	}
 
-	following, err := models.GetUserFollowing(userID)
+	following, pageInfo, err := models.GetUserFollowing(userID, page)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -138,16 +198,26 @@ func GetUserFollowing(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"success":   true,
-		"following": following,
+		"success":     true,
+		"following":   following,
+		"next_cursor": pageInfo.NextCursor,
+		"prev_cursor": pageInfo.PrevCursor,
 	})
 }
 
 // 获取用户粉丝
 func GetUserFollowers(c *gin.Context) {
 	userID := c.GetInt("user_id")
+	page, err := pageQuery(c, models.DefaultPageSize)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的分页参数",
+		})
+		return
+	}
 
-	followers, err := models.GetUserFollowers(userID)
+	followers, pageInfo, err := models.GetUserFollowers(userID, page)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -157,16 +227,49 @@ func GetUserFollowers(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"success":   true,
-		"followers": followers,
+		"success":     true,
+		"followers":   followers,
+		"next_cursor": pageInfo.NextCursor,
+		"prev_cursor": pageInfo.PrevCursor,
+	})
+}
+
+// 获取推荐关注的用户
+func GetFollowSuggestions(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if limit <= 0 || limit > 50 {
+		limit = 10
+	}
+
+	suggestions, err := models.SuggestFollows(userID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "获取推荐关注失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"suggestions": suggestions,
 	})
 }
 
 // 获取用户消息
 func GetUserMessages(c *gin.Context) {
 	userID := c.GetInt("user_id")
+	page, err := pageQuery(c, models.DefaultPageSize)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的分页参数",
+		})
+		return
+	}
 
-	messages, err := models.GetUserMessages(userID)
+	messages, pageInfo, err := models.GetUserMessages(userID, page)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -176,8 +279,10 @@ func GetUserMessages(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"messages": messages,
+		"success":     true,
+		"messages":    messages,
+		"next_cursor": pageInfo.NextCursor,
+		"prev_cursor": pageInfo.PrevCursor,
 	})
 }
 
@@ -362,7 +467,9 @@ func SaveNotificationSettings(c *gin.Context) {
 		EmailNotifications    bool `json:"email_notifications"`
 		BrowserNotifications  bool `json:"browser_notifications"`
 		QuestionNotifications bool `json:"question_notifications"`
-		FollowNotifications   bool `json:"follow_notifications"`
+		FollowNotifications   bool   `json:"follow_notifications"`
+		EmailDigest           string `json:"email_digest"`
+		MentionNotifications  *bool  `json:"mention_notifications"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -373,7 +480,21 @@ func SaveNotificationSettings(c *gin.Context) {
 		return
 	}
 
+	if req.EmailDigest != "" && !models.ValidEmailDigest(req.EmailDigest) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   models.ErrInvalidEmailDigest.Error(),
+		})
+		return
+	}
+
 	err := models.UpdateNotificationSettings(userID, req.EmailNotifications, req.BrowserNotifications, req.QuestionNotifications, req.FollowNotifications)
+	if err == nil && req.EmailDigest != "" {
+		err = models.UpdateEmailDigest(userID, req.EmailDigest)
+	}
+	if err == nil && req.MentionNotifications != nil {
+		err = models.UpdateMentionNotifications(userID, *req.MentionNotifications)
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -481,7 +602,14 @@ func FollowUser(c *gin.Context) {
 		return
 	}
 
-	err = models.FollowUser(userID, targetUserID)
+	_, err = models.Follow(userID, targetUserID)
+	if err == models.ErrBlocked {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -508,7 +636,7 @@ func UnfollowUser(c *gin.Context) {
 		return
 	}
 
-	err = models.UnfollowUser(userID, targetUserID)
+	_, err = models.Unfollow(userID, targetUserID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -535,7 +663,7 @@ func RemoveFavorite(c *gin.Context) {
 		return
 	}
 
-	err = models.RemoveFavorite(userID, favoriteID)
+	err = models.RemoveBookmark(userID, favoriteID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -563,6 +691,13 @@ func DeleteUserQuestion(c *gin.Context) {
 	}
 
 	err = models.DeleteUserQuestion(userID, questionID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "问题不存在",
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -573,7 +708,7 @@ func DeleteUserQuestion(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "问题删除成功",
+		"message": "问题已移入回收站，30天内可以恢复",
 	})
 }
 
@@ -590,6 +725,13 @@ func DeleteUserAnswer(c *gin.Context) {
 	}
 
 	err = models.DeleteUserAnswer(userID, answerID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "回答不存在",
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -600,7 +742,7 @@ func DeleteUserAnswer(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "回答删除成功",
+		"message": "回答已移入回收站，30天内可以恢复",
 	})
 }
 
@@ -617,6 +759,13 @@ func DeleteUserShare(c *gin.Context) {
 	}
 
 	err = models.DeleteUserShare(userID, shareID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "分享不存在",
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -627,7 +776,7 @@ func DeleteUserShare(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "分享删除成功",
+		"message": "分享已移入回收站，30天内可以恢复",
 	})
 }
 
@@ -644,6 +793,13 @@ func DeleteUserResource(c *gin.Context) {
 	}
 
 	err = models.DeleteUserResource(userID, resourceID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "资料不存在",
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -654,7 +810,7 @@ func DeleteUserResource(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "资料删除成功",
+		"message": "资料已移入回收站，30天内可以恢复",
 	})
 }
 
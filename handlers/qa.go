@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"database/sql"
 	"net/http"
 	"strconv"
 
@@ -20,7 +21,13 @@ func calculateUserLevel(userID int) int {
 // 问答页面
 func QAPage(c *gin.Context) {
 	// 获取查询参数
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	page, err := pageQuery(c, 10)
+	if err != nil {
+		c.HTML(http.StatusBadRequest, "error.html", gin.H{
+			"error": "无效的分页参数",
+		})
+		return
+	}
 	categoryID, _ := strconv.Atoi(c.DefaultQuery("category", "0"))
 	keyword := c.Query("q")
 	tag := c.Query("tag")
@@ -46,17 +53,19 @@ func QAPage(c *gin.Context) {
 
 	// 获取问答列表
 	var questions []*models.Question
+	var pageInfo models.PageInfo
 	var totalCount, solvedCount, unsolvedCount, todayCount int
 
 	if keyword != "" {
 		// 搜索问答
-		questions, err = models.SearchQuestions(keyword, page, 10)
+		questions, pageInfo, err = models.SearchQuestions(keyword, c.GetInt("user_id"), page)
+		go models.RecordSearchQuery(c.GetInt("user_id"), keyword, "qa")
 	} else if tag != "" {
 		// 按标签筛选
-		questions, err = models.GetQuestionsByTag(tag, page, 10)
+		questions, pageInfo, err = models.GetQuestionsByTag(tag, page)
 	} else {
 		// 获取问答列表
-		questions, err = models.GetQuestions(page, 10, categoryID, sort)
+		questions, pageInfo, err = models.GetQuestions(categoryID, sort, page)
 	}
 
 	if err != nil {
@@ -87,9 +96,6 @@ func QAPage(c *gin.Context) {
 		}
 	}
 
-	// 计算总页数
-	totalPages := (totalCount + 9) / 10
-
 	c.HTML(http.StatusOK, "qa.html", gin.H{
 		"title":             "知识问答",
 		"questions":         questions,
@@ -100,8 +106,8 @@ func QAPage(c *gin.Context) {
 		"keyword":           keyword,
 		"tag":               tag,
 		"sort":              sort,
-		"page":              page,
-		"totalPages":        totalPages,
+		"nextCursor":        pageInfo.NextCursor,
+		"prevCursor":        pageInfo.PrevCursor,
 		"totalCount":        totalCount,
 		"solvedCount":       solvedCount,
 		"unsolvedCount":     unsolvedCount,
@@ -120,7 +126,11 @@ func AdvancedSearch(c *gin.Context) {
 	status := c.Query("status")
 	timeRange := c.Query("time")
 	rewardRange := c.Query("reward")
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	page, err := pageQuery(c, 10)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的分页参数"})
+		return
+	}
 
 	// 构建搜索条件
 	conditions := make(map[string]interface{})
@@ -144,15 +154,16 @@ func AdvancedSearch(c *gin.Context) {
 	}
 
 	// 执行高级搜索
-	questions, err := models.AdvancedSearchQuestions(conditions, page, 10)
+	questions, pageInfo, err := models.AdvancedSearchQuestions(conditions, c.GetInt("user_id"), page)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "搜索失败"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"questions": questions,
-		"page":      page,
+		"questions":   questions,
+		"next_cursor": pageInfo.NextCursor,
+		"prev_cursor": pageInfo.PrevCursor,
 	})
 }
 
@@ -191,6 +202,8 @@ func AskQuestion(c *gin.Context) {
 		CategoryID int    `form:"category_id" binding:"required"`
 		Tags       string `form:"tags"`
 		Reward     int    `form:"reward"`
+		// 用户确认与已有问题不重复后再次提交
+		IgnoreSimilar bool `form:"ignore_similar"`
 	}
 
 	if err := c.ShouldBind(&req); err != nil {
@@ -198,6 +211,19 @@ func AskQuestion(c *gin.Context) {
 		return
 	}
 
+	// 提交前检查是否存在高度相似的问题
+	if !req.IgnoreSimilar {
+		similar, err := models.FindSimilarQuestions(req.Title, req.Content, 0, 5, models.DuplicateWarningScore)
+		if err == nil && len(similar) > 0 {
+			c.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"error":   "发现相似的问题，请确认是否仍要提问",
+				"similar": similar,
+			})
+			return
+		}
+	}
+
 	// 检查用户积分是否足够
 	user, err := models.GetUserByID(userID)
 	if err != nil {
@@ -252,11 +278,23 @@ func ViewQuestion(c *gin.Context) {
 		return
 	}
 
+	// 重复问题跳转到原问题（redirect=no时停留在当前问题）
+	if question.DuplicateOf > 0 && c.Query("redirect") != "no" {
+		c.Redirect(http.StatusFound, "/qa/"+strconv.Itoa(question.DuplicateOf)+"?from="+strconv.Itoa(questionID))
+		return
+	}
+
+	// 从重复问题跳转而来
+	var fromQuestion *models.Question
+	if fromID, err := strconv.Atoi(c.Query("from")); err == nil {
+		fromQuestion, _ = models.GetQuestionByID(fromID)
+	}
+
 	// 增加浏览量
 	models.IncrementQuestionViewCount(questionID)
 
-	// 获取回答
-	answers, err := models.GetAnswersByQuestionID(questionID)
+	// 获取回答（包含重复问题下的回答）
+	answers, err := models.GetMergedAnswersByQuestionID(questionID)
 	if err != nil {
 		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
 			"error": "获取回答失败",
@@ -267,6 +305,9 @@ func ViewQuestion(c *gin.Context) {
 	// 获取相关推荐
 	relatedQuestions, _ := models.GetRelatedQuestions(questionID, 5)
 
+	// 获取重复问题
+	duplicateQuestions, _ := models.GetDuplicateQuestions(questionID)
+
 	// 获取当前用户信息（如果已登录）
 	var user *models.User
 	if userID, exists := c.Get("user_id"); exists {
@@ -276,11 +317,21 @@ func ViewQuestion(c *gin.Context) {
 	// 计算用户等级
 	question.UserLevel = calculateUserLevel(question.UserID)
 
+	// 一次查出问题和回答中提及的用户
+	contents := []string{question.Content}
+	for _, answer := range answers {
+		contents = append(contents, answer.Content)
+	}
+
 	c.HTML(http.StatusOK, "question_detail.html", gin.H{
 		"title":             question.Title,
 		"question":          question,
 		"answers":           answers,
 		"relatedQuestions":  relatedQuestions,
+		"duplicateQuestions": duplicateQuestions,
+		"fromQuestion":      fromQuestion,
+		"closeReason":       models.CloseReasonNames[question.CloseReason],
+		"mentionedUsers":    models.LoadMentionedUsers(contents...),
 		"user":              user,
 	})
 }
@@ -303,8 +354,38 @@ func AnswerQuestion(c *gin.Context) {
 		return
 	}
 
-	// 创建回答
-	answerID, err := models.CreateAnswer(questionID, userID, req.Content)
+	createAnswer(c, questionID, userID, req.Content)
+}
+
+// 回答问题（旧接口，问题ID放在请求体中）。
+// 已弃用，保留给旧客户端，新代码请使用 POST /api/questions/:id/answers 或 /api/v1/questions/:id/answers
+func AnswerQuestionLegacy(c *gin.Context) {
+	var req struct {
+		QuestionID int    `json:"question_id" binding:"required"`
+		Content    string `json:"content" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请填写问题ID和回答内容"})
+		return
+	}
+
+	c.Header("Deprecation", "true")
+	c.Header("Link", "</api/v1/questions/"+strconv.Itoa(req.QuestionID)+"/answers>; rel=\"successor-version\"")
+	createAnswer(c, req.QuestionID, c.GetInt("user_id"), req.Content)
+}
+
+// 创建回答并返回结果
+func createAnswer(c *gin.Context, questionID, userID int, content string) {
+	answerID, err := models.CreateAnswer(questionID, userID, content)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "问题不存在"})
+		return
+	}
+	if err == models.ErrQuestionClosed || err == models.ErrQuestionLocked || err == models.ErrQuestionProtected || err == models.ErrBlocked {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "回答失败"})
 		return
@@ -377,7 +458,11 @@ func FavoriteQuestion(c *gin.Context) {
 		return
 	}
 
-	isFavorited, err := models.ToggleQuestionFavorite(questionID, userID)
+	isFavorited, err := models.ToggleBookmark(userID, models.ContentTypeQuestion, questionID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "问题不存在"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "操作失败"})
 		return
@@ -423,4 +508,74 @@ func ReportQuestion(c *gin.Context) {
 		"success": true,
 		"message": "举报成功",
 	})
-} 
\ No newline at end of file
+} 
+
+// 查找相似问题
+func GetSimilarQuestions(c *gin.Context) {
+	title := c.Query("title")
+	if title == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请输入问题标题"})
+		return
+	}
+	content := c.Query("content")
+	excludeID, _ := strconv.Atoi(c.DefaultQuery("exclude", "0"))
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "5"))
+	if limit < 1 || limit > 20 {
+		limit = 5
+	}
+
+	similar, err := models.FindSimilarQuestions(title, content, excludeID, limit, models.SimilarListMinScore)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查找相似问题失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"similar": similar,
+	})
+}
+
+// 将问题作为重复问题关闭（版主）
+func MarkQuestionDuplicate(c *gin.Context) {
+	var req struct {
+		DuplicateOf int `json:"duplicate_of" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请指定原问题"})
+		return
+	}
+
+	closeQuestionAsDuplicate(c, req.DuplicateOf)
+}
+
+// 将问题作为重复问题关闭
+func closeQuestionAsDuplicate(c *gin.Context, duplicateOf int) {
+	moderatorID := c.GetInt("user_id")
+	questionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的问题ID"})
+		return
+	}
+
+	err = models.CloseQuestionAsDuplicate(questionID, duplicateOf, moderatorID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "问题不存在"})
+		return
+	}
+	if err == models.ErrInvalidDuplicate || err == models.ErrInvalidTransition {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "操作失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "问题已作为重复问题关闭",
+	})
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"aiforum/models"
+	"github.com/gin-gonic/gin"
+)
+
+// 关闭问题
+func CloseQuestion(c *gin.Context) {
+	var req struct {
+		Reason      string `json:"reason" binding:"required"`
+		DuplicateOf int    `json:"duplicate_of"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请选择关闭原因"})
+		return
+	}
+
+	// 重复问题需要指定原问题
+	if req.Reason == models.CloseReasonDuplicate {
+		if req.DuplicateOf <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "请指定原问题"})
+			return
+		}
+		closeQuestionAsDuplicate(c, req.DuplicateOf)
+		return
+	}
+
+	changeQuestionState(c, models.QuestionActionClose, req.Reason, "问题已关闭")
+}
+
+// 重新开放问题
+func ReopenQuestion(c *gin.Context) {
+	changeQuestionState(c, models.QuestionActionReopen, "", "问题已重新开放")
+}
+
+// 锁定问题
+func LockQuestion(c *gin.Context) {
+	changeQuestionState(c, models.QuestionActionLock, "", "问题已锁定")
+}
+
+// 解除锁定
+func UnlockQuestion(c *gin.Context) {
+	changeQuestionState(c, models.QuestionActionUnlock, "", "问题已解除锁定")
+}
+
+// 保护问题
+func ProtectQuestion(c *gin.Context) {
+	changeQuestionState(c, models.QuestionActionProtect, "", "问题已设为受保护")
+}
+
+// 取消保护
+func UnprotectQuestion(c *gin.Context) {
+	changeQuestionState(c, models.QuestionActionUnprotect, "", "问题已取消保护")
+}
+
+// 转为社区维基（提问者或版主）
+func ConvertQuestionToWiki(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	questionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的问题ID"})
+		return
+	}
+
+	question, err := models.GetQuestionByID(questionID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "问题不存在"})
+		return
+	}
+
+	if question.UserID != userID {
+		user, err := models.GetUserByID(userID)
+		if err != nil || !user.IsModerator() {
+			c.JSON(http.StatusForbidden, gin.H{"error": "没有操作权限"})
+			return
+		}
+	}
+
+	changeQuestionState(c, models.QuestionActionWiki, "", "问题已转为社区维基")
+}
+
+// 投票重新开放问题
+func VoteReopenQuestion(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	questionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的问题ID"})
+		return
+	}
+
+	user, err := models.GetUserByID(userID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "用户不存在"})
+		return
+	}
+
+	votes, reopened, err := models.VoteReopenQuestion(questionID, user)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "问题不存在"})
+		return
+	}
+	if err == models.ErrReopenVoteLevel {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if err == models.ErrInvalidTransition || err == models.ErrAlreadyVoted {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "投票失败"})
+		return
+	}
+
+	message := "投票成功"
+	if reopened {
+		message = "问题已重新开放"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":        true,
+		"message":        message,
+		"votes":          votes,
+		"votes_required": models.ReopenVotesRequired,
+		"reopened":       reopened,
+	})
+}
+
+// 执行问题状态变更
+func changeQuestionState(c *gin.Context, action, reason, message string) {
+	userID := c.GetInt("user_id")
+	questionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的问题ID"})
+		return
+	}
+
+	state, err := models.ChangeQuestionState(questionID, userID, action, reason)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "问题不存在"})
+		return
+	}
+	if err == models.ErrInvalidTransition || err == models.ErrInvalidCloseReason {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "操作失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": message,
+		"state":   state,
+	})
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"aiforum/models"
+)
+
+// 搜索建议（自动补全）
+func SearchSuggest(c *gin.Context) {
+	prefix := c.Query("q")
+	if prefix == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "请输入搜索关键词",
+		})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if limit < 1 || limit > 20 {
+		limit = 10
+	}
+
+	suggestions, err := models.GetSearchSuggestions(prefix, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "获取搜索建议失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"suggestions": suggestions,
+	})
+}
+
+// 获取热门搜索
+func GetTrendingSearches(c *gin.Context) {
+	window := c.DefaultQuery("window", "day")
+	if !models.IsValidTrendingWindow(window) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的时间窗口",
+		})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if limit < 1 || limit > 50 {
+		limit = 10
+	}
+
+	trending, err := models.GetTrendingQueries(window, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "获取热门搜索失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"window":   window,
+		"trending": trending,
+	})
+}
+
+// 获取用户搜索历史
+func GetSearchHistory(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	history, err := models.GetUserSearchHistory(userID, 20)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "获取搜索历史失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"history": history,
+	})
+}
+
+// 删除单条搜索历史
+func DeleteSearchHistoryItem(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	historyID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的搜索记录ID",
+		})
+		return
+	}
+
+	err = models.DeleteSearchHistoryItem(userID, historyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "删除搜索记录失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "搜索记录已删除",
+	})
+}
+
+// 清空搜索历史
+func ClearSearchHistory(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	err := models.ClearUserSearchHistory(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "清空搜索历史失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "搜索历史已清空",
+	})
+}
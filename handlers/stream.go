@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"aiforum/hub"
+	"aiforum/models"
+	"github.com/gin-gonic/gin"
+)
+
+// 单个连接最多订阅的问题数
+const maxStreamQuestions = 10
+
+// 心跳间隔（避免代理断开空闲连接）
+const streamHeartbeat = 25 * time.Second
+
+// 实时推送通知、未读数和问题回答数（Server-Sent Events）
+func UserStream(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	topics := []string{hub.UserTopic(userID)}
+	for _, raw := range c.QueryArray("question") {
+		for _, part := range strings.Split(raw, ",") {
+			if len(topics) > maxStreamQuestions {
+				break
+			}
+			if id, err := strconv.Atoi(strings.TrimSpace(part)); err == nil && id > 0 {
+				topics = append(topics, hub.QuestionTopic(id))
+			}
+		}
+	}
+
+	count, err := models.GetUnreadMessageCount(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "获取未读消息失败",
+		})
+		return
+	}
+
+	sub := hub.Subscribe(topics...)
+	defer sub.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	c.SSEvent("unread", gin.H{"count": count})
+	c.Writer.Flush()
+
+	ticker := time.NewTicker(streamHeartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			c.SSEvent(event.Type, event.Data)
+			c.Writer.Flush()
+		case <-ticker.C:
+			if _, err := c.Writer.WriteString(": ping\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
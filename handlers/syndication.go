@@ -0,0 +1,326 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"aiforum/config"
+	"aiforum/models"
+	"aiforum/syndication"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 每个订阅源包含的条目数
+var feedPage = models.PageQuery{Limit: 30}
+
+// 最新问题
+func QuestionsFeed(c *gin.Context) {
+	format, ok := feedFormat(c)
+	if !ok {
+		return
+	}
+
+	questions, _, err := models.GetQuestions(0, "latest", feedPage)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取问题失败"})
+		return
+	}
+
+	serveFeed(c, format, &syndication.Feed{
+		Title:       "AI论坛 - 最新问题",
+		Description: "AI论坛知识问答中的最新问题",
+		Link:        absoluteURL("/qa"),
+		Items:       questionFeedItems(questions),
+	})
+}
+
+// 待解决的问题
+func UnsolvedQuestionsFeed(c *gin.Context) {
+	format, ok := feedFormat(c)
+	if !ok {
+		return
+	}
+
+	// unsolved排序把未解决的问题排在前面，不足一页时会带出已解决的问题
+	questions, _, err := models.GetQuestions(0, "unsolved", feedPage)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取问题失败"})
+		return
+	}
+	unsolved := questions[:0]
+	for _, question := range questions {
+		if !question.IsSolved {
+			unsolved = append(unsolved, question)
+		}
+	}
+
+	serveFeed(c, format, &syndication.Feed{
+		Title:       "AI论坛 - 待解决问题",
+		Description: "AI论坛知识问答中还没有采纳回答的问题",
+		Link:        absoluteURL("/qa?sort=unsolved"),
+		Items:       questionFeedItems(unsolved),
+	})
+}
+
+// 技术文章
+func ArticlesFeed(c *gin.Context) {
+	format, ok := feedFormat(c)
+	if !ok {
+		return
+	}
+
+	articles, _, err := models.GetTechArticles("", "", "latest", "", 0, feedPage)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取文章失败"})
+		return
+	}
+
+	serveFeed(c, format, &syndication.Feed{
+		Title:       "AI论坛 - 技术分享",
+		Description: "AI论坛最新发布的技术文章",
+		Link:        absoluteURL("/tech-share"),
+		Items:       articleFeedItems(articles),
+	})
+}
+
+// 分类下的技术文章
+func ArticleCategoryFeed(c *gin.Context) {
+	format, ok := feedFormat(c)
+	if !ok {
+		return
+	}
+
+	category := c.Param("category")
+	name, exists := models.TechCategoryName(category)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "分类不存在"})
+		return
+	}
+
+	articles, _, err := models.GetTechArticles(category, "", "latest", "", 0, feedPage)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取文章失败"})
+		return
+	}
+
+	serveFeed(c, format, &syndication.Feed{
+		Title:       "AI论坛 - 技术分享 - " + name,
+		Description: "AI论坛「" + name + "」分类下的技术文章",
+		Link:        absoluteURL("/tech-share?category=" + url.QueryEscape(category)),
+		Items:       articleFeedItems(articles),
+	})
+}
+
+// 专题下的技术文章
+func TopicFeed(c *gin.Context) {
+	format, ok := feedFormat(c)
+	if !ok {
+		return
+	}
+
+	topic, err := models.GetTopicBySlug(c.Param("slug"))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "专题不存在"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取专题失败"})
+		return
+	}
+
+	articles, _, err := models.GetTechArticles("", "", "latest", topic.Slug, 0, feedPage)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取文章失败"})
+		return
+	}
+
+	serveFeed(c, format, &syndication.Feed{
+		Title:       "AI论坛 - 专题 - " + topic.Name,
+		Description: topic.Description,
+		Link:        absoluteURL("/tech-share/topic/" + url.PathEscape(topic.Slug)),
+		Items:       articleFeedItems(articles),
+	})
+}
+
+// 作者的技术文章
+func AuthorArticlesFeed(c *gin.Context) {
+	format, ok := feedFormat(c)
+	if !ok {
+		return
+	}
+
+	author, err := models.GetUserByUsername(c.Param("username"))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取用户失败"})
+		return
+	}
+
+	articles, _, err := models.GetAuthorTechArticles(author.ID, feedPage)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取文章失败"})
+		return
+	}
+
+	serveFeed(c, format, &syndication.Feed{
+		Title:       "AI论坛 - " + author.Username + "的技术分享",
+		Description: author.Username + "在AI论坛发布的技术文章",
+		Link:        profileURL(author.Username),
+		Items:       articleFeedItems(articles),
+	})
+}
+
+// 学习资料
+func ResourcesFeed(c *gin.Context) {
+	format, ok := feedFormat(c)
+	if !ok {
+		return
+	}
+
+	resources, _, err := models.GetLearningResources("", "", "", "", "", "", 0, feedPage)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取资料失败"})
+		return
+	}
+
+	serveFeed(c, format, &syndication.Feed{
+		Title:       "AI论坛 - 学习资料",
+		Description: "AI论坛最新上传的学习资料",
+		Link:        absoluteURL("/learning-resources"),
+		Items:       resourceFeedItems(resources),
+	})
+}
+
+// 分类下的学习资料
+func ResourceCategoryFeed(c *gin.Context) {
+	format, ok := feedFormat(c)
+	if !ok {
+		return
+	}
+
+	category, err := models.GetCategoryBySlug(c.Param("category"))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "分类不存在"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取分类失败"})
+		return
+	}
+
+	resources, _, err := models.GetLearningResources("", "", "", "", "", category.Slug, 0, feedPage)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取资料失败"})
+		return
+	}
+
+	serveFeed(c, format, &syndication.Feed{
+		Title:       "AI论坛 - 学习资料 - " + category.Name,
+		Description: category.Description,
+		Link:        absoluteURL("/learning-resources/category/" + url.PathEscape(category.Slug)),
+		Items:       resourceFeedItems(resources),
+	})
+}
+
+// 检查路径中的订阅格式（rss、atom、json）
+func feedFormat(c *gin.Context) (string, bool) {
+	format := c.Param("format")
+	if !syndication.ValidFormat(format) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "不支持的订阅格式，可选rss、atom、json"})
+		return "", false
+	}
+	return format, true
+}
+
+// 输出订阅内容。ETag取内容的哈希，Last-Modified取最新条目的发布时间，
+// 条件请求（If-None-Match、If-Modified-Since）命中时返回304
+func serveFeed(c *gin.Context, format string, feed *syndication.Feed) {
+	feed.FeedURL = absoluteURL(c.Request.URL.Path)
+	feed.Updated = syndication.LastPublished(feed.Items)
+
+	body, contentType, err := syndication.Render(feed, format)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成订阅失败"})
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	c.Header("Content-Type", contentType)
+	c.Header("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+	c.Header("Cache-Control", "public, max-age=300")
+	http.ServeContent(c.Writer, c.Request, "", feed.Updated, bytes.NewReader(body))
+}
+
+func absoluteURL(path string) string {
+	return config.AppConfig.SiteURL + path
+}
+
+func profileURL(username string) string {
+	return absoluteURL("/u/" + url.PathEscape(username))
+}
+
+func questionFeedItems(questions []*models.Question) []*syndication.Item {
+	items := make([]*syndication.Item, 0, len(questions))
+	for _, q := range questions {
+		link := absoluteURL("/qa/" + strconv.Itoa(q.ID))
+		items = append(items, &syndication.Item{
+			ID:         link,
+			Title:      q.Title,
+			Link:       link,
+			Summary:    q.Summary,
+			Content:    q.Content,
+			AuthorName: q.Username,
+			AuthorURL:  profileURL(q.Username),
+			Categories: models.ParseTagNames(q.Tags),
+			Published:  q.CreatedAt,
+		})
+	}
+	return items
+}
+
+func articleFeedItems(articles []*models.TechArticle) []*syndication.Item {
+	items := make([]*syndication.Item, 0, len(articles))
+	for _, a := range articles {
+		link := absoluteURL("/tech-share/" + strconv.Itoa(a.ID))
+		items = append(items, &syndication.Item{
+			ID:         link,
+			Title:      a.Title,
+			Link:       link,
+			Summary:    a.Summary,
+			Content:    a.Content,
+			AuthorName: a.AuthorName,
+			AuthorURL:  profileURL(a.AuthorName),
+			Categories: models.ParseTagNames(a.Tags),
+			Published:  a.CreatedAt,
+		})
+	}
+	return items
+}
+
+func resourceFeedItems(resources []*models.LearningResource) []*syndication.Item {
+	items := make([]*syndication.Item, 0, len(resources))
+	for _, r := range resources {
+		link := absoluteURL("/learning-resources/" + strconv.Itoa(r.ID))
+		items = append(items, &syndication.Item{
+			ID:         link,
+			Title:      r.Title,
+			Link:       link,
+			Summary:    r.Description,
+			Content:    r.Description,
+			AuthorName: r.UploaderName,
+			AuthorURL:  profileURL(r.UploaderName),
+			Categories: models.ParseTagNames(r.Tags),
+			Published:  r.CreatedAt,
+		})
+	}
+	return items
+}
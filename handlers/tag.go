@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"aiforum/models"
+	"github.com/gin-gonic/gin"
+)
+
+// 获取标签详情
+func GetTagDetail(c *gin.Context) {
+	tag, err := models.GetTagByName(c.Param("name"))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "标签不存在"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取标签失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tag": tag,
+	})
+}
+
+// 标签管理页面
+func AdminTagsPage(c *gin.Context) {
+	tags, err := models.GetTags()
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"error": "获取标签失败",
+		})
+		return
+	}
+
+	user, _ := models.GetUserByID(c.GetInt("user_id"))
+
+	c.HTML(http.StatusOK, "admin_tags.html", gin.H{
+		"title": "标签管理",
+		"tags":  tags,
+		"user":  user,
+	})
+}
+
+// 更新标签说明和维基
+func UpdateTag(c *gin.Context) {
+	tagID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的标签ID"})
+		return
+	}
+
+	var req struct {
+		Description string `json:"description" binding:"max=500"`
+		Wiki        string `json:"wiki"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "标签说明不能超过500个字符"})
+		return
+	}
+
+	err = models.UpdateTagInfo(tagID, req.Description, req.Wiki)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "标签不存在"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新标签失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "标签已更新",
+	})
+}
+
+// 添加标签同义词
+func AddTagSynonym(c *gin.Context) {
+	tagID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的标签ID"})
+		return
+	}
+
+	var req struct {
+		Name string `json:"name" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请输入同义词"})
+		return
+	}
+
+	err = models.AddTagSynonym(tagID, req.Name)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "标签不存在"})
+		return
+	}
+	if err == models.ErrTagNameTaken {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "添加同义词失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "同义词已添加",
+	})
+}
+
+// 删除标签同义词
+func DeleteTagSynonym(c *gin.Context) {
+	tagID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的标签ID"})
+		return
+	}
+	synonymID, err := strconv.Atoi(c.Param("synonym_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的同义词ID"})
+		return
+	}
+
+	err = models.DeleteTagSynonym(tagID, synonymID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "同义词不存在"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除同义词失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "同义词已删除",
+	})
+}
+
+// 合并标签
+func MergeTag(c *gin.Context) {
+	sourceID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的标签ID"})
+		return
+	}
+
+	var req struct {
+		TargetID int `json:"target_id" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请指定目标标签"})
+		return
+	}
+
+	err = models.MergeTags(sourceID, req.TargetID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "标签不存在"})
+		return
+	}
+	if err == models.ErrInvalidTagMerge {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "合并标签失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "标签已合并",
+	})
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"database/sql"
 	"net/http"
 	"strconv"
 
@@ -11,14 +12,25 @@ import (
 // 技术分享页面
 func TechSharePage(c *gin.Context) {
 	// 获取查询参数
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	page, err := pageQuery(c, 12)
+	if err != nil {
+		c.HTML(http.StatusBadRequest, "error.html", gin.H{
+			"error": "无效的分页参数",
+		})
+		return
+	}
 	category := c.Query("category")
 	keyword := c.Query("q")
 	sort := c.DefaultQuery("sort", "latest")
 	topic := c.Query("topic")
 
+	// 记录搜索词
+	if keyword != "" {
+		go models.RecordSearchQuery(c.GetInt("user_id"), keyword, "tech_share")
+	}
+
 	// 获取文章列表
-	articles, err := models.GetTechArticles(page, 12, category, keyword, sort, topic)
+	articles, pageInfo, err := models.GetTechArticles(category, keyword, sort, topic, c.GetInt("user_id"), page)
 	if err != nil {
 		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
 			"error": "获取文章失败",
@@ -38,10 +50,6 @@ func TechSharePage(c *gin.Context) {
 		user, _ = models.GetUserByID(userID.(int))
 	}
 
-	// 计算总页数
-	totalCount, _ := models.GetTechArticleCount(category, keyword, topic)
-	totalPages := (totalCount + 11) / 12
-
 	c.HTML(http.StatusOK, "tech_share.html", gin.H{
 		"title":           "技术分享",
 		"articles":        articles,
@@ -52,9 +60,8 @@ func TechSharePage(c *gin.Context) {
 		"keyword":         keyword,
 		"sort":            sort,
 		"currentTopic":    topic,
-		"page":            page,
-		"totalPages":      totalPages,
-		"currentPage":     page,
+		"nextCursor":      pageInfo.NextCursor,
+		"prevCursor":      pageInfo.PrevCursor,
 	})
 }
 
@@ -83,7 +90,7 @@ func TechShareDetailPage(c *gin.Context) {
 	// 检查用户是否已点赞和收藏
 	if user != nil {
 		article.IsLiked = models.IsArticleLiked(article.ID, user.ID)
-		article.IsFavorited = models.IsArticleFavorited(article.ID, user.ID)
+		article.IsFavorited = models.IsBookmarked(user.ID, models.ContentTypeArticle, article.ID)
 	}
 	
 	// 获取文章评论
@@ -92,11 +99,35 @@ func TechShareDetailPage(c *gin.Context) {
 		comments = []models.Comment{}
 	}
 	
-	// 为评论添加用户等级和点赞状态
+	// 为评论和回复添加用户等级和点赞状态（批量查询）
+	var userIDs, commentIDs []int
+	contents := []string{article.Content}
+	for _, comment := range comments {
+		userIDs = append(userIDs, comment.UserID)
+		commentIDs = append(commentIDs, comment.ID)
+		contents = append(contents, comment.Content)
+		for _, reply := range comment.Replies {
+			userIDs = append(userIDs, reply.UserID)
+			commentIDs = append(commentIDs, reply.ID)
+			contents = append(contents, reply.Content)
+		}
+	}
+	commentUsers, _ := models.GetUsersByIDs(userIDs)
+	var liked map[int]bool
+	if user != nil {
+		liked, _ = models.GetLikedCommentIDs(user.ID, commentIDs)
+	}
+	fillComment := func(comment *models.Comment) {
+		comment.UserLevel = 1
+		if u, ok := commentUsers[comment.UserID]; ok {
+			comment.UserLevel = models.GetUserLevel(u.Points)
+		}
+		comment.IsLiked = liked[comment.ID]
+	}
 	for i := range comments {
-		comments[i].UserLevel = calculateUserLevel(comments[i].UserID)
-		if user != nil {
-			comments[i].IsLiked = models.IsCommentLiked(comments[i].ID, user.ID)
+		fillComment(&comments[i])
+		for j := range comments[i].Replies {
+			fillComment(&comments[i].Replies[j])
 		}
 	}
 	
@@ -121,6 +152,7 @@ func TechShareDetailPage(c *gin.Context) {
 		"comments":        comments,
 		"relatedArticles": relatedArticles,
 		"authorArticles":  authorArticles,
+		"mentionedUsers":  models.LoadMentionedUsers(contents...),
 		"user":            user,
 	})
 }
@@ -128,7 +160,13 @@ func TechShareDetailPage(c *gin.Context) {
 // 专题页面
 func TopicPage(c *gin.Context) {
 	topicSlug := c.Param("slug")
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	page, err := pageQuery(c, 12)
+	if err != nil {
+		c.HTML(http.StatusBadRequest, "error.html", gin.H{
+			"error": "无效的分页参数",
+		})
+		return
+	}
 
 	// 获取专题信息
 	topic, err := models.GetTopicBySlug(topicSlug)
@@ -140,7 +178,7 @@ func TopicPage(c *gin.Context) {
 	}
 
 	// 获取专题下的文章
-	articles, err := models.GetTechArticles(page, 12, "", "", "latest", topicSlug)
+	articles, pageInfo, err := models.GetTechArticles("", "", "latest", topicSlug, 0, page)
 	if err != nil {
 		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
 			"error": "获取文章失败",
@@ -148,17 +186,12 @@ func TopicPage(c *gin.Context) {
 		return
 	}
 
-	// 计算总页数
-	totalCount, _ := models.GetTechArticleCount("", "", topicSlug)
-	totalPages := (totalCount + 11) / 12
-
 	c.HTML(http.StatusOK, "topic.html", gin.H{
-		"title":       topic.Name,
-		"topic":       topic,
-		"articles":    articles,
-		"page":        page,
-		"totalPages":  totalPages,
-		"currentPage": page,
+		"title":      topic.Name,
+		"topic":      topic,
+		"articles":   articles,
+		"nextCursor": pageInfo.NextCursor,
+		"prevCursor": pageInfo.PrevCursor,
 	})
 }
 
@@ -235,6 +268,31 @@ func LikeTechArticle(c *gin.Context) {
 	})
 }
 
+// 收藏/取消收藏文章
+func FavoriteArticle(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	articleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的文章ID"})
+		return
+	}
+
+	isFavorited, err := models.ToggleBookmark(userID, models.ContentTypeArticle, articleID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "文章不存在"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "操作失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"isFavorited": isFavorited,
+	})
+}
+
 // 关注作者
 func FollowAuthor(c *gin.Context) {
 	userID := c.GetInt("user_id")
@@ -244,7 +302,15 @@ func FollowAuthor(c *gin.Context) {
 		return
 	}
 
-	isFollowed, err := models.ToggleFollowAuthor(authorID, userID)
+	isFollowed, err := models.ToggleFollow(userID, authorID)
+	if err == models.ErrCannotFollowSelf {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err == models.ErrBlocked {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "操作失败"})
 		return
@@ -260,4 +326,43 @@ func FollowAuthor(c *gin.Context) {
 			return "取消关注成功"
 		}(),
 	})
-} 
\ No newline at end of file
+} 
+// 发表文章评论
+func CreateArticleComment(c *gin.Context) {
+	userID := c.GetInt("user_id")
+	articleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的文章ID"})
+		return
+	}
+
+	var req struct {
+		Content  string `json:"content" binding:"required"`
+		ParentID int    `json:"parent_id"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请填写评论内容"})
+		return
+	}
+
+	commentID, err := models.CreateArticleComment(articleID, userID, req.ParentID, req.Content)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "文章或评论不存在"})
+		return
+	}
+	if err == models.ErrBlocked {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "评论失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"message":    "评论成功",
+		"comment_id": commentID,
+	})
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"aiforum/models"
+	"aiforum/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 令牌有效期的上限（天）
+const maxTokenExpiryDays = 365
+
+// 获取个人访问令牌
+func GetPersonalTokens(c *gin.Context) {
+	tokens, err := models.GetPersonalTokens(c.GetInt("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "获取令牌失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"tokens":  tokens,
+		"scopes":  models.PersonalTokenScopes,
+	})
+}
+
+// 创建个人访问令牌，令牌明文只在创建时返回一次
+func CreatePersonalToken(c *gin.Context) {
+	var req struct {
+		Name          string   `json:"name" binding:"required,max=100"`
+		Scopes        []string `json:"scopes" binding:"required"`
+		ExpiresInDays int      `json:"expires_in_days"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "请填写令牌名称并选择权限范围",
+		})
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || !models.ValidScopes(req.Scopes) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "请填写令牌名称并选择有效的权限范围",
+		})
+		return
+	}
+	if req.ExpiresInDays < 0 || req.ExpiresInDays > maxTokenExpiryDays {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "有效期最长为365天",
+		})
+		return
+	}
+
+	// 0表示不过期
+	var expiresAt *time.Time
+	if req.ExpiresInDays > 0 {
+		t := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		expiresAt = &t
+	}
+
+	plaintext, err := utils.GeneratePersonalToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "创建令牌失败",
+		})
+		return
+	}
+	prefix := plaintext[:len(utils.PersonalTokenPrefix)+8]
+
+	token, err := models.CreatePersonalToken(c.GetInt("user_id"), req.Name, utils.HashPersonalToken(plaintext),
+		prefix, req.Scopes, expiresAt)
+	if err == models.ErrTooManyTokens || err == models.ErrInvalidScope {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "创建令牌失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "令牌已创建，请立即复制保存，关闭后将无法再次查看",
+		"token":   plaintext,
+		"info":    token,
+	})
+}
+
+// 删除个人访问令牌
+func RevokePersonalToken(c *gin.Context) {
+	tokenID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的令牌ID",
+		})
+		return
+	}
+
+	err = models.RevokePersonalToken(c.GetInt("user_id"), tokenID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "令牌不存在",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "删除令牌失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "令牌已删除",
+	})
+}
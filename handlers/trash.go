@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"aiforum/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 获取当前用户的回收站
+func GetUserTrash(c *gin.Context) {
+	items, err := models.GetUserTrash(c.GetInt("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "获取回收站失败",
+		})
+		return
+	}
+	if items == nil {
+		items = []*models.TrashItem{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":        true,
+		"items":          items,
+		"retention_days": int(models.TrashRetention.Hours() / 24),
+	})
+}
+
+// 从回收站恢复自己删除的内容
+func RestoreTrashItem(c *gin.Context) {
+	contentType, contentID, ok := trashParams(c)
+	if !ok {
+		return
+	}
+	respondRestore(c, models.RestoreContent(c.GetInt("user_id"), contentType, contentID))
+}
+
+// 版主恢复用户删除的内容
+func UndeleteContent(c *gin.Context) {
+	contentType, contentID, ok := trashParams(c)
+	if !ok {
+		return
+	}
+	respondRestore(c, models.UndeleteContent(contentType, contentID))
+}
+
+// 解析路径中的内容类型和ID
+func trashParams(c *gin.Context) (string, int, bool) {
+	contentType := c.Param("type")
+	if !models.ValidTrashType(contentType) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   models.ErrInvalidTrashType.Error(),
+		})
+		return "", 0, false
+	}
+
+	contentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的内容ID",
+		})
+		return "", 0, false
+	}
+	return contentType, contentID, true
+}
+
+// 返回恢复结果
+func respondRestore(c *gin.Context, err error) {
+	switch err {
+	case nil:
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "内容已恢复",
+		})
+	case models.ErrTrashNotFound:
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+	case models.ErrTrashExpired:
+		c.JSON(http.StatusGone, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "恢复内容失败",
+		})
+	}
+}
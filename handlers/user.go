@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"aiforum/models"
+	"github.com/gin-gonic/gin"
+)
+
+// @提及自动补全每次最多返回的用户数
+const maxUserSuggestions = 20
+
+// 按用户名前缀推荐用户（@提及自动补全）
+func SuggestUsers(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "8"))
+	if limit < 1 || limit > maxUserSuggestions {
+		limit = 8
+	}
+
+	users, err := models.SuggestUsers(c.Query("q"), c.GetInt("user_id"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "获取用户失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"users":   users,
+	})
+}
+
+// 用户公开主页
+func UserProfilePage(c *gin.Context) {
+	viewerID := c.GetInt("user_id")
+
+	profile, err := models.GetPublicProfileByUsername(c.Param("username"), viewerID)
+	if err == sql.ErrNoRows {
+		c.HTML(http.StatusNotFound, "error.html", gin.H{
+			"error": "用户不存在",
+		})
+		return
+	}
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"error": "获取用户主页失败",
+		})
+		return
+	}
+
+	var viewer *models.User
+	if viewerID > 0 {
+		viewer, _ = models.GetUserByID(viewerID)
+	}
+
+	c.HTML(http.StatusOK, "user_profile.html", gin.H{
+		"title":   profile.Username + " 的主页",
+		"user":    viewer,
+		"profile": profile,
+	})
+}
+
+// 获取用户公开资料
+func GetPublicProfile(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的用户ID",
+		})
+		return
+	}
+
+	profile, err := models.GetPublicProfile(userID, c.GetInt("user_id"))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "用户不存在",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "获取用户资料失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    profile,
+	})
+}
@@ -0,0 +1,87 @@
+package v1
+
+import (
+	"database/sql"
+	"net/http"
+
+	"aiforum/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 文章列表的排序方式
+var articleSorts = []string{"latest", "likes", "comments", "views"}
+
+// 发布文章请求
+type createArticleRequest struct {
+	Title    string `json:"title" binding:"required,max=200"`
+	Content  string `json:"content" binding:"required"`
+	Category string `json:"category" binding:"required"`
+	Tags     string `json:"tags"`
+}
+
+// 文章列表
+func listArticles(c *gin.Context) {
+	page, ok := pageParams(c)
+	if !ok {
+		return
+	}
+	sort, ok := enumQuery(c, "sort", "latest", articleSorts)
+	if !ok {
+		return
+	}
+	category, keyword, topic := c.Query("category"), c.Query("q"), c.Query("topic")
+
+	articles, info, err := models.GetTechArticles(category, keyword, sort, topic, c.GetInt("user_id"), page.query)
+	if err != nil {
+		failList(c, err, "获取文章列表失败")
+		return
+	}
+	meta := page.meta(info)
+	if page.total {
+		total, err := models.GetTechArticleCount(category, keyword, topic, c.GetInt("user_id"))
+		if err != nil {
+			fail(c, http.StatusInternalServerError, CodeInternal, "获取文章列表失败")
+			return
+		}
+		meta.Total = &total
+	}
+	respondList(c, articles, meta)
+}
+
+// 文章详情
+func getArticle(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		return
+	}
+
+	article, err := models.GetTechArticleByID(id)
+	if err == sql.ErrNoRows {
+		fail(c, http.StatusNotFound, CodeNotFound, "文章不存在")
+		return
+	}
+	if err != nil {
+		fail(c, http.StatusInternalServerError, CodeInternal, "获取文章失败")
+		return
+	}
+	if userID := c.GetInt("user_id"); userID > 0 {
+		article.IsLiked = models.IsArticleLiked(id, userID)
+	}
+	respond(c, http.StatusOK, article)
+}
+
+// 发布文章
+func createArticle(c *gin.Context) {
+	var req createArticleRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	articleID, err := models.CreateTechArticle(req.Title, req.Content, req.Category, c.GetInt("user_id"), req.Tags, "")
+	if err != nil {
+		fail(c, http.StatusInternalServerError, CodeInternal, "发布失败")
+		return
+	}
+	respond(c, http.StatusCreated, createdID{ID: articleID})
+}
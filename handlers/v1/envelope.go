@@ -0,0 +1,177 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+
+	"aiforum/models"
+	"aiforum/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 错误码（客户端应根据错误码而不是错误信息判断错误类型）
+const (
+	CodeBadRequest       = "bad_request"
+	CodeValidationFailed = "validation_failed"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeNotFound         = "not_found"
+	CodeConflict         = "conflict"
+	CodeInternal         = "internal_error"
+)
+
+// 分页参数的默认值和上限
+const (
+	defaultPerPage = models.DefaultPageSize
+	maxPerPage     = models.MaxPageSize
+)
+
+// 成功响应
+type Envelope struct {
+	Data interface{} `json:"data"`
+	Meta *Pagination `json:"meta,omitempty"`
+}
+
+// 错误响应
+type ErrorEnvelope struct {
+	Error ErrorBody `json:"error"`
+}
+
+// 错误详情
+type ErrorBody struct {
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// 分页信息，游标为空表示没有上一页或下一页，total只在请求include_total时返回
+type Pagination struct {
+	PerPage    int    `json:"per_page"`
+	NextCursor string `json:"next_cursor"`
+	PrevCursor string `json:"prev_cursor"`
+	Total      *int   `json:"total,omitempty"`
+}
+
+// 解析后的分页参数
+type listPage struct {
+	query models.PageQuery
+	total bool // 是否需要返回总数
+}
+
+// 根据查询结果生成分页信息
+func (p listPage) meta(info models.PageInfo) Pagination {
+	return Pagination{PerPage: p.query.Limit, NextCursor: info.NextCursor, PrevCursor: info.PrevCursor}
+}
+
+// 返回单个对象
+func respond(c *gin.Context, status int, data interface{}) {
+	c.JSON(status, Envelope{Data: data})
+}
+
+// 返回分页列表，空列表返回[]而不是null
+func respondList(c *gin.Context, items interface{}, meta Pagination) {
+	if v := reflect.ValueOf(items); v.Kind() == reflect.Slice && v.IsNil() {
+		items = []interface{}{}
+	}
+	c.JSON(http.StatusOK, Envelope{Data: items, Meta: &meta})
+}
+
+// 返回错误
+func fail(c *gin.Context, status int, code, message string) {
+	c.JSON(status, ErrorEnvelope{Error: ErrorBody{Code: code, Message: message}})
+}
+
+// 返回带详情的错误
+func failWithDetails(c *gin.Context, status int, code, message string, details interface{}) {
+	c.JSON(status, ErrorEnvelope{Error: ErrorBody{Code: code, Message: message, Details: details}})
+}
+
+// 列表查询失败，游标与排序方式不符时属于请求错误
+func failList(c *gin.Context, err error, message string) {
+	if errors.Is(err, utils.ErrInvalidCursor) {
+		fail(c, http.StatusBadRequest, CodeBadRequest, "cursor与当前排序方式不符")
+		return
+	}
+	fail(c, http.StatusInternalServerError, CodeInternal, message)
+}
+
+// 认证和权限中间件失败时使用统一的错误格式，附加信息放在details中
+func abortAuth(c *gin.Context, status int, message string, details gin.H) {
+	code := CodeUnauthorized
+	if status == http.StatusForbidden {
+		code = CodeForbidden
+	}
+	if details != nil {
+		failWithDetails(c, status, code, message, details)
+		return
+	}
+	fail(c, status, code, message)
+}
+
+// 解析cursor、per_page和include_total参数
+func pageParams(c *gin.Context) (listPage, bool) {
+	cursor, err := utils.DecodeCursor(c.Query("cursor"))
+	if err != nil {
+		fail(c, http.StatusBadRequest, CodeBadRequest, "cursor无效")
+		return listPage{}, false
+	}
+	perPage, err := strconv.Atoi(c.DefaultQuery("per_page", strconv.Itoa(defaultPerPage)))
+	if err != nil || perPage < 1 || perPage > maxPerPage {
+		fail(c, http.StatusBadRequest, CodeBadRequest, "per_page必须是1到"+strconv.Itoa(maxPerPage)+"之间的整数")
+		return listPage{}, false
+	}
+	total, err := strconv.ParseBool(c.DefaultQuery("include_total", "false"))
+	if err != nil {
+		fail(c, http.StatusBadRequest, CodeBadRequest, "include_total必须是true或false")
+		return listPage{}, false
+	}
+	return listPage{query: models.PageQuery{Cursor: cursor, Limit: perPage}, total: total}, true
+}
+
+// 解析路径中的ID参数
+func idParam(c *gin.Context, name string) (int, bool) {
+	id, err := strconv.Atoi(c.Param(name))
+	if err != nil || id <= 0 {
+		fail(c, http.StatusBadRequest, CodeBadRequest, name+"必须是正整数")
+		return 0, false
+	}
+	return id, true
+}
+
+// 解析可选的整数查询参数
+func intQuery(c *gin.Context, name string) (int, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return 0, true
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		fail(c, http.StatusBadRequest, CodeBadRequest, name+"必须是非负整数")
+		return 0, false
+	}
+	return n, true
+}
+
+// 检查查询参数是否为允许的取值
+func enumQuery(c *gin.Context, name, fallback string, allowed []string) (string, bool) {
+	value := c.DefaultQuery(name, fallback)
+	for _, a := range allowed {
+		if value == a {
+			return value, true
+		}
+	}
+	failWithDetails(c, http.StatusBadRequest, CodeBadRequest, "无效的"+name, gin.H{"allowed": allowed})
+	return "", false
+}
+
+// 绑定JSON请求体
+func bindJSON(c *gin.Context, req interface{}) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		failWithDetails(c, http.StatusUnprocessableEntity, CodeValidationFailed, "请求参数不完整或格式错误", err.Error())
+		return false
+	}
+	return true
+}
@@ -0,0 +1,306 @@
+package v1
+
+import (
+	"net/http"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"aiforum/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OpenAPI文档根据路由表和处理函数使用的请求、响应类型生成，只生成一次
+var (
+	openAPIOnce sync.Once
+	openAPIDoc  gin.H
+)
+
+// 返回OpenAPI 3文档
+func ServeOpenAPI(c *gin.Context) {
+	openAPIOnce.Do(func() {
+		openAPIDoc = buildOpenAPI()
+	})
+	c.JSON(http.StatusOK, openAPIDoc)
+}
+
+func buildOpenAPI() gin.H {
+	schemas := newSchemaRegistry()
+	errorSchema := schemas.schemaFor(reflect.TypeOf(ErrorEnvelope{}), false)
+	paginationSchema := schemas.schemaFor(reflect.TypeOf(Pagination{}), false)
+
+	paths := gin.H{}
+	for _, rt := range routes {
+		path := openAPIPath(rt.path)
+		item, ok := paths[path].(gin.H)
+		if !ok {
+			item = gin.H{}
+			paths[path] = item
+		}
+		item[strings.ToLower(rt.method)] = operation(schemas, rt, paginationSchema)
+	}
+
+	responses := gin.H{}
+	for _, status := range []int{
+		http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound,
+		http.StatusConflict, http.StatusUnprocessableEntity, http.StatusInternalServerError,
+	} {
+		responses[strconv.Itoa(status)] = gin.H{
+			"description": http.StatusText(status),
+			"content":     gin.H{"application/json": gin.H{"schema": errorSchema}},
+		}
+	}
+
+	return gin.H{
+		"openapi": "3.0.3",
+		"info": gin.H{
+			"title":   "AI论坛 API",
+			"version": "1.0.0",
+			"description": "成功时返回{\"data\": ..., \"meta\": 分页信息}，失败时返回{\"error\": {\"code\", \"message\"}}。" +
+				"错误码：" + strings.Join([]string{CodeBadRequest, CodeValidationFailed, CodeUnauthorized,
+				CodeForbidden, CodeNotFound, CodeConflict, CodeInternal}, "、") + "。",
+		},
+		"servers": []gin.H{{"url": config.AppConfig.SiteURL + "/api/v1"}},
+		"paths":   paths,
+		"components": gin.H{
+			"schemas":   schemas.schemas,
+			"responses": responses,
+			"securitySchemes": gin.H{
+				"bearerAuth": gin.H{
+					"type":        "http",
+					"scheme":      "bearer",
+					"description": "登录token或个人访问令牌（afp_开头），令牌需要的权限范围见接口的x-token-scopes，没有该字段的接口不接受个人访问令牌",
+				},
+				"cookieAuth": gin.H{"type": "apiKey", "in": "cookie", "name": "token"},
+			},
+		},
+	}
+}
+
+// 生成单个接口的描述
+func operation(schemas *schemaRegistry, rt route, paginationSchema gin.H) gin.H {
+	op := gin.H{
+		"operationId": handlerName(rt.handler),
+		"tags":        []string{rt.tag},
+		"summary":     rt.summary,
+	}
+
+	var params []gin.H
+	for _, segment := range strings.Split(rt.path, "/") {
+		if strings.HasPrefix(segment, ":") {
+			params = append(params, gin.H{
+				"name": segment[1:], "in": "path", "required": true,
+				"schema": gin.H{"type": "integer", "minimum": 1},
+			})
+		}
+	}
+	query := rt.query
+	if rt.list {
+		query = append([]queryParam{
+			{name: "cursor", kind: "string", description: "上一次响应meta中的next_cursor或prev_cursor，不传时返回第一页"},
+			{name: "per_page", kind: "integer", description: "每页数量，默认" + strconv.Itoa(defaultPerPage) + "，最大" + strconv.Itoa(maxPerPage)},
+			{name: "include_total", kind: "boolean", description: "是否在meta中返回总数"},
+		}, query...)
+	}
+	for _, q := range query {
+		schema := gin.H{"type": q.kind}
+		if len(q.enum) > 0 {
+			schema["enum"] = q.enum
+			schema["default"] = q.enum[0]
+		}
+		params = append(params, gin.H{"name": q.name, "in": "query", "description": q.description, "schema": schema})
+	}
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+
+	if rt.body != nil {
+		op["requestBody"] = gin.H{
+			"required": true,
+			"content": gin.H{"application/json": gin.H{
+				"schema": schemas.schemaFor(reflect.TypeOf(rt.body), true),
+			}},
+		}
+	}
+
+	data := schemas.schemaFor(reflect.TypeOf(rt.result), false)
+	envelope := gin.H{
+		"type":       "object",
+		"required":   []string{"data"},
+		"properties": gin.H{"data": data},
+	}
+	if rt.list {
+		envelope["required"] = []string{"data", "meta"}
+		envelope["properties"] = gin.H{
+			"data": gin.H{"type": "array", "items": data},
+			"meta": paginationSchema,
+		}
+	}
+	status := rt.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	responses := gin.H{
+		strconv.Itoa(status): gin.H{
+			"description": http.StatusText(status),
+			"content":     gin.H{"application/json": gin.H{"schema": envelope}},
+		},
+	}
+
+	errors := append([]int{http.StatusInternalServerError}, rt.errors...)
+	if len(params) > 0 {
+		errors = append(errors, http.StatusBadRequest)
+	}
+	if strings.Contains(rt.path, ":") {
+		errors = append(errors, http.StatusNotFound)
+	}
+	if rt.body != nil {
+		errors = append(errors, http.StatusUnprocessableEntity)
+	}
+	switch rt.auth {
+	case authRequired:
+		errors = append(errors, http.StatusUnauthorized, http.StatusForbidden)
+		op["security"] = []gin.H{{"bearerAuth": []string{}}, {"cookieAuth": []string{}}}
+	case authOptional:
+		op["security"] = []gin.H{{"bearerAuth": []string{}}, {"cookieAuth": []string{}}, {}}
+	}
+	for _, e := range errors {
+		responses[strconv.Itoa(e)] = gin.H{"$ref": "#/components/responses/" + strconv.Itoa(e)}
+	}
+	op["responses"] = responses
+
+	if rt.auth != authNone && len(rt.scopes) > 0 {
+		op["x-token-scopes"] = rt.scopes
+	}
+	return op
+}
+
+// 将gin路径中的:id转换为{id}
+func openAPIPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// 处理函数名作为operationId
+func handlerName(handler gin.HandlerFunc) string {
+	name := runtime.FuncForPC(reflect.ValueOf(handler).Pointer()).Name()
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// 根据Go类型生成JSON Schema，具名结构体放入components/schemas
+type schemaRegistry struct {
+	schemas gin.H
+}
+
+func newSchemaRegistry() *schemaRegistry {
+	return &schemaRegistry{schemas: gin.H{}}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// request为true时按binding标签判断必填字段，否则不带omitempty的字段都是必有字段
+func (r *schemaRegistry) schemaFor(t reflect.Type, request bool) gin.H {
+	switch {
+	case t == timeType:
+		return gin.H{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Ptr:
+		schema := r.schemaFor(t.Elem(), request)
+		if _, ok := schema["$ref"]; ok {
+			return gin.H{"allOf": []gin.H{schema}, "nullable": true}
+		}
+		schema["nullable"] = true
+		return schema
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		name := t.Name()
+		if name == "" {
+			return r.structSchema(t, request)
+		}
+		name = strings.ToUpper(name[:1]) + name[1:]
+		if _, ok := r.schemas[name]; !ok {
+			// 先占位，避免自引用的类型无限递归
+			r.schemas[name] = gin.H{}
+			r.schemas[name] = r.structSchema(t, request)
+		}
+		return gin.H{"$ref": "#/components/schemas/" + name}
+	case reflect.Slice, reflect.Array:
+		return gin.H{"type": "array", "items": r.schemaFor(t.Elem(), request)}
+	case reflect.Map:
+		return gin.H{"type": "object", "additionalProperties": r.schemaFor(t.Elem(), request)}
+	case reflect.Bool:
+		return gin.H{"type": "boolean"}
+	case reflect.Int64, reflect.Uint64:
+		return gin.H{"type": "integer", "format": "int64"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return gin.H{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return gin.H{"type": "number"}
+	case reflect.String:
+		return gin.H{"type": "string"}
+	}
+	return gin.H{}
+}
+
+func (r *schemaRegistry) structSchema(t reflect.Type, request bool) gin.H {
+	properties := gin.H{}
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+
+		schema := r.schemaFor(field.Type, request)
+		binding := field.Tag.Get("binding")
+		for _, rule := range strings.Split(binding, ",") {
+			key, value, _ := strings.Cut(rule, "=")
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				continue
+			}
+			switch {
+			case key == "max" && field.Type.Kind() == reflect.String:
+				schema["maxLength"] = n
+			case key == "min" && field.Type.Kind() == reflect.Int:
+				schema["minimum"] = n
+			}
+		}
+		properties[name] = schema
+
+		if request {
+			if strings.Contains(binding, "required") {
+				required = append(required, name)
+			}
+		} else if !strings.Contains(opts, "omitempty") {
+			required = append(required, name)
+		}
+	}
+
+	schema := gin.H{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	return schema
+}
@@ -0,0 +1,179 @@
+package v1
+
+import (
+	"database/sql"
+	"net/http"
+
+	"aiforum/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 问题列表的排序方式
+var questionSorts = []string{"latest", "hot", "reward", "unsolved"}
+
+// 提问请求
+type createQuestionRequest struct {
+	Title      string `json:"title" binding:"required,max=200"`
+	Content    string `json:"content" binding:"required"`
+	CategoryID int    `json:"category_id" binding:"required,min=1"`
+	Tags       string `json:"tags"`
+	Reward     int    `json:"reward" binding:"min=0"`
+	// 确认与已有问题不重复后再次提交
+	IgnoreSimilar bool `json:"ignore_similar"`
+}
+
+// 回答请求
+type createAnswerRequest struct {
+	Content string `json:"content" binding:"required"`
+}
+
+// 创建成功后返回的ID
+type createdID struct {
+	ID int `json:"id"`
+}
+
+// 问题列表
+func listQuestions(c *gin.Context) {
+	page, ok := pageParams(c)
+	if !ok {
+		return
+	}
+	categoryID, ok := intQuery(c, "category_id")
+	if !ok {
+		return
+	}
+	sort, ok := enumQuery(c, "sort", "latest", questionSorts)
+	if !ok {
+		return
+	}
+
+	questions, info, err := models.GetQuestions(categoryID, sort, page.query)
+	if err != nil {
+		failList(c, err, "获取问题列表失败")
+		return
+	}
+	meta := page.meta(info)
+	if page.total {
+		total, err := models.GetQuestionCount(categoryID)
+		if err != nil {
+			fail(c, http.StatusInternalServerError, CodeInternal, "获取问题列表失败")
+			return
+		}
+		meta.Total = &total
+	}
+	respondList(c, questions, meta)
+}
+
+// 问题详情
+func getQuestion(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		return
+	}
+
+	question, err := models.GetQuestionByID(id)
+	if err == sql.ErrNoRows {
+		fail(c, http.StatusNotFound, CodeNotFound, "问题不存在")
+		return
+	}
+	if err != nil {
+		fail(c, http.StatusInternalServerError, CodeInternal, "获取问题失败")
+		return
+	}
+	respond(c, http.StatusOK, question)
+}
+
+// 问题的回答列表
+func listAnswers(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if _, err := models.GetQuestionByID(id); err == sql.ErrNoRows {
+		fail(c, http.StatusNotFound, CodeNotFound, "问题不存在")
+		return
+	} else if err != nil {
+		fail(c, http.StatusInternalServerError, CodeInternal, "获取回答失败")
+		return
+	}
+
+	answers, err := models.GetAnswersByQuestionID(id)
+	if err != nil {
+		fail(c, http.StatusInternalServerError, CodeInternal, "获取回答失败")
+		return
+	}
+	if answers == nil {
+		answers = []*models.Answer{}
+	}
+	respond(c, http.StatusOK, answers)
+}
+
+// 提问
+func createQuestion(c *gin.Context) {
+	userID := c.GetInt("user_id")
+
+	var req createQuestionRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	// 提交前检查是否存在高度相似的问题
+	if !req.IgnoreSimilar {
+		similar, err := models.FindSimilarQuestions(req.Title, req.Content, 0, 5, models.DuplicateWarningScore)
+		if err == nil && len(similar) > 0 {
+			failWithDetails(c, http.StatusConflict, CodeConflict, "发现相似的问题，确认不重复后请设置ignore_similar重新提交", similar)
+			return
+		}
+	}
+
+	user, err := models.GetUserByID(userID)
+	if err != nil {
+		fail(c, http.StatusInternalServerError, CodeInternal, "获取用户信息失败")
+		return
+	}
+	if user.Points < req.Reward {
+		fail(c, http.StatusUnprocessableEntity, CodeValidationFailed, "积分不足，无法设置悬赏")
+		return
+	}
+
+	questionID, err := models.CreateQuestion(req.Title, req.Content, req.CategoryID, userID, req.Tags, req.Reward)
+	if err != nil {
+		fail(c, http.StatusInternalServerError, CodeInternal, "发布问题失败")
+		return
+	}
+	if req.Reward > 0 {
+		if err := models.UpdateUserPoints(userID, -req.Reward); err != nil {
+			fail(c, http.StatusInternalServerError, CodeInternal, "扣除积分失败")
+			return
+		}
+	}
+
+	respond(c, http.StatusCreated, createdID{ID: questionID})
+}
+
+// 回答问题
+func createAnswer(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req createAnswerRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	answerID, err := models.CreateAnswer(id, c.GetInt("user_id"), req.Content)
+	switch err {
+	case nil:
+		respond(c, http.StatusCreated, createdID{ID: answerID})
+	case sql.ErrNoRows:
+		fail(c, http.StatusNotFound, CodeNotFound, "问题不存在")
+	case models.ErrQuestionClosed, models.ErrQuestionLocked, models.ErrQuestionProtected, models.ErrBlocked:
+		fail(c, http.StatusForbidden, CodeForbidden, err.Error())
+	default:
+		fail(c, http.StatusInternalServerError, CodeInternal, "回答失败")
+	}
+}
@@ -0,0 +1,73 @@
+package v1
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"aiforum/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 资料的发布时间筛选
+var resourcePeriods = []string{"all", "today", "week", "month", "year"}
+
+// 学习资料列表
+func listResources(c *gin.Context) {
+	page, ok := pageParams(c)
+	if !ok {
+		return
+	}
+	period, ok := enumQuery(c, "period", "all", resourcePeriods)
+	if !ok {
+		return
+	}
+	if period == "all" {
+		period = ""
+	}
+	minRating, ok := intQuery(c, "min_rating")
+	if !ok {
+		return
+	}
+	rating := ""
+	if minRating > 0 {
+		rating = strconv.Itoa(minRating)
+	}
+
+	keyword, resourceType, level, category := c.Query("q"), c.Query("type"), c.Query("level"), c.Query("category")
+	resources, info, err := models.GetLearningResources(keyword, resourceType, level, period, rating, category, c.GetInt("user_id"), page.query)
+	if err != nil {
+		failList(c, err, "获取资料列表失败")
+		return
+	}
+	meta := page.meta(info)
+	if page.total {
+		total, err := models.CountLearningResources(keyword, resourceType, level, period, rating, category, c.GetInt("user_id"))
+		if err != nil {
+			fail(c, http.StatusInternalServerError, CodeInternal, "获取资料列表失败")
+			return
+		}
+		meta.Total = &total
+	}
+	respondList(c, resources, meta)
+}
+
+// 学习资料详情
+func getResource(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		return
+	}
+
+	resource, err := models.GetLearningResourceByID(strconv.Itoa(id))
+	if err == sql.ErrNoRows {
+		fail(c, http.StatusNotFound, CodeNotFound, "资料不存在")
+		return
+	}
+	if err != nil {
+		fail(c, http.StatusInternalServerError, CodeInternal, "获取资料失败")
+		return
+	}
+	respond(c, http.StatusOK, resource)
+}
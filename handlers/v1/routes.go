@@ -0,0 +1,163 @@
+package v1
+
+import (
+	"net/http"
+
+	"aiforum/middleware"
+	"aiforum/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 接口的认证要求
+type authLevel int
+
+const (
+	authNone     authLevel = iota
+	authOptional           // 登录后返回与当前用户相关的字段
+	authRequired
+)
+
+// 查询参数
+type queryParam struct {
+	name        string
+	kind        string // integer、string或boolean
+	description string
+	enum        []string
+}
+
+// 接口声明
+type route struct {
+	method  string
+	path    string
+	handler gin.HandlerFunc
+	auth    authLevel
+	scopes  []string // 个人访问令牌需要的权限范围，未声明时不接受个人访问令牌
+	tag     string
+	summary string
+	query   []queryParam
+	body    interface{} // 请求体类型
+	result  interface{} // data字段的类型，分页列表为元素类型
+	list    bool        // 返回分页列表
+	status  int         // 成功时的状态码，默认200
+	errors  []int       // 除通用错误外可能返回的状态码
+}
+
+// /api/v1的接口，同时用于注册路由和生成OpenAPI文档
+var routes = []route{
+	{
+		method: http.MethodGet, path: "/questions", handler: listQuestions,
+		tag: "questions", summary: "问题列表",
+		query: []queryParam{
+			{name: "category_id", kind: "integer", description: "按分类筛选"},
+			{name: "sort", kind: "string", description: "排序方式", enum: questionSorts},
+		},
+		result: models.Question{}, list: true,
+	},
+	{
+		method: http.MethodPost, path: "/questions", handler: createQuestion,
+		auth: authRequired, scopes: []string{models.ScopeWriteQuestions},
+		tag: "questions", summary: "提问",
+		body: createQuestionRequest{}, result: createdID{}, status: http.StatusCreated,
+		errors: []int{http.StatusConflict},
+	},
+	{
+		method: http.MethodGet, path: "/questions/:id", handler: getQuestion,
+		tag: "questions", summary: "问题详情",
+		result: models.Question{},
+	},
+	{
+		method: http.MethodGet, path: "/questions/:id/answers", handler: listAnswers,
+		tag: "questions", summary: "问题的回答（已采纳的回答在前）",
+		result: []models.Answer{},
+	},
+	{
+		method: http.MethodPost, path: "/questions/:id/answers", handler: createAnswer,
+		auth: authRequired, scopes: []string{models.ScopeWriteQuestions},
+		tag: "questions", summary: "回答问题",
+		body: createAnswerRequest{}, result: createdID{}, status: http.StatusCreated,
+	},
+	{
+		method: http.MethodGet, path: "/articles", handler: listArticles,
+		auth: authOptional, scopes: []string{models.ScopeRead},
+		tag: "articles", summary: "技术文章列表",
+		query: []queryParam{
+			{name: "q", kind: "string", description: "搜索标题、内容和标签"},
+			{name: "category", kind: "string", description: "按分类筛选"},
+			{name: "topic", kind: "string", description: "按专题slug筛选"},
+			{name: "sort", kind: "string", description: "排序方式", enum: articleSorts},
+		},
+		result: models.TechArticle{}, list: true,
+	},
+	{
+		method: http.MethodPost, path: "/articles", handler: createArticle,
+		auth: authRequired, scopes: []string{models.ScopeWriteArticles},
+		tag: "articles", summary: "发布技术文章",
+		body: createArticleRequest{}, result: createdID{}, status: http.StatusCreated,
+	},
+	{
+		method: http.MethodGet, path: "/articles/:id", handler: getArticle,
+		auth: authOptional, scopes: []string{models.ScopeRead},
+		tag: "articles", summary: "技术文章详情",
+		result: models.TechArticle{},
+	},
+	{
+		method: http.MethodGet, path: "/resources", handler: listResources,
+		auth: authOptional, scopes: []string{models.ScopeRead},
+		tag: "resources", summary: "学习资料列表",
+		query: []queryParam{
+			{name: "q", kind: "string", description: "搜索标题、简介和标签"},
+			{name: "type", kind: "string", description: "按资料类型筛选"},
+			{name: "level", kind: "string", description: "按难度筛选"},
+			{name: "category", kind: "string", description: "按分类筛选"},
+			{name: "period", kind: "string", description: "按发布时间筛选", enum: resourcePeriods},
+			{name: "min_rating", kind: "integer", description: "最低评分"},
+		},
+		result: models.LearningResource{}, list: true,
+	},
+	{
+		method: http.MethodGet, path: "/resources/:id", handler: getResource,
+		tag: "resources", summary: "学习资料详情",
+		result: models.LearningResource{},
+	},
+	{
+		method: http.MethodGet, path: "/categories", handler: listCategories,
+		tag: "meta", summary: "问题分类",
+		result: []models.Category{},
+	},
+	{
+		method: http.MethodGet, path: "/tags", handler: listTags,
+		tag: "meta", summary: "标签（按使用次数排序）",
+		result: []models.Tag{},
+	},
+	{
+		method: http.MethodGet, path: "/users/:id", handler: getUser,
+		auth: authOptional, scopes: []string{models.ScopeRead},
+		tag: "users", summary: "用户公开资料（私密主页只返回基本信息）",
+		result: models.PublicProfile{},
+	},
+	{
+		method: http.MethodGet, path: "/me", handler: getMe,
+		auth: authRequired, scopes: []string{models.ScopeRead},
+		tag: "users", summary: "当前用户",
+		result: models.User{},
+	},
+}
+
+// 注册/api/v1路由
+func Register(group *gin.RouterGroup) {
+	group.Use(middleware.AbortWith(abortAuth))
+
+	for _, rt := range routes {
+		var chain []gin.HandlerFunc
+		switch rt.auth {
+		case authOptional:
+			chain = append(chain, middleware.OptionalAuthMiddleware(rt.scopes...))
+		case authRequired:
+			chain = append(chain, middleware.AuthMiddleware(rt.scopes...))
+		}
+		group.Handle(rt.method, rt.path, append(chain, rt.handler)...)
+	}
+
+	group.GET("/openapi.json", ServeOpenAPI)
+}
@@ -0,0 +1,69 @@
+package v1
+
+import (
+	"database/sql"
+	"net/http"
+
+	"aiforum/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 当前用户
+func getMe(c *gin.Context) {
+	user, err := models.GetUserByID(c.GetInt("user_id"))
+	if err == sql.ErrNoRows {
+		fail(c, http.StatusNotFound, CodeNotFound, "用户不存在")
+		return
+	}
+	if err != nil {
+		fail(c, http.StatusInternalServerError, CodeInternal, "获取用户信息失败")
+		return
+	}
+	respond(c, http.StatusOK, user)
+}
+
+// 用户公开资料
+func getUser(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		return
+	}
+
+	profile, err := models.GetPublicProfile(id, c.GetInt("user_id"))
+	if err == sql.ErrNoRows {
+		fail(c, http.StatusNotFound, CodeNotFound, "用户不存在")
+		return
+	}
+	if err != nil {
+		fail(c, http.StatusInternalServerError, CodeInternal, "获取用户资料失败")
+		return
+	}
+	respond(c, http.StatusOK, profile)
+}
+
+// 问题分类
+func listCategories(c *gin.Context) {
+	categories, err := models.GetCategories()
+	if err != nil {
+		fail(c, http.StatusInternalServerError, CodeInternal, "获取分类失败")
+		return
+	}
+	if categories == nil {
+		categories = []*models.Category{}
+	}
+	respond(c, http.StatusOK, categories)
+}
+
+// 标签
+func listTags(c *gin.Context) {
+	tags, err := models.GetTags()
+	if err != nil {
+		fail(c, http.StatusInternalServerError, CodeInternal, "获取标签失败")
+		return
+	}
+	if tags == nil {
+		tags = []*models.Tag{}
+	}
+	respond(c, http.StatusOK, tags)
+}
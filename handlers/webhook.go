@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"aiforum/models"
+	"aiforum/webhooks"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 投递记录每次返回的条数
+const webhookDeliveryLogSize = 50
+
+// 创建和修改订阅的请求
+type webhookRequest struct {
+	URL    string   `json:"url" binding:"required"`
+	Events []string `json:"events" binding:"required"`
+	Tags   []string `json:"tags"`
+	Active *bool    `json:"active"`
+}
+
+// 个人Webhook订阅
+func GetWebhooks(c *gin.Context)          { listWebhooks(c, c.GetInt("user_id")) }
+func CreateWebhook(c *gin.Context)        { createWebhook(c, c.GetInt("user_id")) }
+func UpdateWebhook(c *gin.Context)        { updateWebhook(c, c.GetInt("user_id")) }
+func DeleteWebhook(c *gin.Context)        { deleteWebhook(c, c.GetInt("user_id")) }
+func GetWebhookDeliveries(c *gin.Context) { listWebhookDeliveries(c, c.GetInt("user_id")) }
+func TestWebhook(c *gin.Context)          { testWebhook(c, c.GetInt("user_id")) }
+
+// 全站Webhook订阅（管理员）
+func AdminGetWebhooks(c *gin.Context)          { listWebhooks(c, 0) }
+func AdminCreateWebhook(c *gin.Context)        { createWebhook(c, 0) }
+func AdminUpdateWebhook(c *gin.Context)        { updateWebhook(c, 0) }
+func AdminDeleteWebhook(c *gin.Context)        { deleteWebhook(c, 0) }
+func AdminGetWebhookDeliveries(c *gin.Context) { listWebhookDeliveries(c, 0) }
+func AdminTestWebhook(c *gin.Context)          { testWebhook(c, 0) }
+
+func listWebhooks(c *gin.Context, ownerID int) {
+	hooks, err := models.GetWebhooks(ownerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "获取订阅失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"webhooks": hooks,
+		"events":   models.WebhookEvents,
+	})
+}
+
+// 签名密钥只在创建时返回一次
+func createWebhook(c *gin.Context, ownerID int) {
+	var req webhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "请填写地址并选择要订阅的事件",
+		})
+		return
+	}
+
+	secret, err := webhooks.GenerateSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "创建订阅失败",
+		})
+		return
+	}
+
+	hook, err := models.CreateWebhook(ownerID, strings.TrimSpace(req.URL), secret, req.Events, req.Tags)
+	if err == models.ErrInvalidWebhookURL || err == models.ErrInvalidWebhookEvents || err == models.ErrTooManyWebhooks {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "创建订阅失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "订阅已创建，请立即保存签名密钥，关闭后将无法再次查看",
+		"secret":  secret,
+		"webhook": hook,
+	})
+}
+
+func updateWebhook(c *gin.Context, ownerID int) {
+	webhookID, ok := webhookIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req webhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "请填写地址并选择要订阅的事件",
+		})
+		return
+	}
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+
+	hook, err := models.UpdateWebhook(ownerID, webhookID, strings.TrimSpace(req.URL), req.Events, req.Tags, active)
+	if err == models.ErrInvalidWebhookURL || err == models.ErrInvalidWebhookEvents {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	if err == sql.ErrNoRows {
+		webhookNotFound(c)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "修改订阅失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "订阅已更新",
+		"webhook": hook,
+	})
+}
+
+func deleteWebhook(c *gin.Context, ownerID int) {
+	webhookID, ok := webhookIDParam(c)
+	if !ok {
+		return
+	}
+
+	err := models.DeleteWebhook(ownerID, webhookID)
+	if err == sql.ErrNoRows {
+		webhookNotFound(c)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "删除订阅失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "订阅已删除",
+	})
+}
+
+// 最近的投递记录
+func listWebhookDeliveries(c *gin.Context, ownerID int) {
+	hook, ok := ownedWebhook(c, ownerID)
+	if !ok {
+		return
+	}
+
+	deliveries, err := models.GetWebhookDeliveries(hook.ID, webhookDeliveryLogSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "获取投递记录失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"deliveries": deliveries,
+	})
+}
+
+// 立即发送一条ping事件并返回投递结果，失败时和普通投递一样进入重试
+func testWebhook(c *gin.Context, ownerID int) {
+	hook, ok := ownedWebhook(c, ownerID)
+	if !ok {
+		return
+	}
+
+	delivery, err := models.CreateWebhookPing(hook)
+	if err == nil {
+		err = webhooks.Deliver(delivery)
+	}
+	if err == nil {
+		delivery, err = models.GetWebhookDelivery(delivery.ID)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "测试投递失败",
+		})
+		return
+	}
+
+	message := "测试投递成功"
+	if delivery.Status != models.WebhookDeliveryDelivered {
+		message = "测试投递失败：" + delivery.LastError
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"message":  message,
+		"delivery": delivery,
+	})
+}
+
+func webhookIDParam(c *gin.Context) (int, bool) {
+	webhookID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的订阅ID",
+		})
+		return 0, false
+	}
+	return webhookID, true
+}
+
+func ownedWebhook(c *gin.Context, ownerID int) (*models.Webhook, bool) {
+	webhookID, ok := webhookIDParam(c)
+	if !ok {
+		return nil, false
+	}
+
+	hook, err := models.GetWebhook(ownerID, webhookID)
+	if err == sql.ErrNoRows {
+		webhookNotFound(c)
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "获取订阅失败",
+		})
+		return nil, false
+	}
+	return hook, true
+}
+
+func webhookNotFound(c *gin.Context) {
+	c.JSON(http.StatusNotFound, gin.H{
+		"success": false,
+		"error":   "订阅不存在",
+	})
+}
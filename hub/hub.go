@@ -0,0 +1,74 @@
+package hub
+
+import (
+	"strconv"
+	"sync"
+)
+
+// 推送事件
+type Event struct {
+	Topic string      `json:"topic"`
+	Type  string      `json:"type"`
+	Data  interface{} `json:"data"`
+}
+
+// 消息代理接口（默认使用进程内实现，可替换为Redis等外部代理）
+type Broker interface {
+	Publish(event Event)
+	Subscribe(topics ...string) *Subscription
+}
+
+// 订阅
+type Subscription struct {
+	C      <-chan Event
+	cancel func()
+	once   sync.Once
+}
+
+// 取消订阅
+func (s *Subscription) Close() {
+	s.once.Do(s.cancel)
+}
+
+// 创建订阅（供Broker实现使用，cancel负责关闭通道）
+func NewSubscription(c <-chan Event, cancel func()) *Subscription {
+	return &Subscription{C: c, cancel: cancel}
+}
+
+var (
+	broker      Broker = NewMemoryBroker()
+	brokerMutex sync.RWMutex
+)
+
+// 替换全局消息代理
+func SetBroker(b Broker) {
+	brokerMutex.Lock()
+	broker = b
+	brokerMutex.Unlock()
+}
+
+func current() Broker {
+	brokerMutex.RLock()
+	defer brokerMutex.RUnlock()
+	return broker
+}
+
+// 发布事件
+func Publish(topic, eventType string, data interface{}) {
+	current().Publish(Event{Topic: topic, Type: eventType, Data: data})
+}
+
+// 订阅一个或多个主题
+func Subscribe(topics ...string) *Subscription {
+	return current().Subscribe(topics...)
+}
+
+// 用户私有主题
+func UserTopic(userID int) string {
+	return "user:" + strconv.Itoa(userID)
+}
+
+// 问题主题
+func QuestionTopic(questionID int) string {
+	return "question:" + strconv.Itoa(questionID)
+}
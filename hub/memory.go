@@ -0,0 +1,56 @@
+package hub
+
+import "sync"
+
+// 每个订阅者的缓冲区大小，消费过慢时丢弃新事件
+const subscriberBuffer = 32
+
+// 进程内消息代理
+type MemoryBroker struct {
+	mu     sync.RWMutex
+	topics map[string]map[chan Event]struct{}
+}
+
+// 创建进程内消息代理
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{topics: make(map[string]map[chan Event]struct{})}
+}
+
+// 发布事件（不阻塞发布者）
+func (b *MemoryBroker) Publish(event Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for ch := range b.topics[event.Topic] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// 订阅主题
+func (b *MemoryBroker) Subscribe(topics ...string) *Subscription {
+	ch := make(chan Event, subscriberBuffer)
+
+	b.mu.Lock()
+	for _, topic := range topics {
+		if b.topics[topic] == nil {
+			b.topics[topic] = make(map[chan Event]struct{})
+		}
+		b.topics[topic][ch] = struct{}{}
+	}
+	b.mu.Unlock()
+
+	return NewSubscription(ch, func() {
+		b.mu.Lock()
+		for _, topic := range topics {
+			delete(b.topics[topic], ch)
+			if len(b.topics[topic]) == 0 {
+				delete(b.topics, topic)
+			}
+		}
+		b.mu.Unlock()
+		close(ch)
+	})
+}
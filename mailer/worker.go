@@ -0,0 +1,217 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"aiforum/config"
+	"aiforum/models"
+	"aiforum/utils"
+)
+
+// 邮件任务的轮询间隔
+const workerInterval = 30 * time.Second
+
+// 每轮处理的数量上限
+const (
+	notificationBatchSize = 200
+	digestBatchSize       = 50
+	deliveryBatchSize     = 20
+)
+
+// 摘要中每类内容的条数
+const digestSectionSize = 10
+
+// 摘要频率的中文名称
+var digestPeriodNames = map[string]string{
+	models.EmailDigestDaily:  "每日",
+	models.EmailDigestWeekly: "每周",
+}
+
+// 邮件模板数据
+type notificationData struct {
+	Username       string
+	SiteURL        string
+	UnsubscribeURL string
+	Messages       []*models.UserMessage
+}
+
+type digestData struct {
+	Username       string
+	SiteURL        string
+	UnsubscribeURL string
+	Period         string
+	Answers        []*models.DigestAnswer
+	Feed           []*models.FeedItem
+	Articles       []*models.DigestArticle
+}
+
+// 启动后台邮件任务：生成即时通知和摘要邮件，并投递发件箱
+func StartWorker(sender Sender) {
+	go func() {
+		ticker := time.NewTicker(workerInterval)
+		defer ticker.Stop()
+
+		for {
+			RunOnce(sender)
+			<-ticker.C
+		}
+	}()
+}
+
+// 执行一轮邮件任务
+func RunOnce(sender Sender) {
+	if err := queueNotificationMails(); err != nil {
+		log.Printf("生成通知邮件失败: %v", err)
+	}
+	if err := queueDigestMails(); err != nil {
+		log.Printf("生成摘要邮件失败: %v", err)
+	}
+	if err := deliverOutbox(sender); err != nil {
+		log.Printf("投递邮件失败: %v", err)
+	}
+}
+
+// 退订链接
+func UnsubscribeURL(userID int) string {
+	return config.AppConfig.SiteURL + "/email/unsubscribe?token=" + url.QueryEscape(utils.GenerateUnsubscribeToken(userID))
+}
+
+// 为开启即时通知的用户生成邮件
+func queueNotificationMails() error {
+	batches, err := models.GetPendingEmailNotifications(notificationBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, batch := range batches {
+		r := batch.Recipient
+		text, html, err := render("notification", notificationData{
+			Username:       r.Username,
+			SiteURL:        config.AppConfig.SiteURL,
+			UnsubscribeURL: UnsubscribeURL(r.UserID),
+			Messages:       batch.Messages,
+		})
+		if err != nil {
+			return err
+		}
+
+		subject := batch.Messages[0].Title
+		if len(batch.Messages) > 1 {
+			subject = fmt.Sprintf("你有 %d 条新通知", len(batch.Messages))
+		}
+
+		err = models.EnqueueMail(&models.OutboxMail{
+			UserID:   r.UserID,
+			ToEmail:  r.Email,
+			Subject:  "[AI论坛] " + subject,
+			BodyText: text,
+			BodyHTML: html,
+			Kind:     "notification",
+		})
+		if err != nil {
+			return err
+		}
+
+		ids := make([]int, 0, len(batch.Messages))
+		for _, message := range batch.Messages {
+			ids = append(ids, message.ID)
+		}
+		if err := models.MarkMessagesEmailed(ids); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 为到期的用户生成每日/每周摘要
+func queueDigestMails() error {
+	recipients, err := models.GetDueDigestRecipients(digestBatchSize)
+	if err != nil || len(recipients) == 0 {
+		return err
+	}
+
+	articles, err := models.GetWeeklyTopArticles(5)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range recipients {
+		now := time.Now()
+		since := r.DigestSince()
+
+		data := digestData{
+			Username:       r.Username,
+			SiteURL:        config.AppConfig.SiteURL,
+			UnsubscribeURL: UnsubscribeURL(r.UserID),
+			Period:         digestPeriodNames[r.Digest],
+		}
+		if data.Answers, err = models.GetDigestAnswers(r.UserID, since, digestSectionSize); err != nil {
+			return err
+		}
+		if data.Feed, err = models.GetDigestFeed(r.UserID, since, digestSectionSize); err != nil {
+			return err
+		}
+		// 热门文章只放在周报里，避免每日摘要重复
+		if r.Digest == models.EmailDigestWeekly {
+			data.Articles = articles
+		}
+
+		// 没有新内容时不打扰用户，只推进统计起点
+		if len(data.Answers) > 0 || len(data.Feed) > 0 || len(data.Articles) > 0 {
+			text, html, err := render("digest", data)
+			if err != nil {
+				return err
+			}
+			err = models.EnqueueMail(&models.OutboxMail{
+				UserID:   r.UserID,
+				ToEmail:  r.Email,
+				Subject:  "[AI论坛] " + data.Period + "摘要",
+				BodyText: text,
+				BodyHTML: html,
+				Kind:     "digest",
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := models.MarkDigestSent(r.UserID, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 投递发件箱中的邮件
+func deliverOutbox(sender Sender) error {
+	mails, err := models.ClaimOutboxMails(deliveryBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, mail := range mails {
+		msg := &Message{
+			To:      mail.ToEmail,
+			Subject: mail.Subject,
+			Text:    mail.BodyText,
+			HTML:    mail.BodyHTML,
+		}
+		if mail.UserID > 0 {
+			msg.UnsubscribeURL = UnsubscribeURL(mail.UserID)
+		}
+
+		if sendErr := sender.Send(msg); sendErr != nil {
+			log.Printf("发送邮件失败 (id=%d, to=%s): %v", mail.ID, mail.ToEmail, sendErr)
+			err = models.MarkMailFailed(mail, sendErr)
+		} else {
+			err = models.MarkMailSent(mail.ID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,11 +1,22 @@
 package main
 
 import (
+	"html/template"
 	"log"
+	"strings"
 
+	"aiforum/accounts"
+	"aiforum/config"
+	"aiforum/exporter"
+	"aiforum/graph"
 	"aiforum/handlers"
+	v1 "aiforum/handlers/v1"
+	"aiforum/mailer"
 	"aiforum/middleware"
 	"aiforum/models"
+	"aiforum/oauth"
+	"aiforum/trash"
+	"aiforum/webhooks"
 
 	"github.com/gin-gonic/gin"
 )
@@ -17,6 +28,28 @@ func main() {
 		log.Fatal("数据库初始化失败:", err)
 	}
 
+	// 注册第三方登录方式
+	oauth.Init()
+
+	// 启动邮件投递
+	if config.AppConfig.SMTPHost != "" {
+		mailer.StartWorker(mailer.NewSMTPSender())
+	} else {
+		log.Println("未配置SMTP_HOST，邮件投递未启动")
+	}
+
+	// 启动数据导出任务
+	exporter.StartWorker()
+
+	// 启动账户注销任务
+	accounts.StartWorker()
+
+	// 启动回收站清理任务
+	trash.StartWorker()
+
+	// 启动Webhook投递任务
+	webhooks.StartWorker()
+
 	// 设置Gin模式
 	gin.SetMode(gin.ReleaseMode)
 
@@ -26,6 +59,16 @@ func main() {
 	// 静态文件服务
 	r.Static("/static", "./static")
 	r.Static("/images", "./images")
+
+	// 模板函数
+	r.SetFuncMap(template.FuncMap{
+		"add":      func(a, b int) int { return a + b },
+		"subtract": func(a, b int) int { return a - b },
+		"seq":      sequence,
+		"sequence": sequence,
+		"split":    strings.Split,
+		"mentions": models.RenderMentions,
+	})
 	r.LoadHTMLGlob("templates/*")
 
 	// 设置路由
@@ -36,9 +79,18 @@ func main() {
 	log.Fatal(r.Run(":8080"))
 }
 
+// 生成1..n的序列（模板中用于分页和评分星级）
+func sequence(n int) []int {
+	s := make([]int, 0, n)
+	for i := 1; i <= n; i++ {
+		s = append(s, i)
+	}
+	return s
+}
+
 func setupRoutes(r *gin.Engine) {
 	// 首页
-	r.GET("/", handlers.HomePage)
+	r.GET("/", middleware.OptionalAuthMiddleware(), handlers.HomePage)
 
 	// 用户认证相关路由
 	auth := r.Group("/auth")
@@ -48,17 +100,24 @@ func setupRoutes(r *gin.Engine) {
 		auth.GET("/login", handlers.LoginPage)
 		auth.POST("/login", handlers.Login)
 		auth.GET("/logout", handlers.Logout)
+		auth.GET("/mfa", handlers.MFAPage)
+		auth.POST("/mfa/verify", handlers.VerifyMFALogin)
+		auth.GET("/oauth/providers", handlers.OAuthProviders)
+		auth.GET("/oauth/complete", handlers.OAuthCompletePage)
+		auth.POST("/oauth/complete", handlers.OAuthComplete)
+		auth.GET("/oauth/:provider", handlers.OAuthLogin)
+		auth.GET("/oauth/:provider/callback", handlers.OAuthCallback)
 	}
 
 	// 问答相关路由
 	qa := r.Group("/qa")
 	{
-		qa.GET("", handlers.QAPage)
-		qa.GET("/search", handlers.AdvancedSearch)
+		qa.GET("", middleware.OptionalAuthMiddleware(), handlers.QAPage)
+		qa.GET("/search", middleware.OptionalAuthMiddleware(), handlers.AdvancedSearch)
 		qa.GET("/ask", handlers.AskQuestionPage)
-		qa.POST("/ask", handlers.AskQuestion)
-		qa.GET("/:id", handlers.ViewQuestion)
-		qa.POST("/:id/answer", handlers.AnswerQuestion)
+		qa.POST("/ask", middleware.AuthMiddleware(), handlers.AskQuestion)
+		qa.GET("/:id", middleware.OptionalAuthMiddleware(), handlers.ViewQuestion)
+		qa.POST("/:id/answer", middleware.AuthMiddleware(), handlers.AnswerQuestion)
 		qa.POST("/answer/:answer_id/accept", handlers.AcceptAnswer)
 		qa.POST("/answer/:answer_id/like", handlers.LikeAnswer)
 	}
@@ -66,13 +125,21 @@ func setupRoutes(r *gin.Engine) {
 	// 技术分享相关路由
 	techShare := r.Group("/tech-share")
 	{
-		techShare.GET("", handlers.TechSharePage)
+		techShare.GET("", middleware.OptionalAuthMiddleware(), handlers.TechSharePage)
 		techShare.GET("/:id", handlers.TechShareDetailPage)
 		techShare.GET("/topic/:slug", handlers.TopicPage)
 		techShare.GET("/publish", handlers.PublishTechSharePage)
 		techShare.POST("/publish", handlers.PublishTechShare)
 	}
 
+	// 学习资料相关路由
+	learningResources := r.Group("/learning-resources")
+	{
+		learningResources.GET("", middleware.OptionalAuthMiddleware(), handlers.LearningResourcesPage)
+		learningResources.GET("/category/:category", middleware.OptionalAuthMiddleware(), handlers.CategoryPage)
+		learningResources.GET("/:id", middleware.OptionalAuthMiddleware(), handlers.LearningResourceDetailPage)
+	}
+
 	// API路由
 	api := r.Group("/api")
 	{
@@ -80,33 +147,83 @@ func setupRoutes(r *gin.Engine) {
 		api.GET("/posts/:id", handlers.GetPost)
 		api.GET("/categories", handlers.GetCategories)
 		api.GET("/tags", handlers.GetTags)
+		api.GET("/tags/:name", handlers.GetTagDetail)
+		api.GET("/feed", middleware.AuthMiddleware(models.ScopeRead), handlers.GetFeed)
+		api.POST("/feed/seen", middleware.AuthMiddleware(), handlers.MarkFeedSeen)
+		api.GET("/users/suggest", middleware.OptionalAuthMiddleware(), handlers.SuggestUsers)
+		api.GET("/users/:id", middleware.OptionalAuthMiddleware(models.ScopeRead), handlers.GetPublicProfile)
+		api.GET("/users/:id/collections", handlers.GetPublicCollections)
+		api.GET("/collections/:id", middleware.OptionalAuthMiddleware(models.ScopeRead), handlers.GetCollection)
+		
+		// 搜索API
+		api.GET("/search/suggest", handlers.SearchSuggest)
+		api.GET("/search/trending", handlers.GetTrendingSearches)
 		
 		// 问答API
-		api.POST("/answers", handlers.AnswerQuestion)
+		api.POST("/answers", middleware.AuthMiddleware(models.ScopeWriteQuestions), handlers.AnswerQuestionLegacy) // 已弃用，见README
 		api.POST("/answers/:answer_id/accept", handlers.AcceptAnswer)
 		api.POST("/answers/:answer_id/like", handlers.LikeAnswer)
-		api.POST("/questions/:id/favorite", handlers.FavoriteQuestion)
+		api.POST("/questions/:id/favorite", middleware.AuthMiddleware(), handlers.FavoriteQuestion)
 		api.POST("/questions/:id/report", handlers.ReportQuestion)
+		api.GET("/questions/similar", handlers.GetSimilarQuestions)
+		api.POST("/questions/:id/duplicate", middleware.AuthMiddleware(), middleware.ModeratorMiddleware(), handlers.MarkQuestionDuplicate)
+		api.POST("/questions/:id/close", middleware.AuthMiddleware(), middleware.ModeratorMiddleware(), handlers.CloseQuestion)
+		api.POST("/questions/:id/reopen", middleware.AuthMiddleware(), middleware.ModeratorMiddleware(), handlers.ReopenQuestion)
+		api.POST("/questions/:id/reopen-vote", middleware.AuthMiddleware(), handlers.VoteReopenQuestion)
+		api.POST("/questions/:id/lock", middleware.AuthMiddleware(), middleware.ModeratorMiddleware(), handlers.LockQuestion)
+		api.POST("/questions/:id/unlock", middleware.AuthMiddleware(), middleware.ModeratorMiddleware(), handlers.UnlockQuestion)
+		api.POST("/questions/:id/protect", middleware.AuthMiddleware(), middleware.ModeratorMiddleware(), handlers.ProtectQuestion)
+		api.POST("/questions/:id/unprotect", middleware.AuthMiddleware(), middleware.ModeratorMiddleware(), handlers.UnprotectQuestion)
+		api.POST("/questions/:id/wiki", middleware.AuthMiddleware(), handlers.ConvertQuestionToWiki)
+		api.POST("/moderation/:type/:id/undelete", middleware.AuthMiddleware(), middleware.ModeratorMiddleware(), handlers.UndeleteContent)
 		
+		// 可以使用个人访问令牌发布内容的接口
+		api.POST("/questions", middleware.AuthMiddleware(models.ScopeWriteQuestions), handlers.AskQuestion)
+		api.POST("/questions/:id/answers", middleware.AuthMiddleware(models.ScopeWriteQuestions), handlers.AnswerQuestion)
+		api.POST("/resources", middleware.AuthMiddleware(models.ScopeUploadResources), handlers.UploadLearningResource)
+
+		// 学习资料评论API
+		api.POST("/learning-resources/:id/rate", middleware.AuthMiddleware(), handlers.RateLearningResource)
+		api.POST("/learning-resources/:id/comments", middleware.AuthMiddleware(), handlers.CommentLearningResource)
+
 		// 技术分享API
-		api.POST("/tech-share/publish", handlers.PublishTechShare)
+		api.POST("/tech-share/publish", middleware.AuthMiddleware(models.ScopeWriteArticles), handlers.PublishTechShare)
 		api.POST("/tech-share/:id/like", handlers.LikeTechArticle)
+		api.POST("/tech-share/:id/favorite", middleware.AuthMiddleware(), handlers.FavoriteArticle)
+		api.POST("/tech-share/:id/comments", middleware.AuthMiddleware(), handlers.CreateArticleComment)
 		api.POST("/authors/:author_id/follow", handlers.FollowAuthor)
 	}
 	
-	// 个人中心API路由
+	// 版本化的JSON API（统一响应格式，文档见/api/v1/openapi.json）
+	v1.Register(r.Group("/api/v1"))
+
+	// GraphQL（只读查询，个人访问令牌需要read权限）
+	r.GET("/graphql", middleware.OptionalAuthMiddleware(models.ScopeRead), graph.Handler)
+	r.POST("/graphql", middleware.OptionalAuthMiddleware(models.ScopeRead), graph.Handler)
+
+	// 个人中心中可以用带read权限的个人访问令牌读取的接口
+	userRead := r.Group("/api/user")
+	userRead.Use(middleware.AuthMiddleware(models.ScopeRead))
+	{
+		userRead.GET("/activity", handlers.GetUserActivity)
+		userRead.GET("/questions", handlers.GetUserQuestions)
+		userRead.GET("/answers", handlers.GetUserAnswers)
+		userRead.GET("/shares", handlers.GetUserShares)
+		userRead.GET("/resources", handlers.GetUserResources)
+		userRead.GET("/favorites", handlers.GetUserFavorites)
+		userRead.GET("/collections", handlers.GetUserCollections)
+		userRead.GET("/following", handlers.GetUserFollowing)
+		userRead.GET("/followers", handlers.GetUserFollowers)
+		userRead.GET("/follow-suggestions", handlers.GetFollowSuggestions)
+		userRead.GET("/interests", handlers.GetUserInterests)
+		userRead.GET("/messages", handlers.GetUserMessages)
+	}
+
+	// 个人中心API路由（只接受登录token）
 	userAPI := r.Group("/api/user")
 	userAPI.Use(middleware.AuthMiddleware())
 	{
-		userAPI.GET("/activity", handlers.GetUserActivity)
-		userAPI.GET("/questions", handlers.GetUserQuestions)
-		userAPI.GET("/answers", handlers.GetUserAnswers)
-		userAPI.GET("/shares", handlers.GetUserShares)
-		userAPI.GET("/resources", handlers.GetUserResources)
-		userAPI.GET("/favorites", handlers.GetUserFavorites)
-		userAPI.GET("/following", handlers.GetUserFollowing)
-		userAPI.GET("/followers", handlers.GetUserFollowers)
-		userAPI.GET("/messages", handlers.GetUserMessages)
+		userAPI.GET("/stream", handlers.UserStream)
 		userAPI.POST("/avatar", handlers.UpdateUserAvatar)
 		userAPI.PUT("/profile", handlers.UpdateUserProfile)
 		userAPI.PUT("/password", handlers.ChangeUserPassword)
@@ -116,13 +233,99 @@ func setupRoutes(r *gin.Engine) {
 		userAPI.DELETE("/messages/:id", handlers.DeleteMessage)
 		userAPI.POST("/:id/follow", handlers.FollowUser)
 		userAPI.DELETE("/:id/unfollow", handlers.UnfollowUser)
+		userAPI.POST("/favorites", handlers.AddBookmark)
+		userAPI.PUT("/favorites/:id", handlers.UpdateBookmark)
 		userAPI.DELETE("/favorites/:id", handlers.RemoveFavorite)
+		userAPI.POST("/collections", handlers.CreateCollection)
+		userAPI.PUT("/collections/:id", handlers.UpdateCollection)
+		userAPI.DELETE("/collections/:id", handlers.DeleteCollection)
 		userAPI.DELETE("/questions/:id", handlers.DeleteUserQuestion)
 		userAPI.DELETE("/answers/:id", handlers.DeleteUserAnswer)
 		userAPI.DELETE("/shares/:id", handlers.DeleteUserShare)
 		userAPI.DELETE("/resources/:id", handlers.DeleteUserResource)
+		userAPI.GET("/search-history", handlers.GetSearchHistory)
+		userAPI.DELETE("/search-history", handlers.ClearSearchHistory)
+		userAPI.DELETE("/search-history/:id", handlers.DeleteSearchHistoryItem)
+		userAPI.POST("/interests", handlers.FollowInterest)
+		userAPI.DELETE("/interests/:type/:id", handlers.UnfollowInterest)
+		userAPI.GET("/account/deletion", handlers.GetAccountDeletion)
+		userAPI.POST("/account/deletion", handlers.RequestAccountDeletion)
+		userAPI.DELETE("/account/deletion", handlers.CancelAccountDeletion)
+		userAPI.GET("/export", handlers.GetLatestDataExport)
+		userAPI.POST("/export", handlers.RequestDataExport)
+		userAPI.GET("/export/:id", handlers.GetDataExport)
+		userAPI.GET("/export/:id/download", handlers.DownloadDataExport)
+		userAPI.GET("/trash", handlers.GetUserTrash)
+		userAPI.POST("/trash/:type/:id/restore", handlers.RestoreTrashItem)
+		userAPI.GET("/mfa", handlers.GetMFAStatus)
+		userAPI.POST("/mfa/enroll", handlers.EnrollMFA)
+		userAPI.POST("/mfa/enable", handlers.EnableMFA)
+		userAPI.POST("/mfa/disable", handlers.DisableMFA)
+		userAPI.POST("/mfa/recovery-codes", handlers.RegenerateRecoveryCodes)
+		userAPI.GET("/tokens", handlers.GetPersonalTokens)
+		userAPI.POST("/tokens", handlers.CreatePersonalToken)
+		userAPI.DELETE("/tokens/:id", handlers.RevokePersonalToken)
+		userAPI.GET("/webhooks", handlers.GetWebhooks)
+		userAPI.POST("/webhooks", handlers.CreateWebhook)
+		userAPI.PUT("/webhooks/:id", handlers.UpdateWebhook)
+		userAPI.DELETE("/webhooks/:id", handlers.DeleteWebhook)
+		userAPI.GET("/webhooks/:id/deliveries", handlers.GetWebhookDeliveries)
+		userAPI.POST("/webhooks/:id/test", handlers.TestWebhook)
+		userAPI.GET("/identities", handlers.GetLinkedAccounts)
+		userAPI.POST("/identities/:provider", handlers.LinkAccount)
+		userAPI.DELETE("/identities/:provider", handlers.UnlinkAccount)
+		userAPI.GET("/blocks", handlers.GetUserBlocks)
+		userAPI.POST("/blocks", handlers.AddUserBlock)
+		userAPI.DELETE("/blocks/:user_id", handlers.RemoveUserBlock)
+		userAPI.GET("/conversations", handlers.GetConversations)
+		userAPI.POST("/conversations", handlers.StartConversation)
+		userAPI.GET("/conversations/:id/messages", handlers.GetConversationMessages)
+		userAPI.POST("/conversations/:id/messages", handlers.SendDirectMessage)
+		userAPI.PUT("/conversations/:id/read", handlers.MarkConversationRead)
+		userAPI.POST("/conversations/:id/block", handlers.BlockConversationPeer)
+		userAPI.DELETE("/conversations/:id/block", handlers.UnblockConversationPeer)
+		userAPI.GET("/conversations/:id/attachments/:attachment_id", handlers.DownloadMessageAttachment)
 	}
 
+	// 管理后台路由
+	r.GET("/admin/tags", middleware.AuthMiddleware(), middleware.AdminMiddleware(), handlers.AdminTagsPage)
+
+	adminAPI := r.Group("/api/admin")
+	adminAPI.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+	{
+		adminAPI.PUT("/tags/:id", handlers.UpdateTag)
+		adminAPI.POST("/tags/:id/synonyms", handlers.AddTagSynonym)
+		adminAPI.DELETE("/tags/:id/synonyms/:synonym_id", handlers.DeleteTagSynonym)
+		adminAPI.POST("/tags/:id/merge", handlers.MergeTag)
+		adminAPI.DELETE("/users/:id", handlers.AdminDeleteUser)
+		adminAPI.GET("/webhooks", handlers.AdminGetWebhooks)
+		adminAPI.POST("/webhooks", handlers.AdminCreateWebhook)
+		adminAPI.PUT("/webhooks/:id", handlers.AdminUpdateWebhook)
+		adminAPI.DELETE("/webhooks/:id", handlers.AdminDeleteWebhook)
+		adminAPI.GET("/webhooks/:id/deliveries", handlers.AdminGetWebhookDeliveries)
+		adminAPI.POST("/webhooks/:id/test", handlers.AdminTestWebhook)
+	}
+
+	// 用户公开主页
+	r.GET("/u/:username", middleware.OptionalAuthMiddleware(), handlers.UserProfilePage)
+
+	// 订阅源，format为rss、atom或json
+	feeds := r.Group("/feeds/:format")
+	{
+		feeds.GET("/questions", handlers.QuestionsFeed)
+		feeds.GET("/questions/unsolved", handlers.UnsolvedQuestionsFeed)
+		feeds.GET("/articles", handlers.ArticlesFeed)
+		feeds.GET("/articles/category/:category", handlers.ArticleCategoryFeed)
+		feeds.GET("/articles/topic/:slug", handlers.TopicFeed)
+		feeds.GET("/authors/:username/articles", handlers.AuthorArticlesFeed)
+		feeds.GET("/resources", handlers.ResourcesFeed)
+		feeds.GET("/resources/category/:category", handlers.ResourceCategoryFeed)
+	}
+
+	// 邮件退订（令牌即凭证，无需登录）
+	r.GET("/email/unsubscribe", handlers.UnsubscribePage)
+	r.POST("/email/unsubscribe", handlers.Unsubscribe)
+
 	// 需要认证的路由
 	authenticated := r.Group("/")
 	authenticated.Use(middleware.AuthMiddleware())
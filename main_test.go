@@ -0,0 +1,54 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 订阅源、通知和搜索建议中生成的页面链接都必须有对应的路由
+func TestContentLinksResolve(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	setupRoutes(r)
+
+	links := []string{
+		"/qa/7",
+		"/tech-share/7",
+		"/u/alice",
+		"/learning-resources",
+		"/learning-resources/category/nlp",
+		"/learning-resources/7",
+	}
+	for _, link := range links {
+		if !routeExists(r.Routes(), "GET", link) {
+			t.Errorf("链接 %s 没有对应的路由", link)
+		}
+	}
+}
+
+func routeExists(routes gin.RoutesInfo, method, path string) bool {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for _, route := range routes {
+		if route.Method == method && matchRoute(strings.Split(strings.Trim(route.Path, "/"), "/"), segments) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchRoute(pattern, segments []string) bool {
+	for i, part := range pattern {
+		if strings.HasPrefix(part, "*") {
+			return true
+		}
+		if i >= len(segments) {
+			return false
+		}
+		if !strings.HasPrefix(part, ":") && part != segments[i] {
+			return false
+		}
+	}
+	return len(pattern) == len(segments)
+}
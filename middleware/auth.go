@@ -5,19 +5,20 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"aiforum/models"
 	"aiforum/utils"
 )
 
-// 认证中间件
-func AuthMiddleware() gin.HandlerFunc {
+// 认证中间件，同时接受JWT和个人访问令牌。
+// 个人访问令牌只能访问声明了权限范围的路由，未声明时拒绝
+func AuthMiddleware(scopes ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
 			// 尝试从Cookie获取token
 			token, err := c.Cookie("token")
 			if err != nil {
-				c.JSON(http.StatusUnauthorized, gin.H{"error": "未授权访问"})
-				c.Abort()
+				abort(c, http.StatusUnauthorized, "未授权访问")
 				return
 			}
 			authHeader = "Bearer " + token
@@ -25,19 +26,47 @@ func AuthMiddleware() gin.HandlerFunc {
 
 		// 检查Bearer前缀
 		if !strings.HasPrefix(authHeader, "Bearer ") {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的认证格式"})
-			c.Abort()
+			abort(c, http.StatusUnauthorized, "无效的认证格式")
 			return
 		}
 
 		// 提取token
 		token := strings.TrimPrefix(authHeader, "Bearer ")
 
+		// 个人访问令牌
+		if utils.IsPersonalToken(token) {
+			owner, err := models.AuthenticatePersonalToken(utils.HashPersonalToken(token))
+			if err == models.ErrPersonalTokenExpired {
+				abort(c, http.StatusUnauthorized, err.Error())
+				return
+			}
+			if err != nil {
+				abort(c, http.StatusUnauthorized, "无效的token")
+				return
+			}
+			if !tokenAllowed(owner, scopes) {
+				abort(c, http.StatusForbidden, "令牌没有执行该操作的权限")
+				return
+			}
+
+			c.Set("user_id", owner.UserID)
+			c.Set("username", owner.Username)
+			c.Set("token_scopes", owner.Scopes)
+
+			c.Next()
+			return
+		}
+
 		// 验证token
 		claims, err := utils.ValidateToken(token)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的token"})
-			c.Abort()
+			abort(c, http.StatusUnauthorized, "无效的token")
+			return
+		}
+
+		// 账户注销后token随之失效
+		if exists, err := models.UserExists(claims.UserID); err != nil || !exists {
+			abort(c, http.StatusUnauthorized, "无效的token")
 			return
 		}
 
@@ -49,8 +78,42 @@ func AuthMiddleware() gin.HandlerFunc {
 	}
 }
 
-// 可选认证中间件（不强制要求登录）
-func OptionalAuthMiddleware() gin.HandlerFunc {
+// 认证失败时的响应方式，路由组可以通过AbortWith替换（/api/v1使用统一的错误格式）。
+// details为附加的错误信息，没有时为nil
+type AbortFunc func(c *gin.Context, status int, message string, details gin.H)
+
+const abortFuncKey = "auth_abort_func"
+
+// 为后续的认证和权限中间件设置失败时的响应方式
+func AbortWith(fn AbortFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(abortFuncKey, fn)
+		c.Next()
+	}
+}
+
+// 返回认证或权限错误并终止请求
+func abort(c *gin.Context, status int, message string) {
+	abortWithDetails(c, status, message, nil)
+}
+
+// 返回带附加信息的认证或权限错误并终止请求，旧接口把附加信息合并到响应中
+func abortWithDetails(c *gin.Context, status int, message string, details gin.H) {
+	if fn, ok := c.Get(abortFuncKey); ok {
+		fn.(AbortFunc)(c, status, message, details)
+	} else {
+		body := gin.H{"error": message}
+		for k, v := range details {
+			body[k] = v
+		}
+		c.JSON(status, body)
+	}
+	c.Abort()
+}
+
+// 可选认证中间件（不强制要求登录），个人访问令牌的权限检查与AuthMiddleware相同，
+// 权限不足时按未登录处理
+func OptionalAuthMiddleware(scopes ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -72,12 +135,28 @@ func OptionalAuthMiddleware() gin.HandlerFunc {
 		// 提取token
 		token := strings.TrimPrefix(authHeader, "Bearer ")
 
+		// 个人访问令牌
+		if utils.IsPersonalToken(token) {
+			owner, err := models.AuthenticatePersonalToken(utils.HashPersonalToken(token))
+			if err == nil && tokenAllowed(owner, scopes) {
+				c.Set("user_id", owner.UserID)
+				c.Set("username", owner.Username)
+				c.Set("token_scopes", owner.Scopes)
+			}
+			c.Next()
+			return
+		}
+
 		// 验证token
 		claims, err := utils.ValidateToken(token)
 		if err != nil {
 			c.Next()
 			return
 		}
+		if exists, err := models.UserExists(claims.UserID); err != nil || !exists {
+			c.Next()
+			return
+		}
 
 		// 将用户信息存储到上下文中
 		c.Set("user_id", claims.UserID)
@@ -85,4 +164,18 @@ func OptionalAuthMiddleware() gin.HandlerFunc {
 
 		c.Next()
 	}
-} 
\ No newline at end of file
+}
+
+// 检查个人访问令牌能否访问当前路由：路由必须显式声明权限范围，令牌需要全部具备。
+// 导出数据、令牌管理、账户设置和管理后台等路由不声明权限范围，令牌无法访问
+func tokenAllowed(owner *models.TokenOwner, scopes []string) bool {
+	if len(scopes) == 0 {
+		return false
+	}
+	for _, scope := range scopes {
+		if !owner.HasScope(scope) {
+			return false
+		}
+	}
+	return true
+}
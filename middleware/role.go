@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"aiforum/models"
+)
+
+// 版主权限中间件（需配合AuthMiddleware使用）
+func ModeratorMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := models.GetUserByID(c.GetInt("user_id"))
+		if err != nil || !user.IsModerator() {
+			abort(c, http.StatusForbidden, "没有操作权限")
+			return
+		}
+		if !requireMFA(c, user.ID) {
+			return
+		}
+
+		c.Set("user_role", user.Role)
+
+		c.Next()
+	}
+}
+
+// 管理员权限中间件（需配合AuthMiddleware使用）
+func AdminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := models.GetUserByID(c.GetInt("user_id"))
+		if err != nil || !user.IsAdmin() {
+			abort(c, http.StatusForbidden, "没有操作权限")
+			return
+		}
+		if !requireMFA(c, user.ID) {
+			return
+		}
+
+		c.Set("user_role", user.Role)
+
+		c.Next()
+	}
+}
+
+// 管理员和版主必须开启两步验证后才能使用管理功能
+func requireMFA(c *gin.Context, userID int) bool {
+	enabled, err := models.TOTPEnabled(userID)
+	if err != nil || !enabled {
+		abortWithDetails(c, http.StatusForbidden, "管理员和版主需要先在个人中心开启两步验证", gin.H{
+			"mfa_enrollment_required": true,
+		})
+		return false
+	}
+	return true
+}
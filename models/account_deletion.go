@@ -0,0 +1,325 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+	"time"
+)
+
+// 申请注销后保留账户的时间，期间可以撤销
+const AccountDeletionGrace = 14 * 24 * time.Hour
+
+// 注销用户的内容统一归属到该账户
+const (
+	GhostUsername = "已注销用户"
+	ghostEmail    = "deleted-user@aiforum.invalid"
+)
+
+var (
+	ErrGhostAccount            = errors.New("该账户不能注销")
+	ErrAccountDeletionNotFound = errors.New("没有待处理的注销申请")
+)
+
+// 注销用户的占位账户ID
+var ghostUserID int
+
+// 创建注销用户的占位账户（密码不是有效的哈希，无法登录）
+func EnsureGhostUser() error {
+	_, err := DB.Exec(`
+		INSERT IGNORE INTO users (username, email, password, avatar, profile_public, email_notifications,
+			browser_notifications, question_notifications, follow_notifications, mention_notifications, email_digest)
+		VALUES (?, ?, ?, '/images/user.jpg', FALSE, FALSE, FALSE, FALSE, FALSE, FALSE, ?)
+	`, GhostUsername, ghostEmail, unusablePassword, EmailDigestOff)
+	if err != nil {
+		return err
+	}
+	return DB.QueryRow("SELECT id FROM users WHERE email = ?", ghostEmail).Scan(&ghostUserID)
+}
+
+// 是否为注销用户的占位账户
+func IsGhostUser(userID int) bool {
+	return ghostUserID != 0 && userID == ghostUserID
+}
+
+// 用户是否仍然存在，已注销用户的token随之失效
+func UserExists(userID int) (bool, error) {
+	var exists int
+	err := DB.QueryRow("SELECT 1 FROM users WHERE id = ?", userID).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// 申请注销账户，返回实际注销的时间（已申请时不会推迟）
+func ScheduleAccountDeletion(userID int) (time.Time, error) {
+	if IsGhostUser(userID) {
+		return time.Time{}, ErrGhostAccount
+	}
+
+	_, err := DB.Exec("UPDATE users SET deletion_scheduled_at = ? WHERE id = ? AND deletion_scheduled_at IS NULL",
+		time.Now().Add(AccountDeletionGrace), userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	scheduledAt, err := GetAccountDeletion(userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if scheduledAt == nil {
+		return time.Time{}, sql.ErrNoRows
+	}
+	return *scheduledAt, nil
+}
+
+// 撤销注销申请
+func CancelAccountDeletion(userID int) error {
+	result, err := DB.Exec("UPDATE users SET deletion_scheduled_at = NULL WHERE id = ? AND deletion_scheduled_at IS NOT NULL",
+		userID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrAccountDeletionNotFound
+	}
+	return nil
+}
+
+// 获取账户的注销时间，未申请注销时返回nil
+func GetAccountDeletion(userID int) (*time.Time, error) {
+	var scheduledAt sql.NullTime
+	err := DB.QueryRow("SELECT deletion_scheduled_at FROM users WHERE id = ?", userID).Scan(&scheduledAt)
+	if err != nil {
+		return nil, err
+	}
+	if !scheduledAt.Valid {
+		return nil, nil
+	}
+	return &scheduledAt.Time, nil
+}
+
+// 获取已过等待期、需要注销的账户
+func GetDueAccountDeletions(limit int) ([]int, error) {
+	rows, err := DB.Query("SELECT id FROM users WHERE deletion_scheduled_at <= NOW() ORDER BY deletion_scheduled_at LIMIT ?",
+		limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// 获取用户上传的文件（头像除外），注销后需要删除。
+// 资料文件按原文件名保存，只返回没有被其他用户的资料引用的文件
+func GetUserUploads(userID int) ([]string, error) {
+	var paths []string
+
+	rows, err := DB.Query("SELECT IFNULL(cover_image, ''), file_paths FROM learning_resources WHERE user_id = ?", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var resourcePaths []string
+	for rows.Next() {
+		var cover, filePaths string
+		if err := rows.Scan(&cover, &filePaths); err != nil {
+			return nil, err
+		}
+		if cover != "" {
+			resourcePaths = append(resourcePaths, cover)
+		}
+		for _, p := range strings.Split(filePaths, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				resourcePaths = append(resourcePaths, p)
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, p := range resourcePaths {
+		var shared int
+		err := DB.QueryRow(`
+			SELECT COUNT(*) FROM learning_resources
+			WHERE user_id <> ? AND (cover_image = ? OR FIND_IN_SET(?, file_paths) > 0)
+		`, userID, p, p).Scan(&shared)
+		if err != nil {
+			return nil, err
+		}
+		if shared == 0 {
+			paths = append(paths, p)
+		}
+	}
+
+	attachmentRows, err := DB.Query(`
+		SELECT a.file_path FROM direct_message_attachments a
+		JOIN direct_messages m ON m.id = a.message_id
+		WHERE m.sender_id = ?
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer attachmentRows.Close()
+	for attachmentRows.Next() {
+		var p string
+		if err := attachmentRows.Scan(&p); err != nil {
+			return nil, err
+		}
+		paths = append(paths, p)
+	}
+	if err := attachmentRows.Err(); err != nil {
+		return nil, err
+	}
+
+	exportRows, err := DB.Query("SELECT file_path FROM data_exports WHERE user_id = ? AND file_path IS NOT NULL", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer exportRows.Close()
+	for exportRows.Next() {
+		var p string
+		if err := exportRows.Scan(&p); err != nil {
+			return nil, err
+		}
+		paths = append(paths, p)
+	}
+	return paths, exportRows.Err()
+}
+
+// 注销后转给占位账户的内容
+var ghostOwnedColumns = []struct{ table, column string }{
+	{"posts", "user_id"},
+	{"replies", "user_id"},
+	{"questions", "user_id"},
+	{"questions", "closed_by"},
+	{"answers", "user_id"},
+	{"tech_articles", "user_id"},
+	{"article_comments", "user_id"},
+	{"resource_comments", "user_id"},
+	{"question_state_logs", "user_id"},
+	{"conversations", "created_by"},
+	{"direct_messages", "sender_id"},
+}
+
+// 注销后转给占位账户的投票、评分和举报（占位账户已有相同记录时直接删除）
+var ghostOwnedVotes = []struct{ table, column string }{
+	{"answer_likes", "user_id"},
+	{"tech_article_likes", "user_id"},
+	{"comment_likes", "user_id"},
+	{"resource_ratings", "user_id"},
+	{"question_reports", "user_id"},
+	{"article_reports", "user_id"},
+	{"question_reopen_votes", "user_id"},
+	{"conversation_members", "user_id"},
+}
+
+// 注销账户：内容转给占位账户，删除学习资料和私信附件记录，最后删除用户，
+// 关注、收藏、通知、搜索记录等个人数据随用户一起删除
+func AnonymizeUser(userID int) error {
+	if ghostUserID == 0 || IsGhostUser(userID) {
+		return ErrGhostAccount
+	}
+
+	var username string
+	if err := DB.QueryRow("SELECT username FROM users WHERE id = ?", userID).Scan(&username); err != nil {
+		return err
+	}
+
+	resourceIDs, err := queryIDs("SELECT id FROM learning_resources WHERE user_id = ?", userID)
+	if err != nil {
+		return err
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	steps := []string{
+		// 附件文件会被删除，在私信转给占位账户之前删除记录
+		`DELETE a FROM direct_message_attachments a
+		 JOIN direct_messages m ON m.id = a.message_id
+		 WHERE m.sender_id = ?`,
+		"DELETE FROM learning_resources WHERE user_id = ?",
+		"DELETE FROM message_actors WHERE actor_id = ?",
+		// 关注关系随用户删除，先更新对方的计数
+		`UPDATE users SET follower_count = GREATEST(follower_count - 1, 0)
+		 WHERE id IN (SELECT followed_id FROM follows WHERE follower_id = ?)`,
+		`UPDATE users SET following_count = GREATEST(following_count - 1, 0)
+		 WHERE id IN (SELECT follower_id FROM follows WHERE followed_id = ?)`,
+	}
+	for _, step := range steps {
+		if _, err := tx.Exec(step, userID); err != nil {
+			return err
+		}
+	}
+
+	for _, c := range ghostOwnedColumns {
+		_, err := tx.Exec("UPDATE "+c.table+" SET "+c.column+" = ? WHERE "+c.column+" = ?", ghostUserID, userID)
+		if err != nil {
+			return err
+		}
+	}
+	for _, c := range ghostOwnedVotes {
+		_, err := tx.Exec("UPDATE IGNORE "+c.table+" SET "+c.column+" = ? WHERE "+c.column+" = ?", ghostUserID, userID)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec("DELETE FROM "+c.table+" WHERE "+c.column+" = ?", userID); err != nil {
+			return err
+		}
+	}
+
+	// 其他用户通知中的发送者名称
+	if _, err := tx.Exec("UPDATE messages SET sender = ? WHERE sender = ?", GhostUsername, username); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM users WHERE id = ?", userID); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	for _, id := range resourceIDs {
+		if err := RemoveContentTags(ContentTypeResource, id); err != nil {
+			log.Printf("清理资料标签失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// 查询一列ID
+func queryIDs(query string, args ...interface{}) ([]int, error) {
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
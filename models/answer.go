@@ -2,11 +2,37 @@ package models
 
 import (
 	"database/sql"
+	"strconv"
+
+	"aiforum/hub"
 )
 
 // 创建回答
 func CreateAnswer(questionID, userID int, content string) (int, error) {
-	result, err := DB.Exec("INSERT INTO answers (question_id, user_id, content) VALUES (?, ?, ?)",
+	user, err := GetUserByID(userID)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkContentOwnerBlock("questions", questionID, userID); err != nil {
+		return 0, err
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// 锁定问题行后再检查状态，避免与关闭、锁定等操作并发时插入回答
+	state, err := getQuestionState(tx, questionID, true)
+	if err != nil {
+		return 0, err
+	}
+	if err := state.CanAnswer(user); err != nil {
+		return 0, err
+	}
+
+	result, err := tx.Exec("INSERT INTO answers (question_id, user_id, content) VALUES (?, ?, ?)",
 		questionID, userID, content)
 	if err != nil {
 		return 0, err
@@ -18,17 +44,56 @@ func CreateAnswer(questionID, userID int, content string) (int, error) {
 	}
 
 	// 更新问题回答数量
-	_, err = DB.Exec("UPDATE questions SET answer_count = answer_count + 1 WHERE id = ?", questionID)
+	_, err = tx.Exec("UPDATE questions SET answer_count = answer_count + 1 WHERE id = ?", questionID)
 	if err != nil {
 		return 0, err
 	}
 
-	// 给回答用户加积分
-	err = UpdateUserPoints(userID, 3)
-	if err != nil {
+	// 给回答用户加积分（社区维基问题不产生声望）
+	if !state.IsWiki {
+		_, err = tx.Exec("UPDATE users SET points = points + ?, updated_at = NOW() WHERE id = ?", 3, userID)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
 		return 0, err
 	}
 
+	// 推送问题页的回答数变化
+	var answerCount int
+	if err := DB.QueryRow("SELECT answer_count FROM questions WHERE id = ?", questionID).Scan(&answerCount); err == nil {
+		hub.Publish(hub.QuestionTopic(questionID), "answer_count", map[string]int{
+			"question_id":  questionID,
+			"answer_count": answerCount,
+		})
+	}
+
+	// 通知提问者和被@的用户
+	if ownerID, title, err := contentOwner("questions", questionID); err == nil {
+		link := "/qa/" + strconv.Itoa(questionID)
+		Notify(Notification{
+			UserID:     ownerID,
+			ActorID:    userID,
+			Type:       NotifyAnswer,
+			TargetType: "question",
+			TargetID:   questionID,
+			Subject:    title,
+			Content:    notificationExcerpt(content),
+			Link:       link,
+		})
+		RecordMentions(MentionSource{
+			ContentType: MentionInAnswer,
+			ContentID:   int(answerID),
+			ActorID:     userID,
+			TargetType:  "answer",
+			TargetID:    int(answerID),
+			Subject:     title,
+			Link:        link,
+		}, content, ownerID)
+	}
+
 	return int(answerID), nil
 }
 
@@ -39,7 +104,7 @@ func GetAnswersByQuestionID(questionID int) ([]*Answer, error) {
 			   a.content, a.like_count, a.is_accepted, a.created_at
 		FROM answers a
 		JOIN users u ON a.user_id = u.id
-		WHERE a.question_id = ?
+		WHERE a.question_id = ? AND a.deleted_at IS NULL
 		ORDER BY a.is_accepted DESC, a.like_count DESC, a.created_at ASC
 	`
 	
@@ -78,7 +143,7 @@ func AcceptAnswer(answerID, userID int) error {
 		SELECT q.id, q.user_id, a.user_id, q.reward, q.is_solved 
 		FROM questions q 
 		JOIN answers a ON q.id = a.question_id 
-		WHERE a.id = ?
+		WHERE a.id = ? AND a.deleted_at IS NULL AND q.deleted_at IS NULL
 	`, answerID).Scan(&questionID, &questionUserID, &answerUserID, &reward, &isSolved)
 	
 	if err != nil {
@@ -124,7 +189,39 @@ func AcceptAnswer(answerID, userID int) error {
 	}
 	
 	// 提交事务
-	return tx.Commit()
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	// 通知回答者
+	if _, title, err := contentOwner("questions", questionID); err == nil {
+		Notify(Notification{
+			UserID:     answerUserID,
+			ActorID:    userID,
+			Type:       NotifyAccept,
+			TargetType: "answer",
+			TargetID:   answerID,
+			Subject:    title,
+			Content:    "问题：" + title,
+			Link:       "/qa/" + strconv.Itoa(questionID),
+		})
+
+		DispatchWebhookEvent(WebhookEvent{
+			Name:        WebhookAnswerAccepted,
+			ActorID:     userID,
+			ContentType: ContentTypeQuestion,
+			ContentID:   questionID,
+			Data: map[string]interface{}{
+				"answer_id":      answerID,
+				"answer_user_id": answerUserID,
+				"question_id":    questionID,
+				"question_title": title,
+				"reward":         reward,
+				"url":            siteURL("/qa/" + strconv.Itoa(questionID)),
+			},
+		})
+	}
+	return nil
 }
 
 // 点赞回答
@@ -147,7 +244,31 @@ func LikeAnswer(answerID, userID int) error {
 			return err
 		}
 		_, err = DB.Exec("UPDATE answers SET like_count = like_count + 1 WHERE id = ?", answerID)
-		return err
+		if err != nil {
+			return err
+		}
+
+		// 通知回答者
+		var authorID, questionID int
+		var title string
+		err = DB.QueryRow(`
+			SELECT a.user_id, a.question_id, q.title FROM answers a
+			JOIN questions q ON a.question_id = q.id
+			WHERE a.id = ? AND a.deleted_at IS NULL
+		`, answerID).Scan(&authorID, &questionID, &title)
+		if err == nil {
+			Notify(Notification{
+				UserID:     authorID,
+				ActorID:    userID,
+				Type:       NotifyLike,
+				TargetType: "answer",
+				TargetID:   answerID,
+				Subject:    title,
+				Content:    "问题：" + title,
+				Link:       "/qa/" + strconv.Itoa(questionID),
+			})
+		}
+		return nil
 	}
 	
 	return err
@@ -161,7 +282,7 @@ func GetAnswerByID(answerID int) (*Answer, error) {
 			   a.content, a.like_count, a.is_accepted, a.created_at
 		FROM answers a
 		JOIN users u ON a.user_id = u.id
-		WHERE a.id = ?
+		WHERE a.id = ? AND a.deleted_at IS NULL
 	`, answerID).Scan(
 		&answer.ID, &answer.QuestionID, &answer.UserID, &answer.Username, &answer.UserAvatar,
 		&answer.Content, &answer.LikeCount, &answer.IsAccepted, &answer.CreatedAt,
@@ -172,4 +293,88 @@ func GetAnswerByID(answerID int) (*Answer, error) {
 	}
 	
 	return answer, nil
-} 
\ No newline at end of file
+} 
+// 批量获取多个问题的合并回答，按问题ID分组，排序与GetMergedAnswersByQuestionID相同
+func GetMergedAnswersByQuestionIDs(questionIDs []int) (map[int][]*Answer, error) {
+	answers := make(map[int][]*Answer)
+	if len(questionIDs) == 0 {
+		return answers, nil
+	}
+	in, args := inClause(questionIDs)
+	query := `
+		SELECT * FROM (
+			SELECT a.question_id AS target_id, a.id, a.question_id, a.user_id, u.username, u.avatar,
+				   a.content, a.like_count, a.is_accepted, a.created_at
+			FROM answers a
+			JOIN users u ON a.user_id = u.id
+			WHERE a.question_id IN (` + in + `) AND a.deleted_at IS NULL
+			UNION ALL
+			SELECT q.duplicate_of AS target_id, a.id, a.question_id, a.user_id, u.username, u.avatar,
+				   a.content, a.like_count, a.is_accepted, a.created_at
+			FROM answers a
+			JOIN users u ON a.user_id = u.id
+			JOIN questions q ON a.question_id = q.id
+			WHERE q.duplicate_of IN (` + in + `) AND q.deleted_at IS NULL AND a.deleted_at IS NULL
+		) AS merged
+		ORDER BY is_accepted DESC, like_count DESC, created_at ASC
+	`
+
+	rows, err := DB.Query(query, append(args, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var targetID int
+		answer := &Answer{}
+		err := rows.Scan(
+			&targetID, &answer.ID, &answer.QuestionID, &answer.UserID, &answer.Username, &answer.UserAvatar,
+			&answer.Content, &answer.LikeCount, &answer.IsAccepted, &answer.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		answers[targetID] = append(answers[targetID], answer)
+	}
+	return answers, rows.Err()
+}
+
+// 批量检查用户点赞过哪些回答
+func GetLikedAnswerIDs(userID int, answerIDs []int) (map[int]bool, error) {
+	return linkedIDs("answer_likes", "answer_id", userID, answerIDs, "")
+}
+
+// 获取问题及其重复问题下的全部回答（合并视图）
+func GetMergedAnswersByQuestionID(questionID int) ([]*Answer, error) {
+	query := `
+		SELECT a.id, a.question_id, a.user_id, u.username, u.avatar, 
+			   a.content, a.like_count, a.is_accepted, a.created_at
+		FROM answers a
+		JOIN users u ON a.user_id = u.id
+		WHERE (a.question_id = ? OR a.question_id IN (SELECT id FROM questions WHERE duplicate_of = ? AND deleted_at IS NULL))
+			AND a.deleted_at IS NULL
+		ORDER BY a.is_accepted DESC, a.like_count DESC, a.created_at ASC
+	`
+	
+	rows, err := DB.Query(query, questionID, questionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var answers []*Answer
+	for rows.Next() {
+		answer := &Answer{}
+		err := rows.Scan(
+			&answer.ID, &answer.QuestionID, &answer.UserID, &answer.Username, &answer.UserAvatar,
+			&answer.Content, &answer.LikeCount, &answer.IsAccepted, &answer.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		answers = append(answers, answer)
+	}
+
+	return answers, nil
+}
@@ -0,0 +1,165 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// 屏蔽相关错误
+var (
+	ErrCannotBlockSelf = errors.New("不能屏蔽自己")
+	ErrCannotMuteSelf  = errors.New("不能静音自己")
+	ErrBlocked         = errors.New("由于屏蔽关系，无法进行此操作")
+)
+
+// 屏蔽或静音的用户
+type RelationUser struct {
+	ID        int       `json:"id"`
+	Username  string    `json:"username"`
+	Avatar    string    `json:"avatar"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// 屏蔽用户，同时解除双方之间的关注关系
+func BlockUser(blockerID, blockedID int) error {
+	if blockerID == blockedID {
+		return ErrCannotBlockSelf
+	}
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("INSERT IGNORE INTO user_blocks (blocker_id, blocked_id) VALUES (?, ?)", blockerID, blockedID); err != nil {
+		return err
+	}
+	if _, err := removeFollow(tx, blockerID, blockedID); err != nil {
+		return err
+	}
+	if _, err := removeFollow(tx, blockedID, blockerID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// 取消屏蔽
+func UnblockUser(blockerID, blockedID int) error {
+	_, err := DB.Exec("DELETE FROM user_blocks WHERE blocker_id = ? AND blocked_id = ?", blockerID, blockedID)
+	return err
+}
+
+// 检查blocker是否屏蔽了blocked
+func HasBlocked(blockerID, blockedID int) (bool, error) {
+	var count int
+	err := DB.QueryRow("SELECT COUNT(*) FROM user_blocks WHERE blocker_id = ? AND blocked_id = ?", blockerID, blockedID).Scan(&count)
+	return count > 0, err
+}
+
+// 检查两个用户之间是否存在任一方向的屏蔽
+func IsBlockedBetween(userA, userB int) (bool, error) {
+	var count int
+	err := DB.QueryRow(`
+		SELECT COUNT(*) FROM user_blocks
+		WHERE (blocker_id = ? AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = ?)
+	`, userA, userB, userB, userA).Scan(&count)
+	return count > 0, err
+}
+
+// 检查内容作者是否屏蔽了userID，被屏蔽时返回ErrBlocked
+func checkNotBlockedBy(userID int, ownerIDs ...int) error {
+	for _, ownerID := range ownerIDs {
+		if ownerID <= 0 || ownerID == userID {
+			continue
+		}
+		blocked, err := HasBlocked(ownerID, userID)
+		if err != nil {
+			return err
+		}
+		if blocked {
+			return ErrBlocked
+		}
+	}
+	return nil
+}
+
+// 检查内容（表table中id对应的记录）的作者是否屏蔽了userID
+func checkContentOwnerBlock(table string, id, userID int) error {
+	ownerID, _, err := contentOwner(table, id)
+	if err != nil {
+		return err
+	}
+	return checkNotBlockedBy(userID, ownerID)
+}
+
+// 静音用户：对方的内容不再出现在自己的动态、搜索和通知中
+func MuteUser(muterID, mutedID int) error {
+	if muterID == mutedID {
+		return ErrCannotMuteSelf
+	}
+	_, err := DB.Exec("INSERT IGNORE INTO user_mutes (muter_id, muted_id) VALUES (?, ?)", muterID, mutedID)
+	return err
+}
+
+// 取消静音
+func UnmuteUser(muterID, mutedID int) error {
+	_, err := DB.Exec("DELETE FROM user_mutes WHERE muter_id = ? AND muted_id = ?", muterID, mutedID)
+	return err
+}
+
+// 检查userID是否不想看到actorID的内容（静音或屏蔽）
+func isHiddenAuthor(userID, actorID int) (bool, error) {
+	var count int
+	err := DB.QueryRow(`
+		SELECT (SELECT COUNT(*) FROM user_mutes WHERE muter_id = ? AND muted_id = ?) +
+			   (SELECT COUNT(*) FROM user_blocks WHERE blocker_id = ? AND blocked_id = ?)
+	`, userID, actorID, userID, actorID).Scan(&count)
+	return count > 0, err
+}
+
+// 过滤掉查看者静音或屏蔽的作者的SQL条件，需要追加两个查看者ID参数
+func hiddenAuthorFilter(column string) string {
+	return " AND " + column + ` NOT IN (
+		SELECT muted_id FROM user_mutes WHERE muter_id = ?
+		UNION SELECT blocked_id FROM user_blocks WHERE blocker_id = ?)`
+}
+
+// 获取屏蔽列表
+func GetBlockedUsers(userID int) ([]RelationUser, error) {
+	return getRelationUsers(`
+		SELECT u.id, u.username, u.avatar, b.created_at
+		FROM user_blocks b
+		JOIN users u ON b.blocked_id = u.id
+		WHERE b.blocker_id = ?
+		ORDER BY b.created_at DESC
+	`, userID)
+}
+
+// 获取静音列表
+func GetMutedUsers(userID int) ([]RelationUser, error) {
+	return getRelationUsers(`
+		SELECT u.id, u.username, u.avatar, m.created_at
+		FROM user_mutes m
+		JOIN users u ON m.muted_id = u.id
+		WHERE m.muter_id = ?
+		ORDER BY m.created_at DESC
+	`, userID)
+}
+
+func getRelationUsers(query string, userID int) ([]RelationUser, error) {
+	rows, err := DB.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []RelationUser{}
+	for rows.Next() {
+		var u RelationUser
+		if err := rows.Scan(&u.ID, &u.Username, &u.Avatar, &u.CreatedAt); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
@@ -0,0 +1,380 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 收藏相关错误
+var (
+	ErrInvalidBookmarkType  = errors.New("不支持收藏该类型的内容")
+	ErrBookmarkNotFound     = errors.New("收藏不存在")
+	ErrCollectionNotFound   = errors.New("收藏夹不存在")
+	ErrCollectionNameEmpty  = errors.New("收藏夹名称不能为空")
+	ErrCollectionNameExists = errors.New("已存在同名收藏夹")
+)
+
+// 收藏夹名称最大长度
+const maxCollectionNameLength = 100
+
+// 收藏（一条内容对每个用户只收藏一次，可放入一个收藏夹）
+type Bookmark struct {
+	ID           int       `json:"id"`
+	ContentType  string    `json:"content_type"` // question, post, article, resource
+	ContentID    int       `json:"content_id"`
+	CollectionID int       `json:"collection_id,omitempty"`
+	Note         string    `json:"note"`
+	Title        string    `json:"title"`
+	Excerpt      string    `json:"excerpt"`
+	Author       string    `json:"author"`
+	Link         string    `json:"link"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// 收藏夹
+type BookmarkCollection struct {
+	ID            int       `json:"id"`
+	UserID        int       `json:"user_id"`
+	Username      string    `json:"username"`
+	Name          string    `json:"name"`
+	Description   string    `json:"description"`
+	IsPublic      bool      `json:"is_public"`
+	BookmarkCount int       `json:"bookmark_count"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// 收藏内容的页面地址前缀
+var bookmarkLinks = map[string]string{
+	ContentTypeQuestion: "/qa/",
+	ContentTypePost:     "/post/",
+	ContentTypeArticle:  "/tech-share/",
+	ContentTypeResource: "/learning-resources/",
+}
+
+// 检查是否为可收藏的内容类型
+func ValidBookmarkType(contentType string) bool {
+	_, ok := contentTagTables[contentType]
+	return ok
+}
+
+// 检查被收藏的内容是否存在
+func bookmarkTargetExists(contentType string, contentID int) (bool, error) {
+	table, ok := contentTagTables[contentType]
+	if !ok {
+		return false, ErrInvalidBookmarkType
+	}
+	var count int
+	err := DB.QueryRow("SELECT COUNT(*) FROM "+table+" WHERE id = ?", contentID).Scan(&count)
+	return count > 0, err
+}
+
+// 检查收藏夹是否属于用户（collectionID为0表示不放入收藏夹）
+func checkCollectionOwner(userID, collectionID int) error {
+	if collectionID == 0 {
+		return nil
+	}
+	var ownerID int
+	err := DB.QueryRow("SELECT user_id FROM bookmark_collections WHERE id = ?", collectionID).Scan(&ownerID)
+	if err == sql.ErrNoRows || (err == nil && ownerID != userID) {
+		return ErrCollectionNotFound
+	}
+	return err
+}
+
+func nullableCollection(collectionID int) interface{} {
+	if collectionID == 0 {
+		return nil
+	}
+	return collectionID
+}
+
+// 添加收藏，已收藏时更新收藏夹和备注
+func AddBookmark(userID int, contentType string, contentID, collectionID int, note string) error {
+	exists, err := bookmarkTargetExists(contentType, contentID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return sql.ErrNoRows
+	}
+	if err := checkCollectionOwner(userID, collectionID); err != nil {
+		return err
+	}
+
+	_, err = DB.Exec(`
+		INSERT INTO bookmarks (user_id, content_type, content_id, collection_id, note)
+		VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE collection_id = VALUES(collection_id), note = VALUES(note)
+	`, userID, contentType, contentID, nullableCollection(collectionID), strings.TrimSpace(note))
+	return err
+}
+
+// 修改收藏所在的收藏夹和备注
+func UpdateBookmark(userID, bookmarkID, collectionID int, note string) error {
+	if err := checkCollectionOwner(userID, collectionID); err != nil {
+		return err
+	}
+	result, err := DB.Exec("UPDATE bookmarks SET collection_id = ?, note = ? WHERE id = ? AND user_id = ?",
+		nullableCollection(collectionID), strings.TrimSpace(note), bookmarkID, userID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		var count int
+		if err := DB.QueryRow("SELECT COUNT(*) FROM bookmarks WHERE id = ? AND user_id = ?", bookmarkID, userID).Scan(&count); err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrBookmarkNotFound
+		}
+	}
+	return nil
+}
+
+// 删除收藏
+func RemoveBookmark(userID, bookmarkID int) error {
+	_, err := DB.Exec("DELETE FROM bookmarks WHERE id = ? AND user_id = ?", bookmarkID, userID)
+	return err
+}
+
+// 收藏/取消收藏内容，返回操作后是否处于收藏状态
+func ToggleBookmark(userID int, contentType string, contentID int) (bool, error) {
+	result, err := DB.Exec("DELETE FROM bookmarks WHERE user_id = ? AND content_type = ? AND content_id = ?",
+		userID, contentType, contentID)
+	if err != nil {
+		return false, err
+	}
+	if affected, _ := result.RowsAffected(); affected > 0 {
+		return false, nil
+	}
+	if err := AddBookmark(userID, contentType, contentID, 0, ""); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// 检查用户是否收藏了内容
+func IsBookmarked(userID int, contentType string, contentID int) bool {
+	var exists int
+	err := DB.QueryRow("SELECT 1 FROM bookmarks WHERE user_id = ? AND content_type = ? AND content_id = ?",
+		userID, contentType, contentID).Scan(&exists)
+	return err == nil
+}
+
+// 批量检查用户收藏了哪些内容
+func GetBookmarkedIDs(userID int, contentType string, contentIDs []int) (map[int]bool, error) {
+	return linkedIDs("bookmarks", "content_id", userID, contentIDs, "AND content_type = ?", contentType)
+}
+
+// 获取用户的收藏（collectionID为0表示全部，contentType为空表示所有类型）
+func GetUserBookmarks(userID, collectionID int, contentType string) ([]*Bookmark, error) {
+	where := "b.user_id = ?"
+	args := []interface{}{userID}
+	if collectionID > 0 {
+		where += " AND b.collection_id = ?"
+		args = append(args, collectionID)
+	}
+	if contentType != "" {
+		where += " AND b.content_type = ?"
+		args = append(args, contentType)
+	}
+	return queryBookmarks(where, args...)
+}
+
+// 查询收藏并补充被收藏内容的标题、摘要和作者（内容已删除的收藏不返回）
+func queryBookmarks(where string, args ...interface{}) ([]*Bookmark, error) {
+	rows, err := DB.Query(`
+		SELECT b.id, b.content_type, b.content_id, IFNULL(b.collection_id, 0), IFNULL(b.note, ''),
+			   COALESCE(q.title, p.title, t.title, lr.title, ''),
+			   COALESCE(LEFT(q.content, 100), LEFT(p.content, 100), t.summary, LEFT(lr.description, 100), ''),
+			   IFNULL(u.username, ''), b.created_at
+		FROM bookmarks b
+		LEFT JOIN questions q ON b.content_type = 'question' AND q.id = b.content_id AND q.deleted_at IS NULL
+		LEFT JOIN posts p ON b.content_type = 'post' AND p.id = b.content_id
+		LEFT JOIN tech_articles t ON b.content_type = 'article' AND t.id = b.content_id AND t.deleted_at IS NULL
+		LEFT JOIN learning_resources lr ON b.content_type = 'resource' AND lr.id = b.content_id AND lr.deleted_at IS NULL
+		LEFT JOIN users u ON u.id = COALESCE(q.user_id, p.user_id, t.user_id, lr.user_id)
+		WHERE `+where+` AND COALESCE(q.id, p.id, t.id, lr.id) IS NOT NULL
+		ORDER BY b.created_at DESC, b.id DESC
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bookmarks := []*Bookmark{}
+	for rows.Next() {
+		b := &Bookmark{}
+		if err := rows.Scan(&b.ID, &b.ContentType, &b.ContentID, &b.CollectionID, &b.Note,
+			&b.Title, &b.Excerpt, &b.Author, &b.CreatedAt); err != nil {
+			return nil, err
+		}
+		b.Link = bookmarkLinks[b.ContentType] + strconv.Itoa(b.ContentID)
+		bookmarks = append(bookmarks, b)
+	}
+	return bookmarks, rows.Err()
+}
+
+// 校验收藏夹名称
+func normalizeCollectionName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", ErrCollectionNameEmpty
+	}
+	if len([]rune(name)) > maxCollectionNameLength {
+		name = string([]rune(name)[:maxCollectionNameLength])
+	}
+	return name, nil
+}
+
+// 创建收藏夹
+func CreateCollection(userID int, name, description string, isPublic bool) (int, error) {
+	name, err := normalizeCollectionName(name)
+	if err != nil {
+		return 0, err
+	}
+	result, err := DB.Exec("INSERT IGNORE INTO bookmark_collections (user_id, name, description, is_public) VALUES (?, ?, ?, ?)",
+		userID, name, description, isPublic)
+	if err != nil {
+		return 0, err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return 0, ErrCollectionNameExists
+	}
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+// 修改收藏夹
+func UpdateCollection(userID, collectionID int, name, description string, isPublic bool) error {
+	name, err := normalizeCollectionName(name)
+	if err != nil {
+		return err
+	}
+	if err := checkCollectionOwner(userID, collectionID); err != nil {
+		return err
+	}
+
+	var count int
+	err = DB.QueryRow("SELECT COUNT(*) FROM bookmark_collections WHERE user_id = ? AND name = ? AND id <> ?",
+		userID, name, collectionID).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrCollectionNameExists
+	}
+
+	_, err = DB.Exec("UPDATE bookmark_collections SET name = ?, description = ?, is_public = ? WHERE id = ? AND user_id = ?",
+		name, description, isPublic, collectionID, userID)
+	return err
+}
+
+// 删除收藏夹，其中的收藏保留但不再属于任何收藏夹
+func DeleteCollection(userID, collectionID int) error {
+	result, err := DB.Exec("DELETE FROM bookmark_collections WHERE id = ? AND user_id = ?", collectionID, userID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrCollectionNotFound
+	}
+	return nil
+}
+
+// 获取用户的收藏夹（publicOnly为true时只返回公开收藏夹）
+func GetUserCollections(userID int, publicOnly bool) ([]*BookmarkCollection, error) {
+	where := "c.user_id = ?"
+	if publicOnly {
+		where += " AND c.is_public = 1"
+	}
+	return queryCollections(where+" ORDER BY c.updated_at DESC, c.id DESC", userID)
+}
+
+// 获取收藏夹，非公开收藏夹只有创建者可以查看
+func GetCollection(collectionID, viewerID int) (*BookmarkCollection, error) {
+	collections, err := queryCollections("c.id = ?", collectionID)
+	if err != nil {
+		return nil, err
+	}
+	if len(collections) == 0 || (!collections[0].IsPublic && collections[0].UserID != viewerID) {
+		return nil, ErrCollectionNotFound
+	}
+	return collections[0], nil
+}
+
+// 获取收藏夹中的收藏
+func GetCollectionBookmarks(collectionID int) ([]*Bookmark, error) {
+	return queryBookmarks("b.collection_id = ?", collectionID)
+}
+
+func queryCollections(where string, args ...interface{}) ([]*BookmarkCollection, error) {
+	rows, err := DB.Query(`
+		SELECT c.id, c.user_id, u.username, c.name, IFNULL(c.description, ''), c.is_public,
+			   (SELECT COUNT(*) FROM bookmarks b WHERE b.collection_id = c.id),
+			   c.created_at, c.updated_at
+		FROM bookmark_collections c
+		JOIN users u ON c.user_id = u.id
+		WHERE `+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	collections := []*BookmarkCollection{}
+	for rows.Next() {
+		c := &BookmarkCollection{}
+		if err := rows.Scan(&c.ID, &c.UserID, &c.Username, &c.Name, &c.Description, &c.IsPublic,
+			&c.BookmarkCount, &c.CreatedAt, &c.UpdatedAt); err != nil {
+			return nil, err
+		}
+		collections = append(collections, c)
+	}
+	return collections, rows.Err()
+}
+
+// 旧收藏表及导入语句
+var legacyFavoriteTables = []struct {
+	table  string
+	insert string
+}{
+	{"question_favorites", `
+		INSERT IGNORE INTO bookmarks (user_id, content_type, content_id, created_at)
+		SELECT user_id, 'question', question_id, created_at FROM question_favorites`},
+	{"article_favorites", `
+		INSERT IGNORE INTO bookmarks (user_id, content_type, content_id, created_at)
+		SELECT user_id, 'article', article_id, created_at FROM article_favorites`},
+	// 个人中心的通用收藏表中文章的类型为share
+	{"favorites", `
+		INSERT IGNORE INTO bookmarks (user_id, content_type, content_id, created_at)
+		SELECT user_id, CASE type WHEN 'share' THEN 'article' ELSE type END, target_id, created_at
+		FROM favorites WHERE type IN ('question', 'share', 'article', 'resource', 'post')`},
+}
+
+// 将旧的收藏表导入bookmarks，导入后删除旧表
+func MigrateBookmarks() {
+	for _, legacy := range legacyFavoriteTables {
+		exists, err := tableExists(legacy.table)
+		if err != nil {
+			log.Printf("迁移%s失败: %v", legacy.table, err)
+			continue
+		}
+		if !exists {
+			continue
+		}
+		if _, err := DB.Exec(legacy.insert); err != nil {
+			log.Printf("迁移%s失败: %v", legacy.table, err)
+			continue
+		}
+		if _, err := DB.Exec("DROP TABLE " + legacy.table); err != nil {
+			log.Printf("删除旧收藏表%s失败: %v", legacy.table, err)
+			continue
+		}
+		log.Printf("已将%s导入bookmarks", legacy.table)
+	}
+}
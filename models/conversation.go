@@ -0,0 +1,404 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"aiforum/hub"
+)
+
+// 不需要对方关注即可发起私信的最低等级
+const DirectMessageMinLevel = 3
+
+// 私信相关错误
+var (
+	ErrConversationNotFound = errors.New("会话不存在")
+	ErrCannotMessageSelf    = errors.New("不能给自己发私信")
+	ErrMessageBlocked       = errors.New("你们之间存在屏蔽关系，无法发送私信")
+	ErrMessageNotAllowed    = errors.New("对方未关注你，等级达到3级后才能发起私信")
+	ErrEmptyDirectMessage   = errors.New("私信内容不能为空")
+)
+
+// 会话中的用户
+type ConversationUser struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+	Avatar   string `json:"avatar"`
+}
+
+// 私信会话
+type Conversation struct {
+	ID            int               `json:"id"`
+	Peer          *ConversationUser `json:"peer"`
+	LastMessage   string            `json:"last_message"`
+	LastMessageAt *time.Time        `json:"last_message_at"`
+	UnreadCount   int               `json:"unread_count"`
+	Blocked       bool              `json:"blocked"`
+}
+
+// 私信消息
+type DirectMessage struct {
+	ID             int                  `json:"id"`
+	ConversationID int                  `json:"conversation_id"`
+	SenderID       int                  `json:"sender_id"`
+	SenderName     string               `json:"sender_name"`
+	SenderAvatar   string               `json:"sender_avatar"`
+	Content        string               `json:"content"`
+	Attachments    []*MessageAttachment `json:"attachments"`
+	IsRead         bool                 `json:"is_read"` // 对方是否已读
+	CreatedAt      time.Time            `json:"created_at"`
+}
+
+// 私信附件
+type MessageAttachment struct {
+	ID             int    `json:"id"`
+	MessageID      int    `json:"message_id"`
+	ConversationID int    `json:"conversation_id"`
+	FileName       string `json:"file_name"`
+	FilePath       string `json:"-"`
+	FileSize       int64  `json:"file_size"`
+	ContentType    string `json:"content_type"`
+	URL            string `json:"url"`
+}
+
+// 两个用户的会话唯一键
+func conversationPairKey(userA, userB int) string {
+	if userA > userB {
+		userA, userB = userB, userA
+	}
+	return strconv.Itoa(userA) + ":" + strconv.Itoa(userB)
+}
+
+// 检查用户是否可以给对方发私信：双方没有屏蔽，且对方关注了自己、自己等级足够或对方曾回复过
+func CanMessageUser(sender *User, recipientID int) error {
+	if sender.ID == recipientID {
+		return ErrCannotMessageSelf
+	}
+
+	blocked, err := IsBlockedBetween(sender.ID, recipientID)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return ErrMessageBlocked
+	}
+
+	if sender.IsModerator() || GetUserLevel(sender.Points) >= DirectMessageMinLevel {
+		return nil
+	}
+
+	var allowed int
+	err = DB.QueryRow(`
+		SELECT
+			(SELECT COUNT(*) FROM follows WHERE follower_id = ? AND followed_id = ?) +
+			(SELECT COUNT(*) FROM direct_messages dm
+			 JOIN conversations c ON dm.conversation_id = c.id
+			 WHERE c.pair_key = ? AND dm.sender_id = ?)
+	`, recipientID, sender.ID, conversationPairKey(sender.ID, recipientID), recipientID).Scan(&allowed)
+	if err != nil {
+		return err
+	}
+	if allowed == 0 {
+		return ErrMessageNotAllowed
+	}
+	return nil
+}
+
+// 获取或创建两个用户之间的会话
+func GetOrCreateConversation(userID, peerID int) (int, error) {
+	if userID == peerID {
+		return 0, ErrCannotMessageSelf
+	}
+
+	pairKey := conversationPairKey(userID, peerID)
+	_, err := DB.Exec("INSERT IGNORE INTO conversations (pair_key, created_by) VALUES (?, ?)", pairKey, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	var conversationID int
+	if err := DB.QueryRow("SELECT id FROM conversations WHERE pair_key = ?", pairKey).Scan(&conversationID); err != nil {
+		return 0, err
+	}
+
+	_, err = DB.Exec("INSERT IGNORE INTO conversation_members (conversation_id, user_id) VALUES (?, ?), (?, ?)",
+		conversationID, userID, conversationID, peerID)
+	return conversationID, err
+}
+
+// 获取会话中的对方用户ID（同时校验当前用户是否是成员）
+func GetConversationPeer(conversationID, userID int) (int, error) {
+	var peerID int
+	err := DB.QueryRow(`
+		SELECT peer.user_id
+		FROM conversation_members me
+		JOIN conversation_members peer ON peer.conversation_id = me.conversation_id AND peer.user_id != me.user_id
+		WHERE me.conversation_id = ? AND me.user_id = ?
+	`, conversationID, userID).Scan(&peerID)
+	if err == sql.ErrNoRows {
+		return 0, ErrConversationNotFound
+	}
+	return peerID, err
+}
+
+// 发送私信
+func SendDirectMessage(conversationID int, sender *User, content string, attachments []*MessageAttachment) (*DirectMessage, error) {
+	content = strings.TrimSpace(content)
+	if content == "" && len(attachments) == 0 {
+		return nil, ErrEmptyDirectMessage
+	}
+
+	peerID, err := GetConversationPeer(conversationID, sender.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := CanMessageUser(sender, peerID); err != nil {
+		return nil, err
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	result, err := tx.Exec("INSERT INTO direct_messages (conversation_id, sender_id, content, created_at) VALUES (?, ?, ?, ?)",
+		conversationID, sender.ID, content, now)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	messageID := int(id)
+
+	for _, attachment := range attachments {
+		result, err := tx.Exec(`
+			INSERT INTO direct_message_attachments (message_id, conversation_id, file_name, file_path, file_size, content_type)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, messageID, conversationID, attachment.FileName, attachment.FilePath, attachment.FileSize, attachment.ContentType)
+		if err != nil {
+			return nil, err
+		}
+		attachmentID, _ := result.LastInsertId()
+		attachment.ID = int(attachmentID)
+		attachment.MessageID = messageID
+		attachment.ConversationID = conversationID
+		attachment.URL = attachmentURL(attachment)
+	}
+
+	_, err = tx.Exec("UPDATE conversations SET last_message_at = ? WHERE id = ?", now, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 自己发出的消息视为已读
+	_, err = tx.Exec("UPDATE conversation_members SET last_read_message_id = ? WHERE conversation_id = ? AND user_id = ?",
+		messageID, conversationID, sender.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if attachments == nil {
+		attachments = []*MessageAttachment{}
+	}
+	message := &DirectMessage{
+		ID:             messageID,
+		ConversationID: conversationID,
+		SenderID:       sender.ID,
+		SenderName:     sender.Username,
+		SenderAvatar:   sender.Avatar,
+		Content:        content,
+		Attachments:    attachments,
+		CreatedAt:      now,
+	}
+	hub.Publish(hub.UserTopic(peerID), "direct_message", message)
+	return message, nil
+}
+
+// 获取用户的会话列表
+func GetUserConversations(userID int) ([]*Conversation, error) {
+	rows, err := DB.Query(`
+		SELECT c.id, c.last_message_at, u.id, u.username, u.avatar,
+			   IFNULL((SELECT content FROM direct_messages WHERE conversation_id = c.id ORDER BY id DESC LIMIT 1), ''),
+			   (SELECT COUNT(*) FROM direct_messages
+			    WHERE conversation_id = c.id AND id > me.last_read_message_id AND sender_id != me.user_id),
+			   (SELECT COUNT(*) FROM user_blocks
+			    WHERE (blocker_id = me.user_id AND blocked_id = u.id) OR (blocker_id = u.id AND blocked_id = me.user_id))
+		FROM conversation_members me
+		JOIN conversations c ON me.conversation_id = c.id
+		JOIN conversation_members peer ON peer.conversation_id = c.id AND peer.user_id != me.user_id
+		JOIN users u ON peer.user_id = u.id
+		WHERE me.user_id = ? AND c.last_message_at IS NOT NULL
+		ORDER BY c.last_message_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var conversations []*Conversation
+	for rows.Next() {
+		conversation := &Conversation{Peer: &ConversationUser{}}
+		var lastMessageAt sql.NullTime
+		var blocked int
+		err := rows.Scan(&conversation.ID, &lastMessageAt, &conversation.Peer.ID, &conversation.Peer.Username,
+			&conversation.Peer.Avatar, &conversation.LastMessage, &conversation.UnreadCount, &blocked)
+		if err != nil {
+			return nil, err
+		}
+		if lastMessageAt.Valid {
+			conversation.LastMessageAt = &lastMessageAt.Time
+		}
+		conversation.LastMessage = notificationExcerpt(conversation.LastMessage)
+		conversation.Blocked = blocked > 0
+		conversations = append(conversations, conversation)
+	}
+	return conversations, rows.Err()
+}
+
+// 获取会话消息（按ID倒序，beforeID为0表示最新一页）
+func GetConversationMessages(conversationID, userID, beforeID, limit int) ([]*DirectMessage, error) {
+	peerID, err := GetConversationPeer(conversationID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var peerLastRead int
+	err = DB.QueryRow("SELECT last_read_message_id FROM conversation_members WHERE conversation_id = ? AND user_id = ?",
+		conversationID, peerID).Scan(&peerLastRead)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT dm.id, dm.sender_id, u.username, u.avatar, dm.content, dm.created_at
+		FROM direct_messages dm
+		JOIN users u ON dm.sender_id = u.id
+		WHERE dm.conversation_id = ?
+	`
+	args := []interface{}{conversationID}
+	if beforeID > 0 {
+		query += " AND dm.id < ?"
+		args = append(args, beforeID)
+	}
+	query += " ORDER BY dm.id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []*DirectMessage
+	byID := make(map[int]*DirectMessage)
+	for rows.Next() {
+		message := &DirectMessage{ConversationID: conversationID, Attachments: []*MessageAttachment{}}
+		err := rows.Scan(&message.ID, &message.SenderID, &message.SenderName, &message.SenderAvatar, &message.Content, &message.CreatedAt)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		message.IsRead = message.SenderID == userID && message.ID <= peerLastRead
+		messages = append(messages, message)
+		byID[message.ID] = message
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(messages) == 0 {
+		return messages, nil
+	}
+
+	// 加载本页消息的附件
+	rows, err = DB.Query(`
+		SELECT id, message_id, file_name, file_path, file_size, IFNULL(content_type, '')
+		FROM direct_message_attachments
+		WHERE conversation_id = ? AND message_id BETWEEN ? AND ?
+		ORDER BY id
+	`, conversationID, messages[len(messages)-1].ID, messages[0].ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		attachment := &MessageAttachment{ConversationID: conversationID}
+		err := rows.Scan(&attachment.ID, &attachment.MessageID, &attachment.FileName, &attachment.FilePath,
+			&attachment.FileSize, &attachment.ContentType)
+		if err != nil {
+			return nil, err
+		}
+		if message, ok := byID[attachment.MessageID]; ok {
+			attachment.URL = attachmentURL(attachment)
+			message.Attachments = append(message.Attachments, attachment)
+		}
+	}
+	return messages, rows.Err()
+}
+
+// 标记会话已读，并向对方推送已读回执
+func MarkConversationRead(conversationID, userID int) (int, error) {
+	peerID, err := GetConversationPeer(conversationID, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	var lastID int
+	err = DB.QueryRow("SELECT IFNULL(MAX(id), 0) FROM direct_messages WHERE conversation_id = ?", conversationID).Scan(&lastID)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := DB.Exec(`
+		UPDATE conversation_members SET last_read_message_id = ?
+		WHERE conversation_id = ? AND user_id = ? AND last_read_message_id < ?
+	`, lastID, conversationID, userID, lastID)
+	if err != nil {
+		return 0, err
+	}
+
+	if affected, _ := result.RowsAffected(); affected > 0 {
+		hub.Publish(hub.UserTopic(peerID), "conversation_read", map[string]int{
+			"conversation_id":      conversationID,
+			"last_read_message_id": lastID,
+		})
+	}
+	return lastID, nil
+}
+
+// 获取私信附件（仅会话成员可访问）
+func GetMessageAttachment(conversationID, attachmentID, userID int) (*MessageAttachment, error) {
+	if _, err := GetConversationPeer(conversationID, userID); err != nil {
+		return nil, err
+	}
+
+	attachment := &MessageAttachment{}
+	err := DB.QueryRow(`
+		SELECT id, message_id, conversation_id, file_name, file_path, file_size, IFNULL(content_type, '')
+		FROM direct_message_attachments
+		WHERE id = ? AND conversation_id = ?
+	`, attachmentID, conversationID).Scan(&attachment.ID, &attachment.MessageID, &attachment.ConversationID,
+		&attachment.FileName, &attachment.FilePath, &attachment.FileSize, &attachment.ContentType)
+	if err != nil {
+		return nil, err
+	}
+	attachment.URL = attachmentURL(attachment)
+	return attachment, nil
+}
+
+// 附件下载地址
+func attachmentURL(attachment *MessageAttachment) string {
+	return fmt.Sprintf("/api/user/conversations/%d/attachments/%d", attachment.ConversationID, attachment.ID)
+}
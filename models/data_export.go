@@ -0,0 +1,549 @@
+package models
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// 数据导出任务状态
+const (
+	ExportStatusPending    = "pending"
+	ExportStatusProcessing = "processing"
+	ExportStatusReady      = "ready"
+	ExportStatusFailed     = "failed"
+	ExportStatusExpired    = "expired"
+)
+
+// 导出文件的保留时间
+const ExportLinkTTL = 7 * 24 * time.Hour
+
+// 处理中的任务超过该时间未完成视为中断，可重新领取
+const exportProcessingLease = 30 * time.Minute
+
+// 数据导出任务
+type DataExport struct {
+	ID          int        `json:"id"`
+	UserID      int        `json:"-"`
+	Status      string     `json:"status"`
+	FilePath    string     `json:"-"`
+	FileSize    int64      `json:"file_size"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+// 导出文件是否可以下载
+func (e *DataExport) Downloadable() bool {
+	return e.Status == ExportStatusReady && e.ExpiresAt != nil && time.Now().Before(*e.ExpiresAt)
+}
+
+const dataExportColumns = `id, user_id, status, IFNULL(file_path, ''), file_size, IFNULL(error, ''),
+	created_at, completed_at, expires_at`
+
+func scanDataExport(row interface{ Scan(...interface{}) error }) (*DataExport, error) {
+	e := &DataExport{}
+	var completedAt, expiresAt sql.NullTime
+	err := row.Scan(&e.ID, &e.UserID, &e.Status, &e.FilePath, &e.FileSize, &e.Error,
+		&e.CreatedAt, &completedAt, &expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if completedAt.Valid {
+		e.CompletedAt = &completedAt.Time
+	}
+	if expiresAt.Valid {
+		e.ExpiresAt = &expiresAt.Time
+	}
+	return e, nil
+}
+
+// 申请导出数据，已有未完成的任务时直接返回该任务
+func RequestDataExport(userID int) (*DataExport, error) {
+	existing, err := scanDataExport(DB.QueryRow(`
+		SELECT `+dataExportColumns+` FROM data_exports
+		WHERE user_id = ? AND status IN (?, ?)
+		ORDER BY id DESC LIMIT 1
+	`, userID, ExportStatusPending, ExportStatusProcessing))
+	if err == nil {
+		return existing, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	result, err := DB.Exec("INSERT INTO data_exports (user_id, status) VALUES (?, ?)", userID, ExportStatusPending)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return GetDataExport(userID, int(id))
+}
+
+// 获取用户的导出任务
+func GetDataExport(userID, exportID int) (*DataExport, error) {
+	return scanDataExport(DB.QueryRow("SELECT "+dataExportColumns+" FROM data_exports WHERE id = ? AND user_id = ?",
+		exportID, userID))
+}
+
+// 获取用户最近一次导出任务
+func GetLatestDataExport(userID int) (*DataExport, error) {
+	return scanDataExport(DB.QueryRow("SELECT "+dataExportColumns+" FROM data_exports WHERE user_id = ? ORDER BY id DESC LIMIT 1",
+		userID))
+}
+
+// 领取一个待处理的导出任务（包括处理超时的任务），没有任务时返回nil
+func ClaimDataExport() (*DataExport, error) {
+	for {
+		e, err := scanDataExport(DB.QueryRow(`
+			SELECT `+dataExportColumns+` FROM data_exports
+			WHERE status = ? OR (status = ? AND started_at < ?)
+			ORDER BY id LIMIT 1
+		`, ExportStatusPending, ExportStatusProcessing, time.Now().Add(-exportProcessingLease)))
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := DB.Exec(`
+			UPDATE data_exports SET status = ?, started_at = NOW()
+			WHERE id = ? AND status = ? AND (status = ? OR started_at < ?)
+		`, ExportStatusProcessing, e.ID, e.Status, ExportStatusPending, time.Now().Add(-exportProcessingLease))
+		if err != nil {
+			return nil, err
+		}
+		// 被其他进程抢先领取时重试
+		if affected, _ := result.RowsAffected(); affected > 0 {
+			e.Status = ExportStatusProcessing
+			return e, nil
+		}
+	}
+}
+
+// 导出完成
+func CompleteDataExport(exportID int, filePath string, fileSize int64) error {
+	_, err := DB.Exec(`
+		UPDATE data_exports SET status = ?, file_path = ?, file_size = ?, error = NULL,
+			completed_at = NOW(), expires_at = ?
+		WHERE id = ?
+	`, ExportStatusReady, filePath, fileSize, time.Now().Add(ExportLinkTTL), exportID)
+	return err
+}
+
+// 导出失败
+func FailDataExport(exportID int, reason string) error {
+	if len(reason) > 500 {
+		reason = reason[:500]
+	}
+	_, err := DB.Exec("UPDATE data_exports SET status = ?, error = ?, completed_at = NOW() WHERE id = ?",
+		ExportStatusFailed, reason, exportID)
+	return err
+}
+
+// 将过期的导出任务标记为已过期，返回需要删除的导出文件
+func ExpireDataExports() ([]string, error) {
+	rows, err := DB.Query("SELECT id, IFNULL(file_path, '') FROM data_exports WHERE status = ? AND expires_at < NOW()",
+		ExportStatusReady)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []interface{}
+	var paths []string
+	for rows.Next() {
+		var id int
+		var path string
+		if err := rows.Scan(&id, &path); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	if err := rows.Err(); err != nil || len(ids) == 0 {
+		return nil, err
+	}
+
+	args := append([]interface{}{ExportStatusExpired}, ids...)
+	_, err = DB.Exec("UPDATE data_exports SET status = ?, file_path = NULL WHERE id IN (?"+
+		strings.Repeat(", ?", len(ids)-1)+")", args...)
+	return paths, err
+}
+
+// 导出的个人资料
+type ExportProfile struct {
+	ID                    int       `json:"id"`
+	Username              string    `json:"username"`
+	Email                 string    `json:"email"`
+	Avatar                string    `json:"avatar"`
+	Bio                   string    `json:"bio"`
+	Phone                 string    `json:"phone"`
+	Website               string    `json:"website"`
+	Points                int       `json:"points"`
+	Level                 int       `json:"level"`
+	Role                  string    `json:"role"`
+	ProfilePublic         bool      `json:"profile_public"`
+	ShowEmail             bool      `json:"show_email"`
+	ShowPhone             bool      `json:"show_phone"`
+	EmailNotifications    bool      `json:"email_notifications"`
+	BrowserNotifications  bool      `json:"browser_notifications"`
+	QuestionNotifications bool      `json:"question_notifications"`
+	FollowNotifications   bool      `json:"follow_notifications"`
+	MentionNotifications  bool      `json:"mention_notifications"`
+	EmailDigest           string    `json:"email_digest"`
+	CreatedAt             time.Time `json:"created_at"`
+}
+
+// 导出的提问、帖子和文章
+type ExportPost struct {
+	ID         int       `json:"id"`
+	Title      string    `json:"title"`
+	Content    string    `json:"content"`
+	Summary    string    `json:"summary,omitempty"`
+	Category   string    `json:"category,omitempty"`
+	Tags       string    `json:"tags"`
+	CoverImage string    `json:"cover_image,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// 导出的回答
+type ExportAnswer struct {
+	ID            int       `json:"id"`
+	QuestionID    int       `json:"question_id"`
+	QuestionTitle string    `json:"question_title"`
+	Content       string    `json:"content"`
+	IsAccepted    bool      `json:"is_accepted"`
+	LikeCount     int       `json:"like_count"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// 导出的学习资料
+type ExportResource struct {
+	ID          int       `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Type        string    `json:"type"`
+	Level       string    `json:"level"`
+	Category    string    `json:"category"`
+	Tags        string    `json:"tags"`
+	CoverImage  string    `json:"cover_image"`
+	FilePaths   []string  `json:"file_paths"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// 导出的回复和评论（target为所在内容）
+type ExportComment struct {
+	ID          int       `json:"id"`
+	Type        string    `json:"type"` // reply, article_comment, resource_comment
+	TargetID    int       `json:"target_id"`
+	TargetTitle string    `json:"target_title"`
+	Content     string    `json:"content"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// 导出的私信会话
+type ExportConversation struct {
+	ID       int                    `json:"id"`
+	Peer     string                 `json:"peer"`
+	Messages []*ExportDirectMessage `json:"messages"`
+}
+
+// 导出的私信
+type ExportDirectMessage struct {
+	ID          int                  `json:"id"`
+	Sender      string               `json:"sender"`
+	FromMe      bool                 `json:"from_me"`
+	Content     string               `json:"content"`
+	Attachments []*MessageAttachment `json:"attachments,omitempty"`
+	CreatedAt   time.Time            `json:"created_at"`
+}
+
+// 用户的全部数据
+type UserExportData struct {
+	ExportedAt    time.Time             `json:"exported_at"`
+	Profile       *ExportProfile        `json:"profile"`
+	Questions     []*ExportPost         `json:"questions"`
+	Answers       []*ExportAnswer       `json:"answers"`
+	Posts         []*ExportPost         `json:"posts"`
+	Articles      []*ExportPost         `json:"articles"`
+	Resources     []*ExportResource     `json:"resources"`
+	Comments      []*ExportComment      `json:"comments"`
+	Bookmarks     []*Bookmark           `json:"bookmarks"`
+	Collections   []*BookmarkCollection `json:"collections"`
+	Following     []UserFollowing       `json:"following"`
+	Followers     []UserFollower        `json:"followers"`
+	Notifications []UserMessage         `json:"notifications"`
+	Conversations []*ExportConversation `json:"conversations"`
+	SearchHistory []SearchQuery         `json:"search_history"`
+}
+
+// 导出搜索历史的最大条数
+const exportSearchHistoryLimit = 10000
+
+// 收集用户的全部数据
+func CollectUserExportData(userID int) (*UserExportData, error) {
+	data := &UserExportData{ExportedAt: time.Now()}
+	var err error
+
+	if data.Profile, err = getExportProfile(userID); err != nil {
+		return nil, err
+	}
+	if data.Questions, err = queryExportPosts(`
+		SELECT id, title, content, IFNULL(summary, ''), '', IFNULL(tags, ''), '', created_at, updated_at
+		FROM questions WHERE user_id = ? ORDER BY id`, userID); err != nil {
+		return nil, err
+	}
+	if data.Posts, err = queryExportPosts(`
+		SELECT id, title, content, '', '', IFNULL(tags, ''), '', created_at, updated_at
+		FROM posts WHERE user_id = ? ORDER BY id`, userID); err != nil {
+		return nil, err
+	}
+	if data.Articles, err = queryExportPosts(`
+		SELECT id, title, content, IFNULL(summary, ''), category, IFNULL(tags, ''), IFNULL(cover_image, ''), created_at, updated_at
+		FROM tech_articles WHERE user_id = ? ORDER BY id`, userID); err != nil {
+		return nil, err
+	}
+	if data.Answers, err = getExportAnswers(userID); err != nil {
+		return nil, err
+	}
+	if data.Resources, err = getExportResources(userID); err != nil {
+		return nil, err
+	}
+	if data.Comments, err = getExportComments(userID); err != nil {
+		return nil, err
+	}
+	if data.Bookmarks, err = GetUserBookmarks(userID, 0, ""); err != nil {
+		return nil, err
+	}
+	if data.Collections, err = GetUserCollections(userID, false); err != nil {
+		return nil, err
+	}
+	if data.Following, err = fetchAll(func(page PageQuery) ([]UserFollowing, PageInfo, error) {
+		return GetUserFollowing(userID, page)
+	}); err != nil {
+		return nil, err
+	}
+	if data.Followers, err = fetchAll(func(page PageQuery) ([]UserFollower, PageInfo, error) {
+		return GetUserFollowers(userID, page)
+	}); err != nil {
+		return nil, err
+	}
+	if data.Notifications, err = fetchAll(func(page PageQuery) ([]UserMessage, PageInfo, error) {
+		return GetUserMessages(userID, page)
+	}); err != nil {
+		return nil, err
+	}
+	if data.Conversations, err = getExportConversations(userID); err != nil {
+		return nil, err
+	}
+	if data.SearchHistory, err = GetUserSearchHistory(userID, exportSearchHistoryLimit); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func getExportProfile(userID int) (*ExportProfile, error) {
+	p := &ExportProfile{}
+	var profilePublic, showEmail, showPhone, emailN, browserN, questionN, followN, mentionN sql.NullBool
+	err := DB.QueryRow(`
+		SELECT id, username, email, avatar, IFNULL(bio, ''), IFNULL(phone, ''), IFNULL(website, ''),
+			   points, role, profile_public, show_email, show_phone, email_notifications,
+			   browser_notifications, question_notifications, follow_notifications,
+			   mention_notifications, email_digest, created_at
+		FROM users WHERE id = ?
+	`, userID).Scan(&p.ID, &p.Username, &p.Email, &p.Avatar, &p.Bio, &p.Phone, &p.Website,
+		&p.Points, &p.Role, &profilePublic, &showEmail, &showPhone, &emailN,
+		&browserN, &questionN, &followN, &mentionN, &p.EmailDigest, &p.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	p.Level = GetUserLevel(p.Points)
+	p.ProfilePublic = profilePublic.Bool
+	p.ShowEmail = showEmail.Bool
+	p.ShowPhone = showPhone.Bool
+	p.EmailNotifications = emailN.Bool
+	p.BrowserNotifications = browserN.Bool
+	p.QuestionNotifications = questionN.Bool
+	p.FollowNotifications = followN.Bool
+	p.MentionNotifications = mentionN.Bool
+	return p, nil
+}
+
+func queryExportPosts(query string, userID int) ([]*ExportPost, error) {
+	rows, err := DB.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	posts := []*ExportPost{}
+	for rows.Next() {
+		p := &ExportPost{}
+		if err := rows.Scan(&p.ID, &p.Title, &p.Content, &p.Summary, &p.Category, &p.Tags, &p.CoverImage,
+			&p.CreatedAt, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		posts = append(posts, p)
+	}
+	return posts, rows.Err()
+}
+
+func getExportAnswers(userID int) ([]*ExportAnswer, error) {
+	rows, err := DB.Query(`
+		SELECT a.id, a.question_id, q.title, a.content, a.is_accepted, a.like_count, a.created_at
+		FROM answers a
+		JOIN questions q ON a.question_id = q.id
+		WHERE a.user_id = ?
+		ORDER BY a.id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	answers := []*ExportAnswer{}
+	for rows.Next() {
+		a := &ExportAnswer{}
+		if err := rows.Scan(&a.ID, &a.QuestionID, &a.QuestionTitle, &a.Content, &a.IsAccepted, &a.LikeCount, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		answers = append(answers, a)
+	}
+	return answers, rows.Err()
+}
+
+func getExportResources(userID int) ([]*ExportResource, error) {
+	rows, err := DB.Query(`
+		SELECT id, title, IFNULL(description, ''), type, IFNULL(level, ''), IFNULL(category, ''),
+			   IFNULL(tags, ''), IFNULL(cover_image, ''), IFNULL(file_paths, ''), created_at
+		FROM learning_resources WHERE user_id = ?
+		ORDER BY id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	resources := []*ExportResource{}
+	for rows.Next() {
+		r := &ExportResource{}
+		var filePaths string
+		if err := rows.Scan(&r.ID, &r.Title, &r.Description, &r.Type, &r.Level, &r.Category,
+			&r.Tags, &r.CoverImage, &filePaths, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		for _, path := range strings.Split(filePaths, ",") {
+			if path = strings.TrimSpace(path); path != "" {
+				r.FilePaths = append(r.FilePaths, path)
+			}
+		}
+		resources = append(resources, r)
+	}
+	return resources, rows.Err()
+}
+
+func getExportComments(userID int) ([]*ExportComment, error) {
+	rows, err := DB.Query(`
+		SELECT r.id, 'reply', r.post_id, p.title, r.content, r.created_at
+		FROM replies r JOIN posts p ON r.post_id = p.id
+		WHERE r.user_id = ?
+		UNION ALL
+		SELECT c.id, 'article_comment', c.article_id, t.title, c.content, c.created_at
+		FROM article_comments c JOIN tech_articles t ON c.article_id = t.id
+		WHERE c.user_id = ?
+		UNION ALL
+		SELECT c.id, 'resource_comment', c.resource_id, lr.title, c.content, c.created_at
+		FROM resource_comments c JOIN learning_resources lr ON c.resource_id = lr.id
+		WHERE c.user_id = ?
+		ORDER BY created_at
+	`, userID, userID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := []*ExportComment{}
+	for rows.Next() {
+		c := &ExportComment{}
+		if err := rows.Scan(&c.ID, &c.Type, &c.TargetID, &c.TargetTitle, &c.Content, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		comments = append(comments, c)
+	}
+	return comments, rows.Err()
+}
+
+// 导出用户参与的全部私信会话
+func getExportConversations(userID int) ([]*ExportConversation, error) {
+	rows, err := DB.Query(`
+		SELECT c.id, dm.id, u.username, dm.sender_id, dm.content, dm.created_at,
+			   (SELECT pu.username FROM conversation_members pm JOIN users pu ON pm.user_id = pu.id
+				WHERE pm.conversation_id = c.id AND pm.user_id <> ? LIMIT 1)
+		FROM conversation_members m
+		JOIN conversations c ON m.conversation_id = c.id
+		JOIN direct_messages dm ON dm.conversation_id = c.id
+		JOIN users u ON dm.sender_id = u.id
+		WHERE m.user_id = ?
+		ORDER BY c.id, dm.id
+	`, userID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	conversations := []*ExportConversation{}
+	messages := make(map[int]*ExportDirectMessage)
+	for rows.Next() {
+		var conversationID, senderID int
+		var peer sql.NullString
+		m := &ExportDirectMessage{}
+		if err := rows.Scan(&conversationID, &m.ID, &m.Sender, &senderID, &m.Content, &m.CreatedAt, &peer); err != nil {
+			return nil, err
+		}
+		m.FromMe = senderID == userID
+
+		if n := len(conversations); n == 0 || conversations[n-1].ID != conversationID {
+			conversations = append(conversations, &ExportConversation{ID: conversationID, Peer: peer.String})
+		}
+		current := conversations[len(conversations)-1]
+		current.Messages = append(current.Messages, m)
+		messages[m.ID] = m
+	}
+	if err := rows.Err(); err != nil || len(messages) == 0 {
+		return conversations, err
+	}
+
+	attachmentRows, err := DB.Query(`
+		SELECT a.id, a.message_id, a.conversation_id, a.file_name, a.file_path, a.file_size, IFNULL(a.content_type, '')
+		FROM direct_message_attachments a
+		JOIN conversation_members m ON m.conversation_id = a.conversation_id
+		WHERE m.user_id = ?
+		ORDER BY a.id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer attachmentRows.Close()
+
+	for attachmentRows.Next() {
+		a := &MessageAttachment{}
+		if err := attachmentRows.Scan(&a.ID, &a.MessageID, &a.ConversationID, &a.FileName, &a.FilePath,
+			&a.FileSize, &a.ContentType); err != nil {
+			return nil, err
+		}
+		if m, ok := messages[a.MessageID]; ok {
+			a.URL = attachmentURL(a)
+			m.Attachments = append(m.Attachments, a)
+		}
+	}
+	return conversations, attachmentRows.Err()
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"aiforum/config"
@@ -22,6 +23,7 @@ type User struct {
 	Avatar    string    `json:"avatar"`
 	Level     int       `json:"level"`
 	Points    int       `json:"points"`
+	Role      string    `json:"role"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -64,8 +66,12 @@ type Category struct {
 
 // 标签模型
 type Tag struct {
-	ID   int    `json:"id"`
-	Name string `json:"name"`
+	ID          int          `json:"id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Wiki        string       `json:"wiki,omitempty"`
+	UsageCount  int          `json:"usage_count"`
+	Synonyms    []TagSynonym `json:"synonyms,omitempty"`
 }
 
 // 初始化数据库
@@ -98,10 +104,148 @@ func InitDB() error {
 		return err
 	}
 
+	// 补齐旧数据库缺少的列
+	if err := upgradeTables(); err != nil {
+		return err
+	}
+
+	// 迁移旧的逗号分隔标签
+	MigrateContentTags()
+
+	// 合并旧的作者关注表
+	MigrateFollows()
+
+	// 导入旧的收藏表
+	MigrateBookmarks()
+
+	// 创建注销用户的占位账户
+	if err := EnsureGhostUser(); err != nil {
+		return err
+	}
+
 	log.Println("数据库连接成功")
 	return nil
 }
 
+// 检查当前数据库中是否存在某张表
+func tableExists(table string) (bool, error) {
+	var count int
+	err := DB.QueryRow(`
+		SELECT COUNT(*) FROM information_schema.tables
+		WHERE table_schema = DATABASE() AND table_name = ?
+	`, table).Scan(&count)
+	return count > 0, err
+}
+
+// 检查表中是否存在某一列
+func columnExists(table, column string) (bool, error) {
+	var count int
+	err := DB.QueryRow(`
+		SELECT COUNT(*) FROM information_schema.columns
+		WHERE table_schema = DATABASE() AND table_name = ? AND column_name = ?
+	`, table, column).Scan(&count)
+	return count > 0, err
+}
+
+// 新版本给已有的表增加的列。CREATE TABLE IF NOT EXISTS不会修改旧数据库中的表，
+// 启动时逐一检查并补齐，definition可以附带同时创建的索引
+var columnUpgrades = []struct {
+	table, column, definition string
+}{
+	{"users", "role", "VARCHAR(20) NOT NULL DEFAULT 'user'"},
+	{"questions", "duplicate_of", "INT NULL"},
+	{"questions", "closed_by", "INT NULL"},
+	{"questions", "closed_at", "TIMESTAMP NULL"},
+	{"questions", "status", "VARCHAR(20) NOT NULL DEFAULT 'open'"},
+	{"questions", "close_reason", "VARCHAR(20) NULL"},
+	{"questions", "is_locked", "BOOLEAN DEFAULT FALSE"},
+	{"questions", "is_protected", "BOOLEAN DEFAULT FALSE"},
+	{"questions", "is_wiki", "BOOLEAN DEFAULT FALSE"},
+	{"tags", "description", "VARCHAR(500)"},
+	{"tags", "wiki", "TEXT"},
+	{"tags", "usage_count", "INT DEFAULT 0"},
+	{"users", "email_digest", "VARCHAR(10) NOT NULL DEFAULT 'off'"},
+	{"users", "last_digest_at", "TIMESTAMP NULL"},
+	{"users", "deletion_scheduled_at", "TIMESTAMP NULL"},
+	{"questions", "deleted_at", "TIMESTAMP NULL, ADD INDEX idx_questions_deleted (deleted_at)"},
+	{"answers", "deleted_at", "TIMESTAMP NULL, ADD INDEX idx_answers_deleted (deleted_at)"},
+	{"tech_articles", "deleted_at", "TIMESTAMP NULL, ADD INDEX idx_tech_articles_deleted (deleted_at)"},
+	{"learning_resources", "deleted_at", "TIMESTAMP NULL, ADD INDEX idx_learning_resources_deleted (deleted_at)"},
+	{"users", "mention_notifications", "BOOLEAN DEFAULT TRUE"},
+	{"users", "follower_count", "INT NOT NULL DEFAULT 0"},
+	{"users", "following_count", "INT NOT NULL DEFAULT 0"},
+	{"users", "totp_secret", "VARCHAR(64) NULL"},
+	{"users", "totp_enabled", "BOOLEAN NOT NULL DEFAULT FALSE"},
+	{"users", "totp_last_step", "BIGINT NOT NULL DEFAULT 0"},
+}
+
+// 本次启动时新增的列（表名.列名），供后续的迁移回填数据
+var addedColumns = map[string]bool{}
+
+// 本次启动时是否新增了某一列
+func columnAdded(table, column string) bool {
+	return addedColumns[table+"."+column]
+}
+
+// 补齐旧数据库缺少的列，不存在的表跳过（由init_db.sql创建）
+func upgradeTables() error {
+	for _, u := range columnUpgrades {
+		hasTable, err := tableExists(u.table)
+		if err != nil {
+			return err
+		}
+		hasColumn := false
+		if hasTable {
+			if hasColumn, err = columnExists(u.table, u.column); err != nil {
+				return err
+			}
+		}
+		if !hasTable || hasColumn {
+			continue
+		}
+		if _, err := DB.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", u.table, u.column, u.definition)); err != nil {
+			return fmt.Errorf("为%s表添加%s列失败: %v", u.table, u.column, err)
+		}
+		addedColumns[u.table+"."+u.column] = true
+		log.Printf("已为%s表添加%s列", u.table, u.column)
+	}
+	return nil
+}
+
+// 生成IN条件的占位符和参数
+func inClause(ids []int) (string, []interface{}) {
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", "), args
+}
+
+// 批量检查用户与多条内容之间是否存在关联（点赞、收藏等），返回存在关联的内容ID
+func linkedIDs(table, column string, userID int, ids []int, extra string, extraArgs ...interface{}) (map[int]bool, error) {
+	linked := make(map[int]bool)
+	if len(ids) == 0 || userID == 0 {
+		return linked, nil
+	}
+	in, args := inClause(ids)
+	args = append(append([]interface{}{userID}, extraArgs...), args...)
+	rows, err := DB.Query(fmt.Sprintf("SELECT %[2]s FROM %[1]s WHERE user_id = ? %[3]s AND %[2]s IN (%[4]s)",
+		table, column, extra, in), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		linked[id] = true
+	}
+	return linked, rows.Err()
+}
+
 // 创建数据表
 func createTables() error {
 	// 用户表
@@ -114,6 +258,26 @@ func createTables() error {
 		avatar VARCHAR(255) DEFAULT '/images/user.jpg',
 		level INT DEFAULT 1,
 		points INT DEFAULT 0,
+		role VARCHAR(20) NOT NULL DEFAULT 'user',
+		bio TEXT,
+		phone VARCHAR(20),
+		website VARCHAR(255),
+		profile_public BOOLEAN DEFAULT TRUE,
+		show_email BOOLEAN DEFAULT FALSE,
+		show_phone BOOLEAN DEFAULT FALSE,
+		email_notifications BOOLEAN DEFAULT TRUE,
+		browser_notifications BOOLEAN DEFAULT TRUE,
+		question_notifications BOOLEAN DEFAULT TRUE,
+		follow_notifications BOOLEAN DEFAULT TRUE,
+		mention_notifications BOOLEAN DEFAULT TRUE,
+		email_digest VARCHAR(10) NOT NULL DEFAULT 'off',
+		last_digest_at TIMESTAMP NULL,
+		follower_count INT NOT NULL DEFAULT 0,
+		following_count INT NOT NULL DEFAULT 0,
+		deletion_scheduled_at TIMESTAMP NULL,
+		totp_secret VARCHAR(64) NULL,
+		totp_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+		totp_last_step BIGINT NOT NULL DEFAULT 0,
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
 	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -177,8 +341,18 @@ func createTables() error {
 		reward INT DEFAULT 0,
 		is_solved BOOLEAN DEFAULT FALSE,
 		summary TEXT,
+		status VARCHAR(20) NOT NULL DEFAULT 'open',
+		close_reason VARCHAR(20) NULL,
+		duplicate_of INT NULL,
+		closed_by INT NULL,
+		closed_at TIMESTAMP NULL,
+		is_locked BOOLEAN DEFAULT FALSE,
+		is_protected BOOLEAN DEFAULT FALSE,
+		is_wiki BOOLEAN DEFAULT FALSE,
+		deleted_at TIMESTAMP NULL,
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+		INDEX idx_questions_deleted (deleted_at),
 		FOREIGN KEY (category_id) REFERENCES categories(id),
 		FOREIGN KEY (user_id) REFERENCES users(id)
 	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -193,7 +367,9 @@ func createTables() error {
 		content TEXT NOT NULL,
 		like_count INT DEFAULT 0,
 		is_accepted BOOLEAN DEFAULT FALSE,
+		deleted_at TIMESTAMP NULL,
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		INDEX idx_answers_deleted (deleted_at),
 		FOREIGN KEY (question_id) REFERENCES questions(id) ON DELETE CASCADE,
 		FOREIGN KEY (user_id) REFERENCES users(id)
 	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -217,11 +393,39 @@ func createTables() error {
 	CREATE TABLE IF NOT EXISTS tags (
 		id INT AUTO_INCREMENT PRIMARY KEY,
 		name VARCHAR(50) UNIQUE NOT NULL,
+		description VARCHAR(500),
+		wiki TEXT,
+		usage_count INT DEFAULT 0,
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
 	`
 
-	tables := []string{userTable, categoryTable, postTable, replyTable, questionTable, answerTable, answerLikeTable, tagTable}
+	// 标签同义词表
+	tagSynonymTable := `
+	CREATE TABLE IF NOT EXISTS tag_synonyms (
+		id INT AUTO_INCREMENT PRIMARY KEY,
+		name VARCHAR(50) UNIQUE NOT NULL,
+		tag_id INT NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+	`
+
+	// 内容标签关联表
+	contentTagTable := `
+	CREATE TABLE IF NOT EXISTS content_tags (
+		id INT AUTO_INCREMENT PRIMARY KEY,
+		tag_id INT NOT NULL,
+		content_type VARCHAR(20) NOT NULL,
+		content_id INT NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE KEY unique_content_tag (content_type, content_id, tag_id),
+		INDEX idx_content_tags_tag (tag_id, content_type, content_id),
+		FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+	`
+
+	tables := []string{userTable, categoryTable, postTable, replyTable, questionTable, answerTable, answerLikeTable, tagTable, tagSynonymTable, contentTagTable}
 	
 	for _, table := range tables {
 		_, err := DB.Exec(table)
@@ -0,0 +1,219 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"aiforum/utils"
+)
+
+// 可关注的兴趣类型
+const (
+	InterestTypeTag      = "tag"
+	InterestTypeCategory = "category"
+)
+
+// 无效的关注类型
+var ErrInvalidInterestType = errors.New("无效的关注类型")
+
+// 关注的标签或分类
+type Interest struct {
+	Type      string    `json:"type"`
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// 动态流条目
+type FeedItem struct {
+	Type       string    `json:"type"` // question, article, resource
+	ID         int       `json:"id"`
+	Title      string    `json:"title"`
+	Summary    string    `json:"summary"`
+	Tags       string    `json:"tags"`
+	UserID     int       `json:"user_id"`
+	Username   string    `json:"username"`
+	UserAvatar string    `json:"user_avatar"`
+	URL        string    `json:"url"`
+	Seen       bool      `json:"seen"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// 动态流条目引用（用于标记已读）
+type FeedItemRef struct {
+	Type string `json:"type"`
+	ID   int    `json:"id"`
+}
+
+// 动态流内容的详情页地址
+var feedItemURLs = map[string]string{
+	ContentTypeQuestion: "/qa/",
+	ContentTypeArticle:  "/tech-share/",
+	ContentTypeResource: "/learning-resources/",
+}
+
+// 关注标签或分类
+func FollowInterest(userID int, targetType string, targetID int) error {
+	var table string
+	switch targetType {
+	case InterestTypeTag:
+		table = "tags"
+	case InterestTypeCategory:
+		table = "categories"
+	default:
+		return ErrInvalidInterestType
+	}
+
+	var exists int
+	if err := DB.QueryRow("SELECT 1 FROM "+table+" WHERE id = ?", targetID).Scan(&exists); err != nil {
+		return err
+	}
+
+	_, err := DB.Exec("INSERT IGNORE INTO interest_follows (user_id, target_type, target_id) VALUES (?, ?, ?)",
+		userID, targetType, targetID)
+	return err
+}
+
+// 取消关注标签或分类
+func UnfollowInterest(userID int, targetType string, targetID int) error {
+	_, err := DB.Exec("DELETE FROM interest_follows WHERE user_id = ? AND target_type = ? AND target_id = ?",
+		userID, targetType, targetID)
+	return err
+}
+
+// 获取用户关注的标签和分类
+func GetUserInterests(userID int) ([]Interest, error) {
+	query := `
+		SELECT f.target_type, f.target_id, t.name, f.created_at
+		FROM interest_follows f
+		JOIN tags t ON f.target_type = 'tag' AND f.target_id = t.id
+		WHERE f.user_id = ?
+		UNION ALL
+		SELECT f.target_type, f.target_id, c.name, f.created_at
+		FROM interest_follows f
+		JOIN categories c ON f.target_type = 'category' AND f.target_id = c.id
+		WHERE f.user_id = ?
+		ORDER BY created_at DESC
+	`
+
+	rows, err := DB.Query(query, userID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var interests []Interest
+	for rows.Next() {
+		var interest Interest
+		err := rows.Scan(&interest.Type, &interest.ID, &interest.Name, &interest.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		interests = append(interests, interest)
+	}
+
+	return interests, nil
+}
+
+// 生成动态流中某类内容的查询（关注的作者或标签发布的内容，match为额外的匹配条件，filter为额外的过滤条件）
+// 游标、静音屏蔽和未读条件都在分支内过滤，每个分支只取前limit条再合并，避免UNION扫描整张内容表
+func feedBranch(contentType, table, summary, match, filter string, userID int, before *utils.Cursor, limit int, unseenOnly bool) (string, []interface{}) {
+	query := fmt.Sprintf(`
+		SELECT '%[1]s' AS content_type, c.id, c.title, %[3]s AS summary, IFNULL(c.tags, '') AS tags,
+			   c.user_id, u.username, u.avatar, c.created_at
+		FROM %[2]s c
+		JOIN users u ON c.user_id = u.id
+		WHERE c.user_id <> ? AND c.deleted_at IS NULL AND (
+			c.user_id IN (SELECT followed_id FROM follows WHERE follower_id = ?)
+			OR EXISTS (
+				SELECT 1 FROM content_tags ct
+				JOIN interest_follows f ON f.target_type = 'tag' AND f.target_id = ct.tag_id
+				WHERE f.user_id = ? AND ct.content_type = '%[1]s' AND ct.content_id = c.id
+			)%[4]s
+		)%[5]s`, contentType, table, summary, match, filter) + hiddenAuthorFilter("c.user_id")
+	args := []interface{}{userID, userID, userID}
+	// match条件中的参数都是用户ID
+	for i := 0; i < strings.Count(match, "?"); i++ {
+		args = append(args, userID)
+	}
+	args = append(args, userID, userID)
+
+	if before != nil {
+		query += fmt.Sprintf(` AND (c.created_at < ? OR (c.created_at = ? AND ('%[1]s' < ?
+			OR ('%[1]s' = ? AND c.id < ?))))`, contentType)
+		args = append(args, before.CreatedAt, before.CreatedAt, before.Type, before.Type, before.ID)
+	}
+	if unseenOnly {
+		query += fmt.Sprintf(` AND NOT EXISTS (
+			SELECT 1 FROM feed_seen s WHERE s.user_id = ? AND s.content_type = '%s' AND s.content_id = c.id)`, contentType)
+		args = append(args, userID)
+	}
+	query += " ORDER BY c.created_at DESC, c.id DESC LIMIT ?"
+	args = append(args, limit)
+
+	return "(" + query + ")", args
+}
+
+// 获取用户的个性化动态流（按发布时间倒序，before为上一页最后一条的游标）
+func GetUserFeed(userID int, before *utils.Cursor, limit int, unseenOnly bool) ([]*FeedItem, error) {
+	questions, questionArgs := feedBranch(ContentTypeQuestion, "questions", "IFNULL(c.summary, '')",
+		" OR c.category_id IN (SELECT target_id FROM interest_follows WHERE user_id = ? AND target_type = 'category')",
+		" AND c.duplicate_of IS NULL", userID, before, limit, unseenOnly)
+	articles, articleArgs := feedBranch(ContentTypeArticle, "tech_articles", "IFNULL(c.summary, '')", "", "",
+		userID, before, limit, unseenOnly)
+	resources, resourceArgs := feedBranch(ContentTypeResource, "learning_resources", "LEFT(IFNULL(c.description, ''), 200)", "", "",
+		userID, before, limit, unseenOnly)
+
+	query := `
+		SELECT feed.content_type, feed.id, feed.title, feed.summary, feed.tags,
+			   feed.user_id, feed.username, feed.avatar, feed.created_at, s.user_id IS NOT NULL AS seen
+		FROM (` + questions + ` UNION ALL ` + articles + ` UNION ALL ` + resources + `) feed
+		LEFT JOIN feed_seen s ON s.user_id = ? AND s.content_type = feed.content_type AND s.content_id = feed.id
+		ORDER BY feed.created_at DESC, feed.content_type DESC, feed.id DESC LIMIT ?
+	`
+	var args []interface{}
+	args = append(args, questionArgs...)
+	args = append(args, articleArgs...)
+	args = append(args, resourceArgs...)
+	args = append(args, userID, limit)
+
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []*FeedItem
+	for rows.Next() {
+		item := &FeedItem{}
+		err := rows.Scan(
+			&item.Type, &item.ID, &item.Title, &item.Summary, &item.Tags,
+			&item.UserID, &item.Username, &item.UserAvatar, &item.CreatedAt, &item.Seen,
+		)
+		if err != nil {
+			return nil, err
+		}
+		item.URL = feedItemURLs[item.Type] + strconv.Itoa(item.ID)
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// 标记动态流条目为已读
+func MarkFeedItemsSeen(userID int, items []FeedItemRef) error {
+	for _, item := range items {
+		if _, ok := feedItemURLs[item.Type]; !ok || item.ID <= 0 {
+			continue
+		}
+		_, err := DB.Exec("INSERT IGNORE INTO feed_seen (user_id, content_type, content_id) VALUES (?, ?, ?)",
+			userID, item.Type, item.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
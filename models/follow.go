@@ -0,0 +1,244 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+)
+
+// 关注相关错误
+var ErrCannotFollowSelf = errors.New("不能关注自己")
+
+// 推荐关注的用户
+type FollowSuggestion struct {
+	ID            int    `json:"id"`
+	Username      string `json:"username"`
+	Avatar        string `json:"avatar"`
+	Bio           string `json:"bio"`
+	Level         int    `json:"level"`
+	FollowerCount int    `json:"follower_count"`
+	CoFollowers   int    `json:"co_followers"` // 我关注的人中有多少也关注了TA
+	SharedTags    int    `json:"shared_tags"`  // TA发布的内容中有多少个我关注的标签
+}
+
+// 推荐关注时共同关注和共同标签的权重
+const (
+	suggestionCoFollowerWeight = 3
+	suggestionSharedTagWeight  = 1
+)
+
+// 关注用户，返回是否新建了关注关系。关注表和两边的计数在同一事务中更新
+func Follow(followerID, followedID int) (bool, error) {
+	if followerID == followedID {
+		return false, ErrCannotFollowSelf
+	}
+	blocked, err := IsBlockedBetween(followerID, followedID)
+	if err != nil {
+		return false, err
+	}
+	if blocked {
+		return false, ErrBlocked
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("INSERT IGNORE INTO follows (follower_id, followed_id) VALUES (?, ?)", followerID, followedID)
+	if err != nil {
+		return false, err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return false, nil // 已经关注了
+	}
+	if err := adjustFollowCounts(tx, followerID, followedID, 1); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	notifyFollow(followerID, followedID)
+	return true, nil
+}
+
+// 取消关注，返回是否删除了关注关系
+func Unfollow(followerID, followedID int) (bool, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	removed, err := removeFollow(tx, followerID, followedID)
+	if err != nil || !removed {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// 已关注则取消关注，否则关注，返回操作后是否处于关注状态
+func ToggleFollow(followerID, followedID int) (bool, error) {
+	following, err := IsFollowing(followerID, followedID)
+	if err != nil {
+		return false, err
+	}
+	if following {
+		_, err = Unfollow(followerID, followedID)
+		return false, err
+	}
+	_, err = Follow(followerID, followedID)
+	return err == nil, err
+}
+
+// 在事务中删除一条关注关系并更新计数
+func removeFollow(tx *sql.Tx, followerID, followedID int) (bool, error) {
+	result, err := tx.Exec("DELETE FROM follows WHERE follower_id = ? AND followed_id = ?", followerID, followedID)
+	if err != nil {
+		return false, err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return false, nil
+	}
+	return true, adjustFollowCounts(tx, followerID, followedID, -1)
+}
+
+// 调整关注者的关注数和被关注者的粉丝数
+func adjustFollowCounts(tx *sql.Tx, followerID, followedID, delta int) error {
+	if _, err := tx.Exec("UPDATE users SET following_count = GREATEST(following_count + ?, 0) WHERE id = ?", delta, followerID); err != nil {
+		return err
+	}
+	_, err := tx.Exec("UPDATE users SET follower_count = GREATEST(follower_count + ?, 0) WHERE id = ?", delta, followedID)
+	return err
+}
+
+// 检查followerID是否关注了userID
+func IsFollowing(followerID, userID int) (bool, error) {
+	var count int
+	err := DB.QueryRow("SELECT COUNT(*) FROM follows WHERE follower_id = ? AND followed_id = ?", followerID, userID).Scan(&count)
+	return count > 0, err
+}
+
+// 检查两个用户是否互相关注
+func IsMutualFollow(userA, userB int) (bool, error) {
+	var count int
+	err := DB.QueryRow(`
+		SELECT COUNT(*) FROM follows
+		WHERE (follower_id = ? AND followed_id = ?) OR (follower_id = ? AND followed_id = ?)
+	`, userA, userB, userB, userA).Scan(&count)
+	return count == 2, err
+}
+
+// 获取用户的粉丝数和关注数
+func GetFollowCounts(userID int) (followers, following int, err error) {
+	err = DB.QueryRow("SELECT follower_count, following_count FROM users WHERE id = ?", userID).Scan(&followers, &following)
+	return
+}
+
+// 推荐关注：我关注的人也关注的用户，以及发布了我关注的标签下内容的用户
+func SuggestFollows(userID, limit int) ([]*FollowSuggestion, error) {
+	rows, err := DB.Query(`
+		SELECT u.id, u.username, u.avatar, IFNULL(u.bio, ''), u.points, u.follower_count,
+			   SUM(c.co_followers) AS co_followers, SUM(c.shared_tags) AS shared_tags
+		FROM (
+			SELECT f2.followed_id AS user_id, COUNT(*) AS co_followers, 0 AS shared_tags
+			FROM follows f1
+			JOIN follows f2 ON f2.follower_id = f1.followed_id
+			WHERE f1.follower_id = ?
+			GROUP BY f2.followed_id
+			UNION ALL
+			SELECT authored.user_id, 0, COUNT(DISTINCT ct.tag_id)
+			FROM interest_follows i
+			JOIN content_tags ct ON ct.tag_id = i.target_id
+			JOIN (
+				SELECT 'question' AS content_type, id, user_id FROM questions WHERE deleted_at IS NULL
+				UNION ALL SELECT 'article', id, user_id FROM tech_articles WHERE deleted_at IS NULL
+				UNION ALL SELECT 'resource', id, user_id FROM learning_resources WHERE deleted_at IS NULL
+			) authored ON authored.content_type = ct.content_type AND authored.id = ct.content_id
+			WHERE i.user_id = ? AND i.target_type = 'tag'
+			GROUP BY authored.user_id
+		) c
+		JOIN users u ON u.id = c.user_id
+		WHERE u.id <> ?
+		  AND u.id NOT IN (SELECT followed_id FROM follows WHERE follower_id = ?)
+		  AND u.id NOT IN (SELECT blocked_id FROM user_blocks WHERE blocker_id = ?)
+		  AND u.id NOT IN (SELECT blocker_id FROM user_blocks WHERE blocked_id = ?)
+		  AND u.id NOT IN (SELECT muted_id FROM user_mutes WHERE muter_id = ?)
+		GROUP BY u.id, u.username, u.avatar, u.bio, u.points, u.follower_count
+		ORDER BY SUM(c.co_followers) * ? + SUM(c.shared_tags) * ? DESC, u.follower_count DESC
+		LIMIT ?
+	`, userID, userID, userID, userID, userID, userID, userID,
+		suggestionCoFollowerWeight, suggestionSharedTagWeight, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	suggestions := []*FollowSuggestion{}
+	for rows.Next() {
+		s := &FollowSuggestion{}
+		var points int
+		if err := rows.Scan(&s.ID, &s.Username, &s.Avatar, &s.Bio, &points, &s.FollowerCount, &s.CoFollowers, &s.SharedTags); err != nil {
+			return nil, err
+		}
+		s.Level = GetUserLevel(points)
+		suggestions = append(suggestions, s)
+	}
+	return suggestions, rows.Err()
+}
+
+// 按follows表重新计算所有用户的关注计数
+const followCountBackfill = `UPDATE users u SET
+			follower_count = (SELECT COUNT(*) FROM follows WHERE followed_id = u.id),
+			following_count = (SELECT COUNT(*) FROM follows WHERE follower_id = u.id)`
+
+// 将旧的作者关注表user_follows合并到follows并重新计算关注计数，合并后删除旧表。
+// 旧数据库升级时刚添加关注计数列的，同样按follows表回填
+func MigrateFollows() {
+	exists, err := tableExists("user_follows")
+	if err != nil {
+		log.Printf("迁移关注数据失败: %v", err)
+		return
+	}
+	if !exists {
+		// 旧数据库刚补上关注计数列时，按follows表回填
+		if columnAdded("users", "follower_count") || columnAdded("users", "following_count") {
+			if _, err := DB.Exec(followCountBackfill); err != nil {
+				log.Printf("回填关注计数失败: %v", err)
+			}
+		}
+		return
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		log.Printf("迁移关注数据失败: %v", err)
+		return
+	}
+	defer tx.Rollback()
+
+	steps := []string{
+		`INSERT IGNORE INTO follows (follower_id, followed_id, created_at)
+		 SELECT follower_id, following_id, created_at FROM user_follows WHERE follower_id <> following_id`,
+		followCountBackfill,
+	}
+	for _, step := range steps {
+		if _, err := tx.Exec(step); err != nil {
+			log.Printf("迁移关注数据失败: %v", err)
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("迁移关注数据失败: %v", err)
+		return
+	}
+
+	// DDL会隐式提交，放在事务之外
+	if _, err := DB.Exec("DROP TABLE user_follows"); err != nil {
+		log.Printf("删除旧关注表失败: %v", err)
+		return
+	}
+	log.Println("已将user_follows合并到follows")
+}
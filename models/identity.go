@@ -0,0 +1,171 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// 通过第三方登录创建的账户没有密码，该值不是有效的哈希，无法用密码登录
+const unusablePassword = "!"
+
+var (
+	ErrIdentityLinked   = errors.New("该第三方账号已绑定其他用户")
+	ErrProviderLinked   = errors.New("已绑定该登录方式的其他账号")
+	ErrIdentityNotFound = errors.New("未绑定该登录方式")
+	ErrLastLoginMethod  = errors.New("这是唯一的登录方式，请先设置密码或绑定其他账号")
+)
+
+// 用户绑定的第三方账号
+type UserIdentity struct {
+	ID          int        `json:"id"`
+	UserID      int        `json:"-"`
+	Provider    string     `json:"provider"`
+	Subject     string     `json:"-"`
+	Email       string     `json:"email"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+}
+
+// 根据第三方账号查找绑定的用户并记录登录时间，未绑定时返回sql.ErrNoRows
+func FindIdentityUser(provider, subject string) (*User, error) {
+	var userID int
+	err := DB.QueryRow("SELECT user_id FROM user_identities WHERE provider = ? AND subject = ?",
+		provider, subject).Scan(&userID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := DB.Exec("UPDATE user_identities SET last_login_at = NOW() WHERE provider = ? AND subject = ?",
+		provider, subject); err != nil {
+		return nil, err
+	}
+	return GetUserByID(userID)
+}
+
+// 为已有用户绑定第三方账号
+func LinkIdentity(userID int, provider, subject, email string) error {
+	var ownerID int
+	err := DB.QueryRow("SELECT user_id FROM user_identities WHERE provider = ? AND subject = ?",
+		provider, subject).Scan(&ownerID)
+	if err == nil {
+		if ownerID == userID {
+			return nil
+		}
+		return ErrIdentityLinked
+	}
+	if err != sql.ErrNoRows {
+		return err
+	}
+
+	var linked int
+	if err := DB.QueryRow("SELECT COUNT(*) FROM user_identities WHERE user_id = ? AND provider = ?",
+		userID, provider).Scan(&linked); err != nil {
+		return err
+	}
+	if linked > 0 {
+		return ErrProviderLinked
+	}
+
+	_, err = DB.Exec("INSERT INTO user_identities (user_id, provider, subject, email) VALUES (?, ?, ?, ?)",
+		userID, provider, subject, email)
+	return err
+}
+
+// 使用第三方账号注册新用户并绑定，返回新用户ID
+func CreateUserWithIdentity(username, email, avatar, provider, subject string) (int, error) {
+	if avatar == "" {
+		avatar = "/images/user.jpg"
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("INSERT INTO users (username, email, password, avatar) VALUES (?, ?, ?, ?)",
+		username, email, unusablePassword, avatar)
+	if err != nil {
+		return 0, err
+	}
+	userID, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec("INSERT INTO user_identities (user_id, provider, subject, email, last_login_at) VALUES (?, ?, ?, ?, NOW())",
+		userID, provider, subject, email)
+	if err != nil {
+		return 0, err
+	}
+	return int(userID), tx.Commit()
+}
+
+// 获取用户绑定的第三方账号
+func GetUserIdentities(userID int) ([]*UserIdentity, error) {
+	rows, err := DB.Query(`
+		SELECT id, user_id, provider, subject, IFNULL(email, ''), created_at, last_login_at
+		FROM user_identities WHERE user_id = ? ORDER BY created_at
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	identities := []*UserIdentity{}
+	for rows.Next() {
+		identity := &UserIdentity{}
+		var lastLoginAt sql.NullTime
+		if err := rows.Scan(&identity.ID, &identity.UserID, &identity.Provider, &identity.Subject,
+			&identity.Email, &identity.CreatedAt, &lastLoginAt); err != nil {
+			return nil, err
+		}
+		if lastLoginAt.Valid {
+			identity.LastLoginAt = &lastLoginAt.Time
+		}
+		identities = append(identities, identity)
+	}
+	return identities, rows.Err()
+}
+
+// 用户是否设置了密码
+func HasPassword(userID int) (bool, error) {
+	var password string
+	if err := DB.QueryRow("SELECT password FROM users WHERE id = ?", userID).Scan(&password); err != nil {
+		return false, err
+	}
+	return password != unusablePassword, nil
+}
+
+// 解除绑定，没有密码的用户不能解除最后一个第三方账号
+func UnlinkIdentity(userID int, provider string) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var password string
+	if err := tx.QueryRow("SELECT password FROM users WHERE id = ? FOR UPDATE", userID).Scan(&password); err != nil {
+		return err
+	}
+	var linked, others int
+	err = tx.QueryRow(`
+		SELECT IFNULL(SUM(provider = ?), 0), IFNULL(SUM(provider <> ?), 0)
+		FROM user_identities WHERE user_id = ?
+	`, provider, provider, userID).Scan(&linked, &others)
+	if err != nil {
+		return err
+	}
+	if linked == 0 {
+		return ErrIdentityNotFound
+	}
+	if password == unusablePassword && others == 0 {
+		return ErrLastLoginMethod
+	}
+
+	if _, err := tx.Exec("DELETE FROM user_identities WHERE user_id = ? AND provider = ?", userID, provider); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -8,6 +8,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"aiforum/utils"
 )
 
 // 学习资料模型
@@ -16,6 +18,7 @@ type LearningResource struct {
 	Title          string    `json:"title"`
 	Description    string    `json:"description"`
 	Type           string    `json:"type"`
+	TypeName       string    `json:"type_name"`
 	TypeIcon       string    `json:"type_icon"`
 	Level          string    `json:"level"`
 	DifficultyText string    `json:"difficulty_text"`
@@ -24,6 +27,7 @@ type LearningResource struct {
 	UserID         int       `json:"user_id"`
 	UploaderName   string    `json:"uploader_name"`
 	UploaderAvatar string    `json:"uploader_avatar"`
+	UploaderLevel  int       `json:"uploader_level"`
 	CoverImage     string    `json:"cover_image"`
 	FilePaths      string    `json:"file_paths"`
 	FileSize       string    `json:"file_size"`
@@ -31,6 +35,8 @@ type LearningResource struct {
 	Tags           string    `json:"tags"`
 	TagsArray      []string  `json:"tags_array"`
 	Rating         float64   `json:"rating"`
+	Stars          int       `json:"-"` // 四舍五入后的星级，模板中显示评分星星
+	RatingCount    int       `json:"rating_count"`
 	DownloadCount  int       `json:"download_count"`
 	CommentCount   int       `json:"comment_count"`
 	DownloadURL    string    `json:"download_url"`
@@ -38,6 +44,19 @@ type LearningResource struct {
 	UpdatedAt      time.Time `json:"updated_at"`
 }
 
+// 学习资料评论
+type ResourceComment struct {
+	ID         int       `json:"id"`
+	ResourceID int       `json:"resource_id"`
+	UserID     int       `json:"user_id"`
+	Username   string    `json:"username"`
+	UserAvatar string    `json:"user_avatar"`
+	UserLevel  int       `json:"user_level"`
+	Content    string    `json:"content"`
+	Rating     int       `json:"rating"` // 评论者给出的评分，未评分为0
+	CreatedAt  time.Time `json:"created_at"`
+}
+
 // 分类模型
 type ResourceCategory struct {
 	ID          int    `json:"id"`
@@ -70,58 +89,77 @@ func CreateLearningResource(title, description, resourceType, level, category, t
 		return 0, err
 	}
 
+	// 建立标签关联
+	err = SyncContentTags(ContentTypeResource, int(resourceID), tags)
+	if err != nil {
+		return 0, err
+	}
+
 	// 给上传用户加积分
 	err = UpdateUserPoints(userID, 20)
 	if err != nil {
 		return 0, err
 	}
 
+	// 通知被@的用户
+	RecordMentions(MentionSource{
+		ContentType: MentionInResource,
+		ContentID:   int(resourceID),
+		ActorID:     userID,
+		TargetType:  "resource",
+		TargetID:    int(resourceID),
+		Subject:     title,
+		Link:        "/learning-resources/" + strconv.Itoa(int(resourceID)),
+	}, description)
+
+	DispatchWebhookEvent(WebhookEvent{
+		Name:        WebhookResourceUploaded,
+		ActorID:     userID,
+		ContentType: ContentTypeResource,
+		ContentID:   int(resourceID),
+		Data: map[string]interface{}{
+			"id":          int(resourceID),
+			"title":       title,
+			"description": description,
+			"type":        resourceType,
+			"level":       level,
+			"category":    category,
+			"url":         siteURL("/learning-resources/" + strconv.Itoa(int(resourceID))),
+		},
+	})
+
 	return int(resourceID), nil
 }
 
-// 获取学习资料列表
-func GetLearningResources(page, limit int, keyword, resourceType, level, timeFilter, rating, category string) ([]*LearningResource, int, error) {
-	offset := (page - 1) * limit
-	
-	var query string
-	var countQuery string
+// 学习资料的筛选条件（搜索时排除viewerID静音和屏蔽的作者）
+func resourceFilter(keyword, resourceType, level, timeFilter, rating, category string, viewerID int) (string, []interface{}) {
+	whereConditions := []string{"r.deleted_at IS NULL"}
 	var args []interface{}
-	
-	baseQuery := `
-		SELECT r.id, r.title, r.description, r.type, r.level, r.category, r.user_id, 
-			   u.username, u.avatar, r.cover_image, r.file_paths, r.total_size, r.tags, 
-			   r.rating, r.download_count, r.comment_count, r.download_url, r.created_at, r.updated_at
-		FROM learning_resources r
-		JOIN users u ON r.user_id = u.id
-	`
-	
-	countQuery = `
-		SELECT COUNT(*) FROM learning_resources r
-		JOIN users u ON r.user_id = u.id
-	`
-	
-	var whereConditions []string
-	
+
 	if keyword != "" {
 		whereConditions = append(whereConditions, "(r.title LIKE ? OR r.description LIKE ? OR r.tags LIKE ?)")
 		args = append(args, "%"+keyword+"%", "%"+keyword+"%", "%"+keyword+"%")
+		if viewerID > 0 {
+			whereConditions = append(whereConditions, strings.TrimPrefix(hiddenAuthorFilter("r.user_id"), " AND "))
+			args = append(args, viewerID, viewerID)
+		}
 	}
-	
+
 	if resourceType != "" {
 		whereConditions = append(whereConditions, "r.type = ?")
 		args = append(args, resourceType)
 	}
-	
+
 	if level != "" {
 		whereConditions = append(whereConditions, "r.level = ?")
 		args = append(args, level)
 	}
-	
+
 	if category != "" {
 		whereConditions = append(whereConditions, "r.category = ?")
 		args = append(args, category)
 	}
-	
+
 	if timeFilter != "" {
 		switch timeFilter {
 		case "today":
@@ -134,49 +172,52 @@ func GetLearningResources(page, limit int, keyword, resourceType, level, timeFil
 			whereConditions = append(whereConditions, "r.created_at >= DATE_SUB(NOW(), INTERVAL 1 YEAR)")
 		}
 	}
-	
+
 	if rating != "" {
 		ratingInt, _ := strconv.Atoi(rating)
 		whereConditions = append(whereConditions, "r.rating >= ?")
 		args = append(args, float64(ratingInt))
 	}
-	
-	if len(whereConditions) > 0 {
-		query = baseQuery + " WHERE " + strings.Join(whereConditions, " AND ")
-		countQuery += " WHERE " + strings.Join(whereConditions, " AND ")
-	} else {
-		query = baseQuery
-	}
-	
-	query += " ORDER BY r.created_at DESC LIMIT ? OFFSET ?"
-	args = append(args, limit, offset)
-	
-	// 获取总数
-	var total int
-	err := DB.QueryRow(countQuery, args[:len(args)-2]...).Scan(&total)
+
+	return strings.Join(whereConditions, " AND "), args
+}
+
+// 学习资料列表的排序（按上传时间倒序）
+var resourceKeyset = latestKeyset("latest", "r.created_at", "r.id")
+
+// 获取学习资料列表
+func GetLearningResources(keyword, resourceType, level, timeFilter, rating, category string, viewerID int, page PageQuery) ([]*LearningResource, PageInfo, error) {
+	where, args := resourceFilter(keyword, resourceType, level, timeFilter, rating, category, viewerID)
+	query, args, err := resourceKeyset.apply(`
+		SELECT r.id, r.title, r.description, r.type, r.level, r.category, r.user_id,
+			   u.username, u.avatar, r.cover_image, r.file_paths, r.total_size, r.tags,
+			   r.rating, r.download_count, r.comment_count, r.download_url, r.created_at, r.updated_at
+		FROM learning_resources r
+		JOIN users u ON r.user_id = u.id
+		WHERE `+where, args, page)
 	if err != nil {
-		return nil, 0, err
+		return nil, PageInfo{}, err
 	}
-	
-	// 获取资料列表
+
 	rows, err := DB.Query(query, args...)
 	if err != nil {
-		return nil, 0, err
+		return nil, PageInfo{}, err
 	}
 	defer rows.Close()
 
 	var resources []*LearningResource
+	var keys []utils.Cursor
 	for rows.Next() {
 		resource := &LearningResource{}
-		err := rows.Scan(&resource.ID, &resource.Title, &resource.Description, &resource.Type, 
-			&resource.Level, &resource.Category, &resource.UserID, &resource.UploaderName, 
-			&resource.UploaderAvatar, &resource.CoverImage, &resource.FilePaths, &resource.TotalSize, 
-			&resource.Tags, &resource.Rating, &resource.DownloadCount, &resource.CommentCount, 
+		err := rows.Scan(&resource.ID, &resource.Title, &resource.Description, &resource.Type,
+			&resource.Level, &resource.Category, &resource.UserID, &resource.UploaderName,
+			&resource.UploaderAvatar, &resource.CoverImage, &resource.FilePaths, &resource.TotalSize,
+			&resource.Tags, &resource.Rating, &resource.DownloadCount, &resource.CommentCount,
 			&resource.DownloadURL, &resource.CreatedAt, &resource.UpdatedAt)
 		if err != nil {
-			return nil, 0, err
+			return nil, PageInfo{}, err
 		}
-		
+
 		// 处理标签数组
 		if resource.Tags != "" {
 			resource.TagsArray = strings.Split(resource.Tags, ",")
@@ -184,23 +225,38 @@ func GetLearningResources(page, limit int, keyword, resourceType, level, timeFil
 				resource.TagsArray[i] = strings.TrimSpace(tag)
 			}
 		}
-		
+
 		// 设置类型图标
 		resource.TypeIcon = getResourceTypeIcon(resource.Type)
-		
+		resource.TypeName = getResourceTypeName(resource.Type)
+		resource.Stars = ratingStars(resource.Rating)
+
 		// 设置难度文本
 		resource.DifficultyText = getDifficultyText(resource.Level)
-		
+
 		// 设置分类名称
 		resource.CategoryName = getCategoryName(resource.Category)
-		
+
 		// 格式化文件大小
 		resource.FileSize = formatFileSize(resource.TotalSize)
-		
+
 		resources = append(resources, resource)
+		keys = append(keys, utils.Cursor{CreatedAt: resource.CreatedAt, ID: resource.ID})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, PageInfo{}, err
 	}
 
-	return resources, total, nil
+	resources, info := finishPage(resources, keys, resourceKeyset, page)
+	return resources, info, nil
+}
+
+// 统计符合条件的学习资料数量
+func CountLearningResources(keyword, resourceType, level, timeFilter, rating, category string, viewerID int) (int, error) {
+	where, args := resourceFilter(keyword, resourceType, level, timeFilter, rating, category, viewerID)
+	var total int
+	err := DB.QueryRow("SELECT COUNT(*) FROM learning_resources r WHERE "+where, args...).Scan(&total)
+	return total, err
 }
 
 // 获取最新上传资料
@@ -208,6 +264,7 @@ func GetLatestResources(limit int) ([]LearningResource, error) {
 	query := `
 		SELECT r.id, r.title, r.cover_image, r.type, r.category, r.rating, r.download_count, r.created_at
 		FROM learning_resources r
+		WHERE r.deleted_at IS NULL
 		ORDER BY r.created_at DESC
 		LIMIT ?
 	`
@@ -241,7 +298,7 @@ func GetTopRatedResources(limit int) ([]LearningResource, error) {
 	query := `
 		SELECT r.id, r.title, r.cover_image, r.category, r.rating, r.download_count
 		FROM learning_resources r
-		WHERE r.rating > 0
+		WHERE r.rating > 0 AND r.deleted_at IS NULL
 		ORDER BY r.rating DESC, r.download_count DESC
 		LIMIT ?
 	`
@@ -274,15 +331,16 @@ func GetLearningResourceByID(resourceID string) (*LearningResource, error) {
 	resource := &LearningResource{}
 	err := DB.QueryRow(`
 		SELECT r.id, r.title, r.description, r.type, r.level, r.category, r.user_id, 
-			   u.username, u.avatar, r.cover_image, r.file_paths, r.total_size, r.tags, 
-			   r.rating, r.download_count, r.comment_count, r.download_url, r.created_at, r.updated_at
+			   u.username, u.avatar, u.level, r.cover_image, r.file_paths, r.total_size, r.tags, 
+			   r.rating, (SELECT COUNT(*) FROM resource_ratings WHERE resource_id = r.id),
+			   r.download_count, r.comment_count, r.download_url, r.created_at, r.updated_at
 		FROM learning_resources r
 		JOIN users u ON r.user_id = u.id
-		WHERE r.id = ?
+		WHERE r.id = ? AND r.deleted_at IS NULL
 	`, resourceID).Scan(&resource.ID, &resource.Title, &resource.Description, &resource.Type, 
 		&resource.Level, &resource.Category, &resource.UserID, &resource.UploaderName, 
-		&resource.UploaderAvatar, &resource.CoverImage, &resource.FilePaths, &resource.TotalSize, 
-		&resource.Tags, &resource.Rating, &resource.DownloadCount, &resource.CommentCount, 
+		&resource.UploaderAvatar, &resource.UploaderLevel, &resource.CoverImage, &resource.FilePaths, &resource.TotalSize, 
+		&resource.Tags, &resource.Rating, &resource.RatingCount, &resource.DownloadCount, &resource.CommentCount, 
 		&resource.DownloadURL, &resource.CreatedAt, &resource.UpdatedAt)
 	
 	if err != nil {
@@ -299,6 +357,8 @@ func GetLearningResourceByID(resourceID string) (*LearningResource, error) {
 	
 	// 设置类型图标
 	resource.TypeIcon = getResourceTypeIcon(resource.Type)
+	resource.TypeName = getResourceTypeName(resource.Type)
+	resource.Stars = ratingStars(resource.Rating)
 	
 	// 设置难度文本
 	resource.DifficultyText = getDifficultyText(resource.Level)
@@ -312,6 +372,35 @@ func GetLearningResourceByID(resourceID string) (*LearningResource, error) {
 	return resource, nil
 }
 
+// 获取学习资料的评论（附带评论者的评分）
+func GetResourceComments(resourceID string, limit int) ([]ResourceComment, error) {
+	rows, err := DB.Query(`
+		SELECT c.id, c.resource_id, c.user_id, u.username, u.avatar, u.level, c.content,
+			   COALESCE(rr.rating, 0), c.created_at
+		FROM resource_comments c
+		JOIN users u ON c.user_id = u.id
+		LEFT JOIN resource_ratings rr ON rr.resource_id = c.resource_id AND rr.user_id = c.user_id
+		WHERE c.resource_id = ?
+		ORDER BY c.created_at DESC
+		LIMIT ?
+	`, resourceID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comments []ResourceComment
+	for rows.Next() {
+		var comment ResourceComment
+		if err := rows.Scan(&comment.ID, &comment.ResourceID, &comment.UserID, &comment.Username,
+			&comment.UserAvatar, &comment.UserLevel, &comment.Content, &comment.Rating, &comment.CreatedAt); err != nil {
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+	return comments, rows.Err()
+}
+
 // 根据slug获取分类
 func GetCategoryBySlug(slug string) (*ResourceCategory, error) {
 	category := &ResourceCategory{}
@@ -326,7 +415,7 @@ func GetCategoryBySlug(slug string) (*ResourceCategory, error) {
 	}
 	
 	// 获取分类下的资料数量
-	err = DB.QueryRow("SELECT COUNT(*) FROM learning_resources WHERE category = ?", slug).Scan(&category.Count)
+	err = DB.QueryRow("SELECT COUNT(*) FROM learning_resources WHERE category = ? AND deleted_at IS NULL", slug).Scan(&category.Count)
 	if err != nil {
 		return nil, err
 	}
@@ -376,15 +465,53 @@ func RateLearningResource(resourceID string, userID int, rating int) error {
 
 // 评论学习资料
 func CommentLearningResource(resourceID string, userID int, content string) error {
+	if id, err := strconv.Atoi(resourceID); err == nil {
+		if err := checkContentOwnerBlock("learning_resources", id, userID); err != nil {
+			return err
+		}
+	}
+
 	// 添加评论
-	_, err := DB.Exec("INSERT INTO resource_comments (resource_id, user_id, content) VALUES (?, ?, ?)", resourceID, userID, content)
+	result, err := DB.Exec("INSERT INTO resource_comments (resource_id, user_id, content) VALUES (?, ?, ?)", resourceID, userID, content)
+	if err != nil {
+		return err
+	}
+	commentID, err := result.LastInsertId()
 	if err != nil {
 		return err
 	}
 	
 	// 更新评论计数
 	_, err = DB.Exec("UPDATE learning_resources SET comment_count = comment_count + 1 WHERE id = ?", resourceID)
-	return err
+	if err != nil {
+		return err
+	}
+
+	// 通知资料上传者
+	if id, err := strconv.Atoi(resourceID); err == nil {
+		if authorID, title, err := contentOwner("learning_resources", id); err == nil {
+			Notify(Notification{
+				UserID:     authorID,
+				ActorID:    userID,
+				Type:       NotifyComment,
+				TargetType: "resource",
+				TargetID:   id,
+				Subject:    title,
+				Content:    notificationExcerpt(content),
+				Link:       "/learning-resources/" + resourceID,
+			})
+			RecordMentions(MentionSource{
+				ContentType: MentionInResourceComment,
+				ContentID:   int(commentID),
+				ActorID:     userID,
+				TargetType:  "comment",
+				TargetID:    int(commentID),
+				Subject:     title,
+				Link:        "/learning-resources/" + resourceID,
+			}, content, authorID)
+		}
+	}
+	return nil
 }
 
 // 上传文件
@@ -438,6 +565,27 @@ func getResourceTypeIcon(resourceType string) string {
 	return "fas fa-file"
 }
 
+func getResourceTypeName(resourceType string) string {
+	nameMap := map[string]string{
+		"ebook": "电子书",
+		"video": "视频",
+		"slides": "课件",
+		"dataset": "数据集",
+		"code": "代码",
+		"paper": "论文",
+	}
+	
+	if name, exists := nameMap[resourceType]; exists {
+		return name
+	}
+	return resourceType
+}
+
+// 评分四舍五入为整数星级
+func ratingStars(rating float64) int {
+	return int(rating + 0.5)
+}
+
 func getDifficultyText(level string) string {
 	textMap := map[string]string{
 		"beginner": "入门",
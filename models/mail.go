@@ -0,0 +1,369 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// 邮件摘要频率（users.email_digest）
+const (
+	EmailDigestImmediate = "immediate"
+	EmailDigestDaily     = "daily"
+	EmailDigestWeekly    = "weekly"
+	EmailDigestOff       = "off"
+)
+
+// 摘要频率对应的时间间隔
+var EmailDigestPeriods = map[string]time.Duration{
+	EmailDigestDaily:  24 * time.Hour,
+	EmailDigestWeekly: 7 * 24 * time.Hour,
+}
+
+// 发件箱邮件状态
+const (
+	MailStatusPending = "pending"
+	MailStatusSending = "sending"
+	MailStatusSent    = "sent"
+	MailStatusFailed  = "failed"
+)
+
+// 单封邮件最多重试次数
+const MailMaxAttempts = 5
+
+// 发送中状态的租约时间（进程崩溃后到期重新投递）
+const mailSendingLease = 10 * time.Minute
+
+// 无效的摘要频率
+var ErrInvalidEmailDigest = errors.New("无效的邮件摘要频率")
+
+// 发件箱中的邮件
+type OutboxMail struct {
+	ID       int
+	UserID   int
+	ToEmail  string
+	Subject  string
+	BodyText string
+	BodyHTML string
+	Kind     string
+	Attempts int
+}
+
+// 邮件收件人
+type MailRecipient struct {
+	UserID     int
+	Username   string
+	Email      string
+	Digest     string
+	LastDigest sql.NullTime
+}
+
+// 摘要中的新回答
+type DigestAnswer struct {
+	QuestionID    int
+	QuestionTitle string
+	Username      string
+	Excerpt       string
+	CreatedAt     time.Time
+}
+
+// 摘要中的热门文章
+type DigestArticle struct {
+	ID        int
+	Title     string
+	Username  string
+	LikeCount int
+	ViewCount int
+}
+
+// 检查摘要频率是否有效
+func ValidEmailDigest(digest string) bool {
+	switch digest {
+	case EmailDigestImmediate, EmailDigestDaily, EmailDigestWeekly, EmailDigestOff:
+		return true
+	}
+	return false
+}
+
+// 获取邮件摘要频率
+func GetEmailDigest(userID int) (string, error) {
+	var digest string
+	err := DB.QueryRow("SELECT email_digest FROM users WHERE id = ?", userID).Scan(&digest)
+	return digest, err
+}
+
+// 更新邮件摘要频率
+func UpdateEmailDigest(userID int, digest string) error {
+	if !ValidEmailDigest(digest) {
+		return ErrInvalidEmailDigest
+	}
+	_, err := DB.Exec("UPDATE users SET email_digest = ? WHERE id = ?", digest, userID)
+	return err
+}
+
+// 退订所有邮件
+func UnsubscribeEmail(userID int) error {
+	result, err := DB.Exec("UPDATE users SET email_notifications = 0 WHERE id = ?", userID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		var exists int
+		if err := DB.QueryRow("SELECT 1 FROM users WHERE id = ?", userID).Scan(&exists); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 写入发件箱
+func EnqueueMail(mail *OutboxMail) error {
+	var userID interface{}
+	if mail.UserID > 0 {
+		userID = mail.UserID
+	}
+	_, err := DB.Exec(`
+		INSERT INTO mail_outbox (user_id, to_email, subject, body_text, body_html, kind)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, userID, mail.ToEmail, mail.Subject, mail.BodyText, mail.BodyHTML, mail.Kind)
+	return err
+}
+
+// 领取待发送的邮件（领取后在租约时间内不会被重复领取）
+func ClaimOutboxMails(limit int) ([]*OutboxMail, error) {
+	rows, err := DB.Query(`
+		SELECT id, IFNULL(user_id, 0), to_email, subject, body_text, body_html, kind, attempts
+		FROM mail_outbox
+		WHERE status IN (?, ?) AND next_attempt_at <= NOW()
+		ORDER BY id
+		LIMIT ?
+	`, MailStatusPending, MailStatusSending, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []*OutboxMail
+	for rows.Next() {
+		mail := &OutboxMail{}
+		err := rows.Scan(&mail.ID, &mail.UserID, &mail.ToEmail, &mail.Subject, &mail.BodyText, &mail.BodyHTML, &mail.Kind, &mail.Attempts)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		candidates = append(candidates, mail)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var mails []*OutboxMail
+	for _, mail := range candidates {
+		result, err := DB.Exec(`
+			UPDATE mail_outbox SET status = ?, next_attempt_at = ?
+			WHERE id = ? AND status IN (?, ?) AND next_attempt_at <= NOW()
+		`, MailStatusSending, time.Now().Add(mailSendingLease), mail.ID, MailStatusPending, MailStatusSending)
+		if err != nil {
+			return nil, err
+		}
+		if affected, _ := result.RowsAffected(); affected == 1 {
+			mails = append(mails, mail)
+		}
+	}
+	return mails, nil
+}
+
+// 标记邮件已发送
+func MarkMailSent(id int) error {
+	_, err := DB.Exec("UPDATE mail_outbox SET status = ?, attempts = attempts + 1, last_error = NULL, sent_at = NOW() WHERE id = ?",
+		MailStatusSent, id)
+	return err
+}
+
+// 记录发送失败，未超过重试次数时按退避时间重新排队
+func MarkMailFailed(mail *OutboxMail, sendErr error) error {
+	attempts := mail.Attempts + 1
+	status := MailStatusPending
+	if attempts >= MailMaxAttempts {
+		status = MailStatusFailed
+	}
+
+	message := sendErr.Error()
+	if len(message) > 500 {
+		message = message[:500]
+	}
+
+	// 第n次失败后等待n²分钟
+	next := time.Now().Add(time.Duration(attempts*attempts) * time.Minute)
+	_, err := DB.Exec("UPDATE mail_outbox SET status = ?, attempts = ?, last_error = ?, next_attempt_at = ? WHERE id = ?",
+		status, attempts, message, next, mail.ID)
+	return err
+}
+
+// 待即时发送的通知邮件
+type EmailNotificationBatch struct {
+	Recipient MailRecipient
+	Messages  []*UserMessage
+}
+
+// 获取需要即时发送邮件的未读通知（按用户分组，只看最近一天）
+func GetPendingEmailNotifications(limit int) ([]*EmailNotificationBatch, error) {
+	rows, err := DB.Query(`
+		SELECT m.id, m.type, m.title, IFNULL(m.content, ''), IFNULL(m.sender, ''), IFNULL(m.link, ''), m.actor_count, m.created_at,
+			   u.id, u.username, u.email
+		FROM messages m
+		JOIN users u ON m.user_id = u.id
+		WHERE m.emailed = 0 AND m.is_read = 0 AND m.created_at > ?
+		  AND u.email_notifications = 1 AND u.email_digest = ?
+		ORDER BY u.id, m.id
+		LIMIT ?
+	`, time.Now().Add(-24*time.Hour), EmailDigestImmediate, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var batches []*EmailNotificationBatch
+	for rows.Next() {
+		message := &UserMessage{}
+		var recipient MailRecipient
+		err := rows.Scan(
+			&message.ID, &message.Type, &message.Title, &message.Content, &message.Sender, &message.Link,
+			&message.ActorCount, &message.CreatedAt, &recipient.UserID, &recipient.Username, &recipient.Email,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(batches) == 0 || batches[len(batches)-1].Recipient.UserID != recipient.UserID {
+			recipient.Digest = EmailDigestImmediate
+			batches = append(batches, &EmailNotificationBatch{Recipient: recipient})
+		}
+		batch := batches[len(batches)-1]
+		batch.Messages = append(batch.Messages, message)
+	}
+	return batches, rows.Err()
+}
+
+// 标记通知已发送邮件
+func MarkMessagesEmailed(ids []int) error {
+	for _, id := range ids {
+		if _, err := DB.Exec("UPDATE messages SET emailed = 1 WHERE id = ?", id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 获取到期需要发送摘要的用户
+func GetDueDigestRecipients(limit int) ([]*MailRecipient, error) {
+	now := time.Now()
+	rows, err := DB.Query(`
+		SELECT id, username, email, email_digest, last_digest_at
+		FROM users
+		WHERE email_notifications = 1
+		  AND ((email_digest = ? AND (last_digest_at IS NULL OR last_digest_at <= ?))
+		    OR (email_digest = ? AND (last_digest_at IS NULL OR last_digest_at <= ?)))
+		ORDER BY id
+		LIMIT ?
+	`, EmailDigestDaily, now.Add(-EmailDigestPeriods[EmailDigestDaily]),
+		EmailDigestWeekly, now.Add(-EmailDigestPeriods[EmailDigestWeekly]), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recipients []*MailRecipient
+	for rows.Next() {
+		r := &MailRecipient{}
+		if err := rows.Scan(&r.UserID, &r.Username, &r.Email, &r.Digest, &r.LastDigest); err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, r)
+	}
+	return recipients, rows.Err()
+}
+
+// 摘要的统计起点
+func (r *MailRecipient) DigestSince() time.Time {
+	if r.LastDigest.Valid {
+		return r.LastDigest.Time
+	}
+	return time.Now().Add(-EmailDigestPeriods[r.Digest])
+}
+
+// 记录摘要发送时间
+func MarkDigestSent(userID int, at time.Time) error {
+	_, err := DB.Exec("UPDATE users SET last_digest_at = ?, updated_at = updated_at WHERE id = ?", at, userID)
+	return err
+}
+
+// 获取用户问题下的新回答
+func GetDigestAnswers(userID int, since time.Time, limit int) ([]*DigestAnswer, error) {
+	rows, err := DB.Query(`
+		SELECT q.id, q.title, u.username, a.content, a.created_at
+		FROM answers a
+		JOIN questions q ON a.question_id = q.id
+		JOIN users u ON a.user_id = u.id
+		WHERE q.user_id = ? AND a.user_id != ? AND a.created_at > ? AND a.deleted_at IS NULL AND q.deleted_at IS NULL
+		ORDER BY a.created_at DESC
+		LIMIT ?
+	`, userID, userID, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var answers []*DigestAnswer
+	for rows.Next() {
+		a := &DigestAnswer{}
+		if err := rows.Scan(&a.QuestionID, &a.QuestionTitle, &a.Username, &a.Excerpt, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		a.Excerpt = notificationExcerpt(a.Excerpt)
+		answers = append(answers, a)
+	}
+	return answers, rows.Err()
+}
+
+// 获取关注的作者和兴趣的新动态
+func GetDigestFeed(userID int, since time.Time, limit int) ([]*FeedItem, error) {
+	items, err := GetUserFeed(userID, nil, limit, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var recent []*FeedItem
+	for _, item := range items {
+		if item.CreatedAt.After(since) && item.UserID != userID {
+			recent = append(recent, item)
+		}
+	}
+	return recent, nil
+}
+
+// 获取本周热门文章
+func GetWeeklyTopArticles(limit int) ([]*DigestArticle, error) {
+	rows, err := DB.Query(`
+		SELECT a.id, a.title, u.username, a.like_count, a.view_count
+		FROM tech_articles a
+		JOIN users u ON a.user_id = u.id
+		WHERE a.created_at > ? AND a.deleted_at IS NULL
+		ORDER BY a.like_count * 10 + a.comment_count * 5 + a.view_count DESC
+		LIMIT ?
+	`, time.Now().Add(-7*24*time.Hour), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var articles []*DigestArticle
+	for rows.Next() {
+		a := &DigestArticle{}
+		if err := rows.Scan(&a.ID, &a.Title, &a.Username, &a.LikeCount, &a.ViewCount); err != nil {
+			return nil, err
+		}
+		articles = append(articles, a)
+	}
+	return articles, rows.Err()
+}
@@ -0,0 +1,287 @@
+package models
+
+import (
+	"html/template"
+	"log"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// 提及所在的内容类型（mentions.content_type）
+const (
+	MentionInQuestion        = "question"
+	MentionInAnswer          = "answer"
+	MentionInPost            = "post"
+	MentionInReply           = "reply"
+	MentionInArticle         = "article"
+	MentionInArticleComment  = "article_comment"
+	MentionInResource        = "resource"
+	MentionInResourceComment = "resource_comment"
+)
+
+// 单条内容最多通知的被提及人数
+const maxMentionsPerContent = 10
+
+// 单条内容最多识别的@片段数，以及每个片段最多按前缀查询的长度，限制查询用户时IN列表的大小
+const (
+	maxMentionTokens   = 20
+	maxMentionPrefixes = 20
+)
+
+// @用户名：@前不能是字母数字（排除邮箱地址），用户名最长50个字符
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_@])(@[\p{L}\p{N}_-]{1,50})`)
+
+// 提及来源
+type MentionSource struct {
+	ContentType string // 提及所在的内容类型
+	ContentID   int
+	ActorID     int
+	TargetType  string // 通知中显示的对象类型
+	TargetID    int
+	Subject     string
+	Link        string
+}
+
+// 自动补全候选用户
+type UserSuggestion struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+	Avatar   string `json:"avatar"`
+	Level    int    `json:"level"`
+}
+
+// 内容中的@片段
+type mentionToken struct {
+	start, end int    // 含@的字节区间
+	name       string // @后的文本
+}
+
+// 被提及的用户
+type mentionedUser struct {
+	id       int
+	username string
+	prefix   string // @片段中与用户名匹配的部分
+}
+
+// 一个页面中被提及的用户（按小写用户名索引），由处理函数一次查出后传给模板函数mentions
+type MentionedUsers map[string]*mentionedUser
+
+// 查找内容中的@片段（最多maxMentionTokens个，之后的按普通文本处理）
+func findMentionTokens(content string) []mentionToken {
+	if !strings.Contains(content, "@") {
+		return nil
+	}
+
+	var tokens []mentionToken
+	for _, m := range mentionPattern.FindAllStringSubmatchIndex(content, maxMentionTokens) {
+		tokens = append(tokens, mentionToken{start: m[2], end: m[3], name: content[m[2]+1 : m[3]]})
+	}
+	return tokens
+}
+
+// @片段可能对应的用户名（小写）。中文内容中用户名后常紧跟正文（如“@张三你好”），
+// 因此片段的前缀都可能是用户名；超过maxMentionPrefixes个字符的用户名只在整个片段就是用户名时匹配
+func mentionCandidates(name string) []string {
+	runes := []rune(name)
+	candidates := []string{strings.ToLower(name)}
+	for i := min(len(runes), maxMentionPrefixes); i > 0; i-- {
+		if i < len(runes) {
+			candidates = append(candidates, strings.ToLower(string(runes[:i])))
+		}
+	}
+	return candidates
+}
+
+// 查询@片段可能对应的用户
+func lookupMentionedUsers(tokens []mentionToken) (MentionedUsers, error) {
+	users := make(MentionedUsers)
+	if len(tokens) == 0 {
+		return users, nil
+	}
+
+	seen := make(map[string]bool)
+	var args []interface{}
+	for _, token := range tokens {
+		for _, candidate := range mentionCandidates(token.name) {
+			if !seen[candidate] {
+				seen[candidate] = true
+				args = append(args, candidate)
+			}
+		}
+	}
+
+	rows, err := DB.Query("SELECT id, username FROM users WHERE username IN (?"+strings.Repeat(", ?", len(args)-1)+")", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		user := &mentionedUser{}
+		if err := rows.Scan(&user.id, &user.username); err != nil {
+			return nil, err
+		}
+		users[strings.ToLower(user.username)] = user
+	}
+	return users, rows.Err()
+}
+
+// 批量查询多段内容中提及的用户，页面渲染前调用一次，避免每段内容单独查询
+func LoadMentionedUsers(contents ...string) MentionedUsers {
+	var tokens []mentionToken
+	for _, content := range contents {
+		tokens = append(tokens, findMentionTokens(content)...)
+	}
+	users, err := lookupMentionedUsers(tokens)
+	if err != nil {
+		log.Printf("查询提及的用户失败: %v", err)
+		return MentionedUsers{}
+	}
+	return users
+}
+
+// 把@片段对应到用户，取能匹配到的最长前缀作为用户名
+func resolveMentionTokens(tokens []mentionToken, users MentionedUsers) map[string]*mentionedUser {
+	resolved := make(map[string]*mentionedUser)
+	for _, token := range tokens {
+		runes := []rune(token.name)
+		for i := len(runes); i > 0; i-- {
+			prefix := string(runes[:i])
+			if user, ok := users[strings.ToLower(prefix)]; ok {
+				resolved[token.name] = &mentionedUser{id: user.id, username: user.username, prefix: prefix}
+				break
+			}
+		}
+	}
+	return resolved
+}
+
+// 记录内容中的@提及并通知被提及的用户（notified中的用户已收到其他通知，不再重复提醒）
+func RecordMentions(source MentionSource, content string, notified ...int) {
+	if err := recordMentions(source, content, notified); err != nil {
+		log.Printf("记录提及失败 (%s=%d): %v", source.ContentType, source.ContentID, err)
+	}
+}
+
+func recordMentions(source MentionSource, content string, notified []int) error {
+	tokens := findMentionTokens(content)
+	users, err := lookupMentionedUsers(tokens)
+	if err != nil {
+		return err
+	}
+	resolved := resolveMentionTokens(tokens, users)
+	if len(resolved) == 0 {
+		return nil
+	}
+
+	skip := map[int]bool{source.ActorID: true}
+	for _, id := range notified {
+		skip[id] = true
+	}
+
+	count := 0
+	for _, token := range tokens {
+		user, ok := resolved[token.name]
+		if !ok || skip[user.id] {
+			continue
+		}
+		skip[user.id] = true
+
+		blocked, err := IsBlockedBetween(source.ActorID, user.id)
+		if err != nil {
+			return err
+		}
+		if blocked {
+			continue
+		}
+
+		result, err := DB.Exec("INSERT IGNORE INTO mentions (user_id, actor_id, content_type, content_id) VALUES (?, ?, ?, ?)",
+			user.id, source.ActorID, source.ContentType, source.ContentID)
+		if err != nil {
+			return err
+		}
+		if affected, _ := result.RowsAffected(); affected > 0 {
+			Notify(Notification{
+				UserID:     user.id,
+				ActorID:    source.ActorID,
+				Type:       NotifyMention,
+				TargetType: source.TargetType,
+				TargetID:   source.TargetID,
+				Subject:    source.Subject,
+				Content:    notificationExcerpt(content),
+				Link:       source.Link,
+			})
+		}
+
+		count++
+		if count >= maxMentionsPerContent {
+			break
+		}
+	}
+	return nil
+}
+
+// 渲染内容：转义HTML并把@用户名替换为个人主页链接（模板函数mentions）。
+// users由LoadMentionedUsers查出，渲染时不再访问数据库，不在users中的@片段按普通文本输出
+func RenderMentions(users MentionedUsers, content string) template.HTML {
+	tokens := findMentionTokens(content)
+	resolved := resolveMentionTokens(tokens, users)
+	if len(resolved) == 0 {
+		return template.HTML(template.HTMLEscapeString(content))
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, token := range tokens {
+		user, ok := resolved[token.name]
+		if !ok {
+			continue
+		}
+		// 链接只覆盖用户名部分，剩余文本照常输出
+		linkEnd := token.start + 1 + len(user.prefix)
+
+		b.WriteString(template.HTMLEscapeString(content[last:token.start]))
+		b.WriteString(`<a class="mention" href="/u/` + url.PathEscape(user.username) + `">`)
+		b.WriteString(template.HTMLEscapeString(content[token.start:linkEnd]))
+		b.WriteString(`</a>`)
+		last = linkEnd
+	}
+	b.WriteString(template.HTMLEscapeString(content[last:]))
+	return template.HTML(b.String())
+}
+
+// 按用户名前缀推荐用户（用于@自动补全），优先显示自己关注的人，排除屏蔽了自己的人
+func SuggestUsers(prefix string, userID, limit int) ([]*UserSuggestion, error) {
+	prefix = strings.TrimPrefix(strings.TrimSpace(prefix), "@")
+	if prefix == "" {
+		return []*UserSuggestion{}, nil
+	}
+
+	escaper := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	rows, err := DB.Query(`
+		SELECT u.id, u.username, u.avatar, u.points
+		FROM users u
+		WHERE u.username LIKE ? AND u.id != ?
+		  AND NOT EXISTS (SELECT 1 FROM user_blocks b WHERE b.blocker_id = u.id AND b.blocked_id = ?)
+		ORDER BY EXISTS (SELECT 1 FROM follows f WHERE f.follower_id = ? AND f.followed_id = u.id) DESC,
+				 u.points DESC, u.username
+		LIMIT ?
+	`, escaper.Replace(prefix)+"%", userID, userID, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	suggestions := []*UserSuggestion{}
+	for rows.Next() {
+		s := &UserSuggestion{}
+		var points int
+		if err := rows.Scan(&s.ID, &s.Username, &s.Avatar, &points); err != nil {
+			return nil, err
+		}
+		s.Level = GetUserLevel(points)
+		suggestions = append(suggestions, s)
+	}
+	return suggestions, rows.Err()
+}
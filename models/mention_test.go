@@ -0,0 +1,52 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+// 渲染只使用预先查出的用户，不访问数据库（测试中DB为nil）
+func TestRenderMentions(t *testing.T) {
+	users := MentionedUsers{
+		"张三":    {id: 1, username: "张三"},
+		"alice": {id: 2, username: "Alice"},
+	}
+
+	cases := []struct {
+		content, want string
+	}{
+		{"@张三你好", `<a class="mention" href="/u/%E5%BC%A0%E4%B8%89">@张三</a>你好`},
+		{"cc @ALICE <b>", `cc <a class="mention" href="/u/Alice">@ALICE</a> &lt;b&gt;`},
+		{"@bob 和 alice@example.com", "@bob 和 alice@example.com"},
+	}
+	for _, tc := range cases {
+		if got := string(RenderMentions(users, tc.content)); got != tc.want {
+			t.Errorf("RenderMentions(%q) = %q, want %q", tc.content, got, tc.want)
+		}
+	}
+
+	if got := string(RenderMentions(nil, "@张三 <i>")); got != "@张三 &lt;i&gt;" {
+		t.Errorf("没有用户时应只转义内容: %q", got)
+	}
+}
+
+// 查询用户前限制@片段数和每个片段的前缀数
+func TestMentionLookupLimits(t *testing.T) {
+	content := strings.Repeat("@a ", maxMentionTokens+5)
+	if got := len(findMentionTokens(content)); got != maxMentionTokens {
+		t.Errorf("@片段数 = %d, want %d", got, maxMentionTokens)
+	}
+
+	name := strings.Repeat("长", 50)
+	candidates := mentionCandidates(name)
+	if len(candidates) != maxMentionPrefixes+1 {
+		t.Errorf("候选用户名数 = %d, want %d", len(candidates), maxMentionPrefixes+1)
+	}
+	if candidates[0] != name {
+		t.Errorf("完整片段应作为候选: %q", candidates[0])
+	}
+
+	if got := mentionCandidates("Bob"); strings.Join(got, ",") != "bob,bo,b" {
+		t.Errorf("mentionCandidates(Bob) = %v", got)
+	}
+}
@@ -0,0 +1,162 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+)
+
+var (
+	ErrMFAAlreadyEnabled = errors.New("已开启两步验证")
+	ErrMFANotEnabled     = errors.New("未开启两步验证")
+	ErrMFANotEnrolled    = errors.New("请先获取两步验证密钥")
+)
+
+// 两步验证的设置
+type MFAStatus struct {
+	Enabled                bool `json:"enabled"`
+	Required               bool `json:"required"`
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+}
+
+// 管理员和版主必须开启两步验证
+func MFARequired(role string) bool {
+	return role == RoleAdmin || role == RoleModerator
+}
+
+// 用户是否开启了两步验证
+func TOTPEnabled(userID int) (bool, error) {
+	var enabled bool
+	err := DB.QueryRow("SELECT totp_enabled FROM users WHERE id = ?", userID).Scan(&enabled)
+	return enabled, err
+}
+
+// 获取两步验证设置
+func GetMFAStatus(userID int) (*MFAStatus, error) {
+	status := &MFAStatus{}
+	var role string
+	err := DB.QueryRow(`
+		SELECT u.totp_enabled, u.role,
+			(SELECT COUNT(*) FROM user_recovery_codes WHERE user_id = u.id AND used_at IS NULL)
+		FROM users u WHERE u.id = ?
+	`, userID).Scan(&status.Enabled, &role, &status.RecoveryCodesRemaining)
+	if err != nil {
+		return nil, err
+	}
+	status.Required = MFARequired(role)
+	return status, nil
+}
+
+// 获取TOTP密钥（包括尚未确认的密钥）
+func GetTOTPSecret(userID int) (string, bool, error) {
+	var secret sql.NullString
+	var enabled bool
+	err := DB.QueryRow("SELECT totp_secret, totp_enabled FROM users WHERE id = ?", userID).Scan(&secret, &enabled)
+	return secret.String, enabled, err
+}
+
+// 保存新生成的TOTP密钥，确认验证码后才会开启
+func SetPendingTOTPSecret(userID int, secret string) error {
+	result, err := DB.Exec("UPDATE users SET totp_secret = ? WHERE id = ? AND totp_enabled = FALSE", secret, userID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrMFAAlreadyEnabled
+	}
+	return nil
+}
+
+// 记录已使用的时间窗口，同一窗口的验证码只能使用一次
+func UseTOTPStep(userID int, step int64) (bool, error) {
+	result, err := DB.Exec("UPDATE users SET totp_last_step = ? WHERE id = ? AND totp_last_step < ?", step, userID, step)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// 使用一个恢复码，已使用或不存在时返回false
+func UseRecoveryCode(userID int, codeHash string) (bool, error) {
+	result, err := DB.Exec("UPDATE user_recovery_codes SET used_at = NOW() WHERE user_id = ? AND code_hash = ? AND used_at IS NULL",
+		userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// 开启两步验证并保存恢复码
+func EnableTOTP(userID int, step int64, codeHashes []string) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE users SET totp_enabled = TRUE, totp_last_step = ?
+		WHERE id = ? AND totp_enabled = FALSE AND totp_secret IS NOT NULL
+	`, step, userID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrMFAAlreadyEnabled
+	}
+	if err := replaceRecoveryCodes(tx, userID, codeHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// 重新生成恢复码，旧的恢复码全部失效
+func ReplaceRecoveryCodes(userID int, codeHashes []string) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := replaceRecoveryCodes(tx, userID, codeHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func replaceRecoveryCodes(tx *sql.Tx, userID int, codeHashes []string) error {
+	if _, err := tx.Exec("DELETE FROM user_recovery_codes WHERE user_id = ?", userID); err != nil {
+		return err
+	}
+	for _, hash := range codeHashes {
+		if _, err := tx.Exec("INSERT INTO user_recovery_codes (user_id, code_hash) VALUES (?, ?)", userID, hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 关闭两步验证，清除密钥和恢复码
+func DisableTOTP(userID int) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE users SET totp_enabled = FALSE, totp_secret = NULL, totp_last_step = 0
+		WHERE id = ? AND totp_enabled = TRUE
+	`, userID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrMFANotEnabled
+	}
+	if _, err := tx.Exec("DELETE FROM user_recovery_codes WHERE user_id = ?", userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
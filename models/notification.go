@@ -0,0 +1,309 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+
+	"aiforum/hub"
+)
+
+// 通知类型（messages.type）
+const (
+	NotifySystem     = "system"
+	NotifyAnswer     = "answer"
+	NotifyAccept     = "accept"
+	NotifyComment    = "comment"
+	NotifyReply      = "reply"
+	NotifyLike       = "like"
+	NotifyFollow     = "follow"
+	NotifyMention    = "mention"
+	NotifyModeration = "moderation"
+)
+
+// 通知对应的用户开关（未列出的类型总是发送）
+var notificationSettings = map[string]string{
+	NotifyAnswer:  "question_notifications",
+	NotifyAccept:  "question_notifications",
+	NotifyComment: "question_notifications",
+	NotifyReply:   "question_notifications",
+	NotifyLike:    "question_notifications",
+	NotifyFollow:  "follow_notifications",
+	NotifyMention: "mention_notifications",
+}
+
+// 会合并为一条消息的通知类型
+var batchedNotifications = map[string]bool{
+	NotifyAnswer:  true,
+	NotifyComment: true,
+	NotifyReply:   true,
+	NotifyLike:    true,
+	NotifyFollow:  true,
+}
+
+// 通知事件
+type Notification struct {
+	UserID     int    // 接收者
+	ActorID    int    // 触发者（0表示系统）
+	Type       string // 通知类型
+	TargetType string // 关联对象类型，如question、answer、article
+	TargetID   int    // 关联对象ID
+	Subject    string // 关联对象的描述，如问题标题
+	Content    string // 消息正文
+	Link       string // 跳转地址
+}
+
+// 关联对象的中文名称
+var notificationTargetNames = map[string]string{
+	"question": "问题",
+	"answer":   "回答",
+	"article":  "文章",
+	"resource": "资料",
+	"post":     "帖子",
+	"comment":  "评论",
+	"reply":    "回复",
+}
+
+// 发送通知（失败只记录日志，不影响业务流程）
+func Notify(n Notification) {
+	if err := createNotification(n); err != nil {
+		log.Printf("发送通知失败 (type=%s, user=%d): %v", n.Type, n.UserID, err)
+	}
+}
+
+func createNotification(n Notification) error {
+	if n.UserID <= 0 || n.UserID == n.ActorID {
+		return nil
+	}
+
+	enabled, err := notificationEnabled(n.UserID, n.Type)
+	if err != nil || !enabled {
+		return err
+	}
+
+	// 不通知来自已静音或已屏蔽用户的动作；系统和管理通知总是送达
+	if n.ActorID > 0 && n.Type != NotifySystem && n.Type != NotifyModeration {
+		hidden, err := isHiddenAuthor(n.UserID, n.ActorID)
+		if err != nil || hidden {
+			return err
+		}
+	}
+
+	sender := "系统"
+	if n.ActorID > 0 {
+		if err := DB.QueryRow("SELECT username FROM users WHERE id = ?", n.ActorID).Scan(&sender); err != nil {
+			return err
+		}
+	}
+
+	if !batchedNotifications[n.Type] || n.ActorID <= 0 {
+		messageID, err := insertNotification(n, sender, "")
+		if err != nil {
+			return err
+		}
+		publishNotification(n.UserID, messageID)
+		return nil
+	}
+
+	// 同一对象上未读的同类通知合并为一条，例如“某某等5人赞了你的回答”
+	groupKey := n.Type + ":" + n.TargetType + ":" + strconv.Itoa(n.TargetID)
+
+	var messageID, actorCount int
+	err = DB.QueryRow(`
+		SELECT id, actor_count FROM messages
+		WHERE user_id = ? AND group_key = ? AND is_read = 0
+		ORDER BY id DESC LIMIT 1
+	`, n.UserID, groupKey).Scan(&messageID, &actorCount)
+	if err == sql.ErrNoRows {
+		messageID, err = insertNotification(n, sender, groupKey)
+		if err != nil {
+			return err
+		}
+		_, err = DB.Exec("INSERT IGNORE INTO message_actors (message_id, actor_id) VALUES (?, ?)", messageID, n.ActorID)
+		if err != nil {
+			return err
+		}
+		publishNotification(n.UserID, messageID)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// 同一个人重复触发（如取消后再次点赞）不重复计数
+	result, err := DB.Exec("INSERT IGNORE INTO message_actors (message_id, actor_id) VALUES (?, ?)", messageID, n.ActorID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return nil
+	}
+
+	actorCount++
+	_, err = DB.Exec(`
+		UPDATE messages SET title = ?, content = ?, sender = ?, actor_count = ?, created_at = NOW()
+		WHERE id = ?
+	`, notificationTitle(n, sender, actorCount), n.Content, sender, actorCount, messageID)
+	if err != nil {
+		return err
+	}
+	publishNotification(n.UserID, messageID)
+	return nil
+}
+
+// 推送新通知和未读数
+func publishNotification(userID, messageID int) {
+	message, err := GetUserMessage(userID, messageID)
+	if err != nil {
+		log.Printf("推送通知失败 (message=%d): %v", messageID, err)
+		return
+	}
+	hub.Publish(hub.UserTopic(userID), "notification", message)
+	PublishUnreadCount(userID)
+}
+
+// 推送未读消息数
+func PublishUnreadCount(userID int) {
+	count, err := GetUnreadMessageCount(userID)
+	if err != nil {
+		log.Printf("推送未读数失败 (user=%d): %v", userID, err)
+		return
+	}
+	hub.Publish(hub.UserTopic(userID), "unread", map[string]int{"count": count})
+}
+
+// 写入一条通知消息
+func insertNotification(n Notification, sender, groupKey string) (int, error) {
+	var group interface{}
+	if groupKey != "" {
+		group = groupKey
+	}
+
+	result, err := DB.Exec(`
+		INSERT INTO messages (user_id, type, title, content, sender, link, group_key, actor_count)
+		VALUES (?, ?, ?, ?, ?, ?, ?, 1)
+	`, n.UserID, n.Type, notificationTitle(n, sender, 1), n.Content, sender, n.Link, group)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+// 检查用户是否开启了该类通知
+func notificationEnabled(userID int, notifyType string) (bool, error) {
+	column, ok := notificationSettings[notifyType]
+	if !ok {
+		return true, nil
+	}
+
+	var enabled sql.NullBool
+	err := DB.QueryRow("SELECT "+column+" FROM users WHERE id = ?", userID).Scan(&enabled)
+	if err != nil {
+		return false, err
+	}
+	// 未设置时默认开启
+	return !enabled.Valid || enabled.Bool, nil
+}
+
+// 生成通知标题
+func notificationTitle(n Notification, sender string, count int) string {
+	actor := sender
+	if count > 1 {
+		actor = fmt.Sprintf("%s 等 %d 人", sender, count)
+	}
+	target := notificationTargetNames[n.TargetType]
+
+	switch n.Type {
+	case NotifyAnswer:
+		// count是回答者人数，同一个人的多次回答只计一次
+		if count > 1 {
+			return fmt.Sprintf("%d 位用户回答了你的问题", count)
+		}
+		return sender + " 回答了你的问题"
+	case NotifyAccept:
+		return sender + " 采纳了你的回答"
+	case NotifyComment:
+		return actor + " 评论了你的" + target
+	case NotifyReply:
+		return actor + " 回复了你的" + target
+	case NotifyLike:
+		return actor + " 赞了你的" + target
+	case NotifyFollow:
+		return actor + " 关注了你"
+	case NotifyMention:
+		return sender + " 在" + target + "中提到了你"
+	case NotifyModeration:
+		return "你的" + target + "状态已更新"
+	}
+	return n.Subject
+}
+
+// 通知问题作者状态变更
+func notifyQuestionStateChange(questionID, moderatorID int, action, reason string) {
+	var ownerID int
+	var title string
+	if err := DB.QueryRow("SELECT user_id, title FROM questions WHERE id = ?", questionID).Scan(&ownerID, &title); err != nil {
+		log.Printf("发送通知失败 (question=%d): %v", questionID, err)
+		return
+	}
+
+	descriptions := map[string]string{
+		QuestionActionClose:     "已被关闭",
+		QuestionActionReopen:    "已重新开放",
+		QuestionActionLock:      "已被锁定",
+		QuestionActionUnlock:    "已解除锁定",
+		QuestionActionProtect:   "已被设为受保护",
+		QuestionActionUnprotect: "已取消保护",
+		QuestionActionWiki:      "已转为社区维基",
+	}
+	content := fmt.Sprintf("你的问题“%s”%s", title, descriptions[action])
+	if name, ok := CloseReasonNames[reason]; ok {
+		content += "，原因：" + name
+	}
+
+	Notify(Notification{
+		UserID:     ownerID,
+		ActorID:    moderatorID,
+		Type:       NotifyModeration,
+		TargetType: "question",
+		TargetID:   questionID,
+		Subject:    title,
+		Content:    content,
+		Link:       "/qa/" + strconv.Itoa(questionID) + "?redirect=no",
+	})
+}
+
+// 查询内容的作者和标题
+func contentOwner(table string, id int) (int, string, error) {
+	var ownerID int
+	var title string
+	query := "SELECT user_id, title FROM " + table + " WHERE id = ?"
+	if softDeleteTables[table] {
+		query += " AND deleted_at IS NULL"
+	}
+	err := DB.QueryRow(query, id).Scan(&ownerID, &title)
+	return ownerID, title, err
+}
+
+// 截取通知正文摘要
+func notificationExcerpt(content string) string {
+	runes := []rune(content)
+	if len(runes) <= 100 {
+		return content
+	}
+	return string(runes[:100]) + "..."
+}
+
+// 通知被关注的用户
+func notifyFollow(followerID, followedID int) {
+	Notify(Notification{
+		UserID:     followedID,
+		ActorID:    followerID,
+		Type:       NotifyFollow,
+		TargetType: "user",
+		TargetID:   followedID,
+	})
+}
@@ -0,0 +1,184 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+
+	"aiforum/utils"
+)
+
+// 每页数量的默认值和上限
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// 分页请求，Cursor为空时返回第一页
+type PageQuery struct {
+	Cursor *utils.Cursor
+	Limit  int
+}
+
+// 分页结果，游标为空表示没有上一页或下一页
+type PageInfo struct {
+	NextCursor string `json:"next_cursor"`
+	PrevCursor string `json:"prev_cursor"`
+}
+
+// 每页数量（超出范围时使用默认值）
+func (p PageQuery) limit() int {
+	if p.Limit < 1 || p.Limit > MaxPageSize {
+		return DefaultPageSize
+	}
+	return p.Limit
+}
+
+func (p PageQuery) backward() bool {
+	return p.Cursor != nil && p.Cursor.Backward
+}
+
+// 键集分页的排序：先按score（可选），再按createdAt、kind（可选）、id倒序。
+// id保证排序唯一，翻页时不会因为新增或删除内容出现重复和遗漏
+type keyset struct {
+	name      string // 排序方式，写入游标
+	score     string // 排序表达式，查询中以sort_score列返回
+	scoreAsc  bool
+	createdAt string
+	kind      string // 混合列表中的内容类型列
+	id        string
+}
+
+// 按时间倒序的键集分页
+func latestKeyset(name, createdAt, id string) keyset {
+	return keyset{name: name, createdAt: createdAt, id: id}
+}
+
+// 查询中返回排序键的列
+func (k keyset) scoreColumn() string {
+	if k.score == "" {
+		return "0 AS sort_score"
+	}
+	return k.score + " AS sort_score"
+}
+
+type keyColumn struct {
+	expr  string
+	asc   bool
+	value interface{}
+}
+
+func (k keyset) columns(cursor *utils.Cursor) []keyColumn {
+	var c utils.Cursor
+	if cursor != nil {
+		c = *cursor
+	}
+	var columns []keyColumn
+	if k.score != "" {
+		columns = append(columns, keyColumn{k.score, k.scoreAsc, c.Score})
+	}
+	columns = append(columns, keyColumn{k.createdAt, false, c.CreatedAt})
+	if k.kind != "" {
+		columns = append(columns, keyColumn{k.kind, false, c.Type})
+	}
+	return append(columns, keyColumn{k.id, false, c.ID})
+}
+
+// 补全查询的游标条件、排序和LIMIT，query需要以WHERE条件结尾。
+// 多取一条用于判断是否还有更多，上一页按相反顺序查询
+func (k keyset) apply(query string, args []interface{}, page PageQuery) (string, []interface{}, error) {
+	if page.Cursor != nil && page.Cursor.Sort != k.name {
+		return "", nil, utils.ErrInvalidCursor
+	}
+	backward := page.backward()
+	columns := k.columns(page.Cursor)
+
+	if page.Cursor != nil {
+		// (a, b, c) < (A, B, C) 展开为 a < A OR (a = A AND (b < B OR (b = B AND c < C)))
+		var cond string
+		var condArgs []interface{}
+		for i := len(columns) - 1; i >= 0; i-- {
+			col := columns[i]
+			op := "<"
+			if col.asc != backward {
+				op = ">"
+			}
+			if cond == "" {
+				cond = fmt.Sprintf("%s %s ?", col.expr, op)
+				condArgs = []interface{}{col.value}
+				continue
+			}
+			cond = fmt.Sprintf("(%[1]s %[2]s ? OR (%[1]s = ? AND %[3]s))", col.expr, op, cond)
+			condArgs = append([]interface{}{col.value, col.value}, condArgs...)
+		}
+		query += " AND " + cond
+		args = append(args, condArgs...)
+	}
+
+	query += " ORDER BY "
+	for i, col := range columns {
+		if i > 0 {
+			query += ", "
+		}
+		if col.asc != backward {
+			query += col.expr + " ASC"
+		} else {
+			query += col.expr + " DESC"
+		}
+	}
+	query += " LIMIT ?"
+	args = append(args, page.limit()+1)
+	return query, args, nil
+}
+
+// 整理一页查询结果：去掉多取的一条，上一页恢复正常顺序，并生成前后页游标。
+// keys是每条结果的排序键，与items一一对应
+func finishPage[T any](items []T, keys []utils.Cursor, k keyset, page PageQuery) ([]T, PageInfo) {
+	var info PageInfo
+	more := len(items) > page.limit()
+	if more {
+		items, keys = items[:page.limit()], keys[:page.limit()]
+	}
+	backward := page.backward()
+	if backward {
+		slices.Reverse(items)
+		slices.Reverse(keys)
+	}
+	if len(items) == 0 {
+		return items, info
+	}
+
+	hasNext, hasPrev := more, page.Cursor != nil
+	if backward {
+		hasNext, hasPrev = true, more
+	}
+	if hasNext {
+		next := keys[len(keys)-1]
+		next.Sort = k.name
+		info.NextCursor = utils.EncodeCursor(next)
+	}
+	if hasPrev {
+		prev := keys[0]
+		prev.Sort, prev.Backward = k.name, true
+		info.PrevCursor = utils.EncodeCursor(prev)
+	}
+	return items, info
+}
+
+// 逐页读取全部结果（用于数据导出）
+func fetchAll[T any](fetch func(PageQuery) ([]T, PageInfo, error)) ([]T, error) {
+	var all []T
+	page := PageQuery{Limit: MaxPageSize}
+	for {
+		items, info, err := fetch(page)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, items...)
+		if info.NextCursor == "" {
+			return all, nil
+		}
+		if page.Cursor, err = utils.DecodeCursor(info.NextCursor); err != nil {
+			return nil, err
+		}
+	}
+}
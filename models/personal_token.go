@@ -0,0 +1,179 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+// 个人访问令牌的权限范围
+const (
+	ScopeRead            = "read"
+	ScopeWriteQuestions  = "write:questions"
+	ScopeWriteArticles   = "write:articles"
+	ScopeUploadResources = "upload:resources"
+)
+
+// 所有可用的权限范围
+var PersonalTokenScopes = []string{ScopeRead, ScopeWriteQuestions, ScopeWriteArticles, ScopeUploadResources}
+
+// 每个用户最多创建的令牌数量
+const maxPersonalTokens = 20
+
+var (
+	ErrInvalidScope         = errors.New("无效的权限范围")
+	ErrTooManyTokens        = errors.New("令牌数量已达上限，请先删除不用的令牌")
+	ErrPersonalTokenExpired = errors.New("令牌已过期")
+)
+
+// 个人访问令牌
+type PersonalToken struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// 通过令牌认证的用户
+type TokenOwner struct {
+	UserID   int
+	Username string
+	Scopes   []string
+}
+
+// 检查权限范围是否有效
+func ValidScopes(scopes []string) bool {
+	if len(scopes) == 0 {
+		return false
+	}
+	for _, scope := range scopes {
+		valid := false
+		for _, s := range PersonalTokenScopes {
+			if scope == s {
+				valid = true
+			}
+		}
+		if !valid {
+			return false
+		}
+	}
+	return true
+}
+
+// 创建个人访问令牌，tokenHash为令牌的哈希，prefix用于在列表中辨认令牌
+func CreatePersonalToken(userID int, name, tokenHash, prefix string, scopes []string, expiresAt *time.Time) (*PersonalToken, error) {
+	if !ValidScopes(scopes) {
+		return nil, ErrInvalidScope
+	}
+
+	var count int
+	if err := DB.QueryRow("SELECT COUNT(*) FROM personal_access_tokens WHERE user_id = ?", userID).Scan(&count); err != nil {
+		return nil, err
+	}
+	if count >= maxPersonalTokens {
+		return nil, ErrTooManyTokens
+	}
+
+	result, err := DB.Exec(`
+		INSERT INTO personal_access_tokens (user_id, name, token_hash, token_prefix, scopes, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, userID, name, tokenHash, prefix, strings.Join(scopes, ","), expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	return &PersonalToken{
+		ID:        int(id),
+		Name:      name,
+		Prefix:    prefix,
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// 获取用户的个人访问令牌
+func GetPersonalTokens(userID int) ([]*PersonalToken, error) {
+	rows, err := DB.Query(`
+		SELECT id, name, token_prefix, scopes, expires_at, last_used_at, created_at
+		FROM personal_access_tokens WHERE user_id = ? ORDER BY created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []*PersonalToken{}
+	for rows.Next() {
+		token := &PersonalToken{}
+		var scopes string
+		var expiresAt, lastUsedAt sql.NullTime
+		if err := rows.Scan(&token.ID, &token.Name, &token.Prefix, &scopes, &expiresAt, &lastUsedAt, &token.CreatedAt); err != nil {
+			return nil, err
+		}
+		token.Scopes = strings.Split(scopes, ",")
+		if expiresAt.Valid {
+			token.ExpiresAt = &expiresAt.Time
+		}
+		if lastUsedAt.Valid {
+			token.LastUsedAt = &lastUsedAt.Time
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, rows.Err()
+}
+
+// 删除个人访问令牌，令牌立即失效
+func RevokePersonalToken(userID, tokenID int) error {
+	result, err := DB.Exec("DELETE FROM personal_access_tokens WHERE id = ? AND user_id = ?", tokenID, userID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// 根据令牌哈希认证并记录使用时间，令牌不存在时返回sql.ErrNoRows
+func AuthenticatePersonalToken(tokenHash string) (*TokenOwner, error) {
+	owner := &TokenOwner{}
+	var id int
+	var scopes string
+	var expiresAt sql.NullTime
+	err := DB.QueryRow(`
+		SELECT t.id, t.user_id, u.username, t.scopes, t.expires_at
+		FROM personal_access_tokens t
+		JOIN users u ON u.id = t.user_id
+		WHERE t.token_hash = ?
+	`, tokenHash).Scan(&id, &owner.UserID, &owner.Username, &scopes, &expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if expiresAt.Valid && time.Now().After(expiresAt.Time) {
+		return nil, ErrPersonalTokenExpired
+	}
+	owner.Scopes = strings.Split(scopes, ",")
+
+	// 使用时间只用于展示，更新失败不影响认证
+	DB.Exec("UPDATE personal_access_tokens SET last_used_at = NOW() WHERE id = ?", id)
+	return owner, nil
+}
+
+// 令牌是否包含权限范围
+func (o *TokenOwner) HasScope(scope string) bool {
+	for _, s := range o.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
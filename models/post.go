@@ -1,5 +1,11 @@
 package models
 
+import (
+	"strconv"
+
+	"aiforum/utils"
+)
+
 // 创建帖子
 func CreatePost(title, content string, categoryID, userID int, tags string) (int, error) {
 	result, err := DB.Exec("INSERT INTO posts (title, content, category_id, user_id, tags) VALUES (?, ?, ?, ?, ?)",
@@ -13,6 +19,12 @@ func CreatePost(title, content string, categoryID, userID int, tags string) (int
 		return 0, err
 	}
 
+	// 建立标签关联
+	err = SyncContentTags(ContentTypePost, int(postID), tags)
+	if err != nil {
+		return 0, err
+	}
+
 	// 更新分类帖子数量
 	_, err = DB.Exec("UPDATE categories SET post_count = post_count + 1 WHERE id = ?", categoryID)
 	if err != nil {
@@ -25,48 +37,51 @@ func CreatePost(title, content string, categoryID, userID int, tags string) (int
 		return 0, err
 	}
 
+	// 通知被@的用户
+	RecordMentions(MentionSource{
+		ContentType: MentionInPost,
+		ContentID:   int(postID),
+		ActorID:     userID,
+		TargetType:  "post",
+		TargetID:    int(postID),
+		Subject:     title,
+		Link:        "/post/" + strconv.Itoa(int(postID)),
+	}, content)
+
 	return int(postID), nil
 }
 
+// 帖子列表的排序（按发布时间倒序）
+var postKeyset = latestKeyset("latest", "p.created_at", "p.id")
+
 // 获取帖子列表
-func GetPosts(page, limit int, categoryID int) ([]*Post, error) {
-	offset := (page - 1) * limit
-	
-	var query string
+func GetPosts(categoryID int, page PageQuery) ([]*Post, PageInfo, error) {
+	query := `
+		SELECT p.id, p.title, p.content, p.category_id, p.user_id,
+			   u.username, u.avatar, p.view_count, p.reply_count, p.like_count,
+			   p.tags, p.created_at, p.updated_at
+		FROM posts p
+		JOIN users u ON p.user_id = u.id
+		WHERE 1 = 1`
 	var args []interface{}
-	
 	if categoryID > 0 {
-		query = `
-			SELECT p.id, p.title, p.content, p.category_id, p.user_id, 
-				   u.username, u.avatar, p.view_count, p.reply_count, p.like_count, 
-				   p.tags, p.created_at, p.updated_at
-			FROM posts p
-			JOIN users u ON p.user_id = u.id
-			WHERE p.category_id = ?
-			ORDER BY p.created_at DESC
-			LIMIT ? OFFSET ?
-		`
-		args = []interface{}{categoryID, limit, offset}
-	} else {
-		query = `
-			SELECT p.id, p.title, p.content, p.category_id, p.user_id, 
-				   u.username, u.avatar, p.view_count, p.reply_count, p.like_count, 
-				   p.tags, p.created_at, p.updated_at
-			FROM posts p
-			JOIN users u ON p.user_id = u.id
-			ORDER BY p.created_at DESC
-			LIMIT ? OFFSET ?
-		`
-		args = []interface{}{limit, offset}
+		query += " AND p.category_id = ?"
+		args = append(args, categoryID)
+	}
+
+	query, args, err := postKeyset.apply(query, args, page)
+	if err != nil {
+		return nil, PageInfo{}, err
 	}
 
 	rows, err := DB.Query(query, args...)
 	if err != nil {
-		return nil, err
+		return nil, PageInfo{}, err
 	}
 	defer rows.Close()
 
 	var posts []*Post
+	var keys []utils.Cursor
 	for rows.Next() {
 		post := &Post{}
 		err := rows.Scan(
@@ -75,12 +90,29 @@ func GetPosts(page, limit int, categoryID int) ([]*Post, error) {
 			&post.Tags, &post.CreatedAt, &post.UpdatedAt,
 		)
 		if err != nil {
-			return nil, err
+			return nil, PageInfo{}, err
 		}
 		posts = append(posts, post)
+		keys = append(keys, utils.Cursor{CreatedAt: post.CreatedAt, ID: post.ID})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, PageInfo{}, err
 	}
 
-	return posts, nil
+	posts, info := finishPage(posts, keys, postKeyset, page)
+	return posts, info, nil
+}
+
+// 统计帖子数量
+func GetPostCount(categoryID int) (int, error) {
+	var count int
+	var err error
+	if categoryID > 0 {
+		err = DB.QueryRow("SELECT COUNT(*) FROM posts WHERE category_id = ?", categoryID).Scan(&count)
+	} else {
+		err = DB.QueryRow("SELECT COUNT(*) FROM posts").Scan(&count)
+	}
+	return count, err
 }
 
 // 根据ID获取帖子
@@ -111,29 +143,36 @@ func IncrementPostViewCount(id int) error {
 	return err
 }
 
-// 搜索帖子
-func SearchPosts(keyword string, page, limit int) ([]*Post, error) {
-	offset := (page - 1) * limit
-	
+// 搜索帖子（viewerID不为0时排除其静音和屏蔽的作者）
+func SearchPosts(keyword string, viewerID int, page PageQuery) ([]*Post, PageInfo, error) {
 	query := `
 		SELECT p.id, p.title, p.content, p.category_id, p.user_id, 
 			   u.username, u.avatar, p.view_count, p.reply_count, p.like_count, 
 			   p.tags, p.created_at, p.updated_at
 		FROM posts p
 		JOIN users u ON p.user_id = u.id
-		WHERE p.title LIKE ? OR p.content LIKE ? OR p.tags LIKE ?
-		ORDER BY p.created_at DESC
-		LIMIT ? OFFSET ?
-	`
+		WHERE (p.title LIKE ? OR p.content LIKE ? OR p.tags LIKE ?)`
 	
 	keyword = "%" + keyword + "%"
-	rows, err := DB.Query(query, keyword, keyword, keyword, limit, offset)
+	args := []interface{}{keyword, keyword, keyword}
+	if viewerID > 0 {
+		query += hiddenAuthorFilter("p.user_id")
+		args = append(args, viewerID, viewerID)
+	}
+
+	query, args, err := postKeyset.apply(query, args, page)
 	if err != nil {
-		return nil, err
+		return nil, PageInfo{}, err
+	}
+
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return nil, PageInfo{}, err
 	}
 	defer rows.Close()
 
 	var posts []*Post
+	var keys []utils.Cursor
 	for rows.Next() {
 		post := &Post{}
 		err := rows.Scan(
@@ -142,12 +181,17 @@ func SearchPosts(keyword string, page, limit int) ([]*Post, error) {
 			&post.Tags, &post.CreatedAt, &post.UpdatedAt,
 		)
 		if err != nil {
-			return nil, err
+			return nil, PageInfo{}, err
 		}
 		posts = append(posts, post)
+		keys = append(keys, utils.Cursor{CreatedAt: post.CreatedAt, ID: post.ID})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, PageInfo{}, err
 	}
 
-	return posts, nil
+	posts, info := finishPage(posts, keys, postKeyset, page)
+	return posts, info, nil
 }
 
 // 获取热门帖子
@@ -0,0 +1,292 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// 公开个人主页
+type PublicProfile struct {
+	ID          int              `json:"id"`
+	Username    string           `json:"username"`
+	Avatar      string           `json:"avatar"`
+	Level       int              `json:"level"`
+	Restricted  bool             `json:"restricted"` // 私密主页且访问者无权查看
+	Bio         string           `json:"bio,omitempty"`
+	Website     string           `json:"website,omitempty"`
+	Email       string           `json:"email,omitempty"`
+	Phone       string           `json:"phone,omitempty"`
+	Points      int              `json:"points,omitempty"`
+	Role        string           `json:"role,omitempty"`
+	IsFollowing bool             `json:"is_following"`
+	IsMutual    bool             `json:"is_mutual"` // 互相关注
+	IsSelf      bool             `json:"is_self"`
+	Stats       *ProfileStats    `json:"stats,omitempty"`
+	Badges      []Badge          `json:"badges,omitempty"`
+	TopAnswers  []ProfileAnswer  `json:"top_answers,omitempty"`
+	TopArticles []ProfileArticle `json:"top_articles,omitempty"`
+	CreatedAt   *time.Time       `json:"created_at,omitempty"`
+}
+
+// 个人主页统计
+type ProfileStats struct {
+	Questions       int `json:"questions"`
+	Answers         int `json:"answers"`
+	AcceptedAnswers int `json:"accepted_answers"`
+	Articles        int `json:"articles"`
+	ArticleLikes    int `json:"article_likes"`
+	Resources       int `json:"resources"`
+	Followers       int `json:"followers"`
+	Following       int `json:"following"`
+}
+
+// 徽章（根据统计数据计算，不单独存储）
+type Badge struct {
+	Key         string `json:"key"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Icon        string `json:"icon"`
+}
+
+// 主页展示的高赞回答
+type ProfileAnswer struct {
+	ID            int       `json:"id"`
+	QuestionID    int       `json:"question_id"`
+	QuestionTitle string    `json:"question_title"`
+	Excerpt       string    `json:"excerpt"`
+	LikeCount     int       `json:"like_count"`
+	IsAccepted    bool      `json:"is_accepted"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// 主页展示的热门文章
+type ProfileArticle struct {
+	ID        int       `json:"id"`
+	Title     string    `json:"title"`
+	Summary   string    `json:"summary"`
+	LikeCount int       `json:"like_count"`
+	ViewCount int       `json:"view_count"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// 主页展示的回答和文章数量
+const profileTopItems = 5
+
+// 徽章规则
+var badgeRules = []struct {
+	Badge
+	earned func(s *ProfileStats, level int, joined time.Time) bool
+}{
+	{Badge{"expert", "社区专家", "等级达到5级", "fas fa-crown"},
+		func(s *ProfileStats, level int, joined time.Time) bool { return level >= 5 }},
+	{Badge{"mentor", "答疑导师", "50个回答被采纳", "fas fa-chalkboard-teacher"},
+		func(s *ProfileStats, level int, joined time.Time) bool { return s.AcceptedAnswers >= 50 }},
+	{Badge{"helper", "热心解答", "10个回答被采纳", "fas fa-hands-helping"},
+		func(s *ProfileStats, level int, joined time.Time) bool { return s.AcceptedAnswers >= 10 }},
+	{Badge{"popular-author", "人气作者", "文章累计获得100个赞", "fas fa-fire"},
+		func(s *ProfileStats, level int, joined time.Time) bool { return s.ArticleLikes >= 100 }},
+	{Badge{"author", "技术作者", "发表5篇技术文章", "fas fa-pen-nib"},
+		func(s *ProfileStats, level int, joined time.Time) bool { return s.Articles >= 5 }},
+	{Badge{"curator", "资料达人", "分享5份学习资料", "fas fa-book-open"},
+		func(s *ProfileStats, level int, joined time.Time) bool { return s.Resources >= 5 }},
+	{Badge{"veteran", "社区元老", "注册满一年", "fas fa-medal"},
+		func(s *ProfileStats, level int, joined time.Time) bool { return time.Since(joined) >= 365*24*time.Hour }},
+}
+
+// 按用户名获取公开主页
+func GetPublicProfileByUsername(username string, viewerID int) (*PublicProfile, error) {
+	var userID int
+	if err := DB.QueryRow("SELECT id FROM users WHERE username = ?", username).Scan(&userID); err != nil {
+		return nil, err
+	}
+	return GetPublicProfile(userID, viewerID)
+}
+
+// 批量检查查看者能否看到用户主页的完整信息（积分、注册时间等），规则与GetPublicProfile相同：
+// 私密主页只对本人、管理员和关注者开放（viewerID为0表示未登录）
+func GetVisibleProfileIDs(viewerID int, ids []int) (map[int]bool, error) {
+	visible := make(map[int]bool)
+	if len(ids) == 0 {
+		return visible, nil
+	}
+	if viewerID > 0 {
+		if viewer, err := GetUserByID(viewerID); err == nil && viewer.IsAdmin() {
+			for _, id := range ids {
+				visible[id] = true
+			}
+			return visible, nil
+		}
+	}
+
+	in, args := inClause(ids)
+	args = append([]interface{}{viewerID, viewerID}, args...)
+	rows, err := DB.Query(`
+		SELECT u.id FROM users u
+		WHERE (u.profile_public IS NULL OR u.profile_public = TRUE OR u.id = ?
+			OR EXISTS (SELECT 1 FROM follows f WHERE f.follower_id = ? AND f.followed_id = u.id))
+		  AND u.id IN (`+in+`)
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		visible[id] = true
+	}
+	return visible, rows.Err()
+}
+
+// 获取公开主页，按用户的隐私设置隐藏字段（viewerID为0表示未登录）
+func GetPublicProfile(userID, viewerID int) (*PublicProfile, error) {
+	profile := &PublicProfile{}
+	var bio, website, email, phone string
+	var points int
+	var role string
+	var createdAt time.Time
+	var profilePublic, showEmail, showPhone sql.NullBool
+	err := DB.QueryRow(`
+		SELECT id, username, avatar, IFNULL(bio, ''), IFNULL(website, ''), email, IFNULL(phone, ''),
+			   points, role, profile_public, show_email, show_phone, created_at
+		FROM users WHERE id = ?
+	`, userID).Scan(&profile.ID, &profile.Username, &profile.Avatar, &bio, &website, &email, &phone,
+		&points, &role, &profilePublic, &showEmail, &showPhone, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+	profile.Level = GetUserLevel(points)
+	profile.IsSelf = viewerID > 0 && viewerID == userID
+
+	if viewerID > 0 && !profile.IsSelf {
+		if profile.IsFollowing, err = IsFollowing(viewerID, userID); err != nil {
+			return nil, err
+		}
+		if profile.IsFollowing {
+			if profile.IsMutual, err = IsFollowing(userID, viewerID); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// 私密主页只对本人、管理员和关注者开放
+	public := !profilePublic.Valid || profilePublic.Bool
+	if !public && !profile.IsSelf && !profile.IsFollowing {
+		if viewerID == 0 {
+			profile.Restricted = true
+			return profile, nil
+		}
+		viewer, err := GetUserByID(viewerID)
+		if err != nil || !viewer.IsAdmin() {
+			profile.Restricted = true
+			return profile, nil
+		}
+	}
+
+	profile.Bio = bio
+	profile.Website = website
+	profile.Points = points
+	profile.Role = role
+	profile.CreatedAt = &createdAt
+	if profile.IsSelf || (showEmail.Valid && showEmail.Bool) {
+		profile.Email = email
+	}
+	if profile.IsSelf || (showPhone.Valid && showPhone.Bool) {
+		profile.Phone = phone
+	}
+
+	if profile.Stats, err = getProfileStats(userID); err != nil {
+		return nil, err
+	}
+	for _, rule := range badgeRules {
+		if rule.earned(profile.Stats, profile.Level, createdAt) {
+			profile.Badges = append(profile.Badges, rule.Badge)
+		}
+	}
+	if profile.TopAnswers, err = getProfileTopAnswers(userID); err != nil {
+		return nil, err
+	}
+	if profile.TopArticles, err = getProfileTopArticles(userID); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// 统计用户的内容和关注数据
+func getProfileStats(userID int) (*ProfileStats, error) {
+	stats := &ProfileStats{}
+	err := DB.QueryRow(`
+		SELECT
+			(SELECT COUNT(*) FROM questions WHERE user_id = ? AND deleted_at IS NULL),
+			(SELECT COUNT(*) FROM answers WHERE user_id = ? AND deleted_at IS NULL),
+			(SELECT COUNT(*) FROM answers WHERE user_id = ? AND is_accepted = 1 AND deleted_at IS NULL),
+			(SELECT COUNT(*) FROM tech_articles WHERE user_id = ? AND deleted_at IS NULL),
+			(SELECT IFNULL(SUM(like_count), 0) FROM tech_articles WHERE user_id = ? AND deleted_at IS NULL),
+			(SELECT COUNT(*) FROM learning_resources WHERE user_id = ? AND deleted_at IS NULL),
+			follower_count,
+			following_count
+		FROM users WHERE id = ?
+	`, userID, userID, userID, userID, userID, userID, userID).Scan(
+		&stats.Questions, &stats.Answers, &stats.AcceptedAnswers, &stats.Articles,
+		&stats.ArticleLikes, &stats.Resources, &stats.Followers, &stats.Following,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// 获取采纳和点赞最多的回答
+func getProfileTopAnswers(userID int) ([]ProfileAnswer, error) {
+	rows, err := DB.Query(`
+		SELECT a.id, a.question_id, q.title, a.content, a.like_count, a.is_accepted, a.created_at
+		FROM answers a
+		JOIN questions q ON a.question_id = q.id
+		WHERE a.user_id = ? AND a.deleted_at IS NULL AND q.deleted_at IS NULL
+		ORDER BY a.is_accepted DESC, a.like_count DESC, a.created_at DESC
+		LIMIT ?
+	`, userID, profileTopItems)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var answers []ProfileAnswer
+	for rows.Next() {
+		var a ProfileAnswer
+		if err := rows.Scan(&a.ID, &a.QuestionID, &a.QuestionTitle, &a.Excerpt, &a.LikeCount, &a.IsAccepted, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		a.Excerpt = notificationExcerpt(a.Excerpt)
+		answers = append(answers, a)
+	}
+	return answers, rows.Err()
+}
+
+// 获取点赞最多的文章
+func getProfileTopArticles(userID int) ([]ProfileArticle, error) {
+	rows, err := DB.Query(`
+		SELECT id, title, IFNULL(summary, ''), like_count, view_count, created_at
+		FROM tech_articles
+		WHERE user_id = ? AND deleted_at IS NULL
+		ORDER BY like_count DESC, view_count DESC
+		LIMIT ?
+	`, userID, profileTopItems)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var articles []ProfileArticle
+	for rows.Next() {
+		var a ProfileArticle
+		if err := rows.Scan(&a.ID, &a.Title, &a.Summary, &a.LikeCount, &a.ViewCount, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		articles = append(articles, a)
+	}
+	return articles, rows.Err()
+}
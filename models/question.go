@@ -2,8 +2,12 @@ package models
 
 import (
 	"database/sql"
+	"errors"
+	"strconv"
 	"strings"
 	"time"
+
+	"aiforum/utils"
 )
 
 // 问题模型
@@ -24,10 +28,19 @@ type Question struct {
 	IsSolved        bool      `json:"is_solved"`
 	AcceptedAnswer  string    `json:"accepted_answer"`
 	Summary         string    `json:"summary"`
+	DuplicateOf     int       `json:"duplicate_of"`
+	Status          string    `json:"status"`
+	CloseReason     string    `json:"close_reason"`
+	IsLocked        bool      `json:"is_locked"`
+	IsProtected     bool      `json:"is_protected"`
+	IsWiki          bool      `json:"is_wiki"`
 	CreatedAt       time.Time `json:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at"`
 }
 
+// 重复问题相关错误
+var ErrInvalidDuplicate = errors.New("不能将问题标记为自身的重复问题")
+
 // 回答模型
 type Answer struct {
 	ID         int       `json:"id"`
@@ -60,6 +73,15 @@ func CreateQuestion(title, content string, categoryID, userID int, tags string,
 		return 0, err
 	}
 
+	// 新问题加入相似度索引
+	updateSimilarityIndex(int(questionID))
+
+	// 建立标签关联
+	err = SyncContentTags(ContentTypeQuestion, int(questionID), tags)
+	if err != nil {
+		return 0, err
+	}
+
 	// 更新分类问题数量
 	_, err = DB.Exec("UPDATE categories SET post_count = post_count + 1 WHERE id = ?", categoryID)
 	if err != nil {
@@ -72,70 +94,108 @@ func CreateQuestion(title, content string, categoryID, userID int, tags string,
 		return 0, err
 	}
 
+	// 通知被@的用户
+	RecordMentions(MentionSource{
+		ContentType: MentionInQuestion,
+		ContentID:   int(questionID),
+		ActorID:     userID,
+		TargetType:  "question",
+		TargetID:    int(questionID),
+		Subject:     title,
+		Link:        "/qa/" + strconv.Itoa(int(questionID)),
+	}, title+"\n"+content)
+
+	DispatchWebhookEvent(WebhookEvent{
+		Name:        WebhookQuestionCreated,
+		ActorID:     userID,
+		ContentType: ContentTypeQuestion,
+		ContentID:   int(questionID),
+		Data: map[string]interface{}{
+			"id":          int(questionID),
+			"title":       title,
+			"summary":     summary,
+			"category_id": categoryID,
+			"reward":      reward,
+			"url":         siteURL("/qa/" + strconv.Itoa(int(questionID))),
+		},
+	})
+
 	return int(questionID), nil
 }
 
-// 获取问题列表
-func GetQuestions(page, limit, categoryID int, sort string) ([]*Question, error) {
-	offset := (page - 1) * limit
-	
-	var query string
-	var args []interface{}
-	
-	baseQuery := `
-		SELECT q.id, q.title, q.content, q.category_id, q.user_id, 
-			   u.username, u.avatar, q.view_count, q.answer_count, q.like_count, 
-			   q.tags, q.reward, q.is_solved, q.summary, q.created_at, q.updated_at
+// 问题列表的排序方式
+var questionKeysets = map[string]keyset{
+	"latest":   latestKeyset("latest", "q.created_at", "q.id"),
+	"hot":      {name: "hot", score: "(q.view_count + q.answer_count * 2 + q.like_count * 3)", createdAt: "q.created_at", id: "q.id"},
+	"reward":   {name: "reward", score: "q.reward", createdAt: "q.created_at", id: "q.id"},
+	"unsolved": {name: "unsolved", score: "q.is_solved", scoreAsc: true, createdAt: "q.created_at", id: "q.id"},
+}
+
+// 获取问题列表（未知的排序方式按最新排序）
+func GetQuestions(categoryID int, sort string, page PageQuery) ([]*Question, PageInfo, error) {
+	k, ok := questionKeysets[sort]
+	if !ok {
+		k = questionKeysets["latest"]
+	}
+
+	from := `
 		FROM questions q
 		JOIN users u ON q.user_id = u.id
-	`
-	
-	whereClause := ""
+		WHERE q.deleted_at IS NULL`
+	var args []interface{}
 	if categoryID > 0 {
-		whereClause = "WHERE q.category_id = ?"
+		from += " AND q.category_id = ?"
 		args = append(args, categoryID)
 	}
-	
-	orderClause := "ORDER BY q.created_at DESC"
-	switch sort {
-	case "hot":
-		orderClause = "ORDER BY (q.view_count + q.answer_count * 2 + q.like_count * 3) DESC"
-	case "reward":
-		orderClause = "ORDER BY q.reward DESC"
-	case "unsolved":
-		orderClause = "ORDER BY q.is_solved ASC, q.created_at DESC"
+
+	return queryQuestionPage(from, args, k, page)
+}
+
+// 查询一页问题，from为FROM和WHERE子句
+func queryQuestionPage(from string, args []interface{}, k keyset, page PageQuery) ([]*Question, PageInfo, error) {
+	query, args, err := k.apply(`
+		SELECT q.id, q.title, q.content, q.category_id, q.user_id,
+			   u.username, u.avatar, q.view_count, q.answer_count, q.like_count,
+			   q.tags, q.reward, q.is_solved, q.summary, q.created_at, q.updated_at, `+k.scoreColumn()+from, args, page)
+	if err != nil {
+		return nil, PageInfo{}, err
 	}
-	
-	query = baseQuery + " " + whereClause + " " + orderClause + " LIMIT ? OFFSET ?"
-	args = append(args, limit, offset)
-	
+
 	rows, err := DB.Query(query, args...)
 	if err != nil {
-		return nil, err
+		return nil, PageInfo{}, err
 	}
 	defer rows.Close()
 
 	var questions []*Question
+	var keys []utils.Cursor
 	for rows.Next() {
 		question := &Question{}
+		var score float64
 		err := rows.Scan(
 			&question.ID, &question.Title, &question.Content, &question.CategoryID, &question.UserID,
 			&question.Username, &question.UserAvatar, &question.ViewCount, &question.AnswerCount, &question.LikeCount,
-			&question.Tags, &question.Reward, &question.IsSolved, &question.Summary, &question.CreatedAt, &question.UpdatedAt,
+			&question.Tags, &question.Reward, &question.IsSolved, &question.Summary, &question.CreatedAt, &question.UpdatedAt, &score,
 		)
 		if err != nil {
-			return nil, err
+			return nil, PageInfo{}, err
 		}
-		
-		// 获取采纳的回答
+		questions = append(questions, question)
+		keys = append(keys, utils.Cursor{CreatedAt: question.CreatedAt, ID: question.ID, Score: score})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, PageInfo{}, err
+	}
+
+	questions, info := finishPage(questions, keys, k, page)
+
+	// 获取采纳的回答
+	for _, question := range questions {
 		if question.IsSolved {
 			question.AcceptedAnswer, _ = getAcceptedAnswer(question.ID)
 		}
-		
-		questions = append(questions, question)
 	}
-
-	return questions, nil
+	return questions, info, nil
 }
 
 // 根据ID获取问题
@@ -144,14 +204,16 @@ func GetQuestionByID(id int) (*Question, error) {
 	err := DB.QueryRow(`
 		SELECT q.id, q.title, q.content, q.category_id, q.user_id, 
 			   u.username, u.avatar, q.view_count, q.answer_count, q.like_count, 
-			   q.tags, q.reward, q.is_solved, q.summary, q.created_at, q.updated_at
+			   q.tags, q.reward, q.is_solved, q.summary, IFNULL(q.duplicate_of, 0),
+			   q.status, IFNULL(q.close_reason, ''), q.is_locked, q.is_protected, q.is_wiki, q.created_at, q.updated_at
 		FROM questions q
 		JOIN users u ON q.user_id = u.id
-		WHERE q.id = ?
+		WHERE q.id = ? AND q.deleted_at IS NULL
 	`, id).Scan(
 		&question.ID, &question.Title, &question.Content, &question.CategoryID, &question.UserID,
 		&question.Username, &question.UserAvatar, &question.ViewCount, &question.AnswerCount, &question.LikeCount,
-		&question.Tags, &question.Reward, &question.IsSolved, &question.Summary, &question.CreatedAt, &question.UpdatedAt,
+		&question.Tags, &question.Reward, &question.IsSolved, &question.Summary, &question.DuplicateOf,
+		&question.Status, &question.CloseReason, &question.IsLocked, &question.IsProtected, &question.IsWiki, &question.CreatedAt, &question.UpdatedAt,
 	)
 	
 	if err != nil {
@@ -166,107 +228,88 @@ func GetQuestionByID(id int) (*Question, error) {
 	return question, nil
 }
 
-// 搜索问题
-func SearchQuestions(keyword string, page, limit int) ([]*Question, error) {
-	offset := (page - 1) * limit
-	keyword = "%" + keyword + "%"
-	
-	query := `
+// 批量获取问题，已删除或不存在的问题不会出现在结果中
+func GetQuestionsByIDs(ids []int) (map[int]*Question, error) {
+	questions := make(map[int]*Question)
+	if len(ids) == 0 {
+		return questions, nil
+	}
+	in, args := inClause(ids)
+	rows, err := DB.Query(`
 		SELECT q.id, q.title, q.content, q.category_id, q.user_id, 
 			   u.username, u.avatar, q.view_count, q.answer_count, q.like_count, 
-			   q.tags, q.reward, q.is_solved, q.summary, q.created_at, q.updated_at
+			   q.tags, q.reward, q.is_solved, q.summary, IFNULL(q.duplicate_of, 0),
+			   q.status, IFNULL(q.close_reason, ''), q.is_locked, q.is_protected, q.is_wiki, q.created_at, q.updated_at
 		FROM questions q
 		JOIN users u ON q.user_id = u.id
-		WHERE q.title LIKE ? OR q.content LIKE ? OR q.tags LIKE ?
-		ORDER BY q.created_at DESC
-		LIMIT ? OFFSET ?
-	`
-	
-	rows, err := DB.Query(query, keyword, keyword, keyword, limit, offset)
+		WHERE q.id IN (`+in+`) AND q.deleted_at IS NULL
+	`, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var questions []*Question
 	for rows.Next() {
 		question := &Question{}
 		err := rows.Scan(
 			&question.ID, &question.Title, &question.Content, &question.CategoryID, &question.UserID,
 			&question.Username, &question.UserAvatar, &question.ViewCount, &question.AnswerCount, &question.LikeCount,
-			&question.Tags, &question.Reward, &question.IsSolved, &question.Summary, &question.CreatedAt, &question.UpdatedAt,
+			&question.Tags, &question.Reward, &question.IsSolved, &question.Summary, &question.DuplicateOf,
+			&question.Status, &question.CloseReason, &question.IsLocked, &question.IsProtected, &question.IsWiki, &question.CreatedAt, &question.UpdatedAt,
 		)
 		if err != nil {
 			return nil, err
 		}
-		
-		if question.IsSolved {
-			question.AcceptedAnswer, _ = getAcceptedAnswer(question.ID)
-		}
-		
-		questions = append(questions, question)
+		questions[question.ID] = question
 	}
-
-	return questions, nil
+	return questions, rows.Err()
 }
 
-// 按标签获取问题
-func GetQuestionsByTag(tag string, page, limit int) ([]*Question, error) {
-	offset := (page - 1) * limit
-	tag = "%" + tag + "%"
-	
-	query := `
-		SELECT q.id, q.title, q.content, q.category_id, q.user_id, 
-			   u.username, u.avatar, q.view_count, q.answer_count, q.like_count, 
-			   q.tags, q.reward, q.is_solved, q.summary, q.created_at, q.updated_at
+// 搜索问题（viewerID不为0时排除其静音和屏蔽的作者）
+func SearchQuestions(keyword string, viewerID int, page PageQuery) ([]*Question, PageInfo, error) {
+	keyword = "%" + keyword + "%"
+
+	from := `
 		FROM questions q
 		JOIN users u ON q.user_id = u.id
-		WHERE q.tags LIKE ?
-		ORDER BY q.created_at DESC
-		LIMIT ? OFFSET ?
-	`
-	
-	rows, err := DB.Query(query, tag, limit, offset)
-	if err != nil {
-		return nil, err
+		WHERE (q.title LIKE ? OR q.content LIKE ? OR q.tags LIKE ?) AND q.deleted_at IS NULL`
+	args := []interface{}{keyword, keyword, keyword}
+	if viewerID > 0 {
+		from += hiddenAuthorFilter("q.user_id")
+		args = append(args, viewerID, viewerID)
 	}
-	defer rows.Close()
 
-	var questions []*Question
-	for rows.Next() {
-		question := &Question{}
-		err := rows.Scan(
-			&question.ID, &question.Title, &question.Content, &question.CategoryID, &question.UserID,
-			&question.Username, &question.UserAvatar, &question.ViewCount, &question.AnswerCount, &question.LikeCount,
-			&question.Tags, &question.Reward, &question.IsSolved, &question.Summary, &question.CreatedAt, &question.UpdatedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
-		
-		if question.IsSolved {
-			question.AcceptedAnswer, _ = getAcceptedAnswer(question.ID)
-		}
-		
-		questions = append(questions, question)
+	return queryQuestionPage(from, args, questionKeysets["latest"], page)
+}
+
+// 按标签获取问题
+func GetQuestionsByTag(tag string, page PageQuery) ([]*Question, PageInfo, error) {
+	// 精确匹配标签（同义词解析为主标签）
+	tagID, _, err := lookupTag(DB, NormalizeTagName(tag))
+	if err == sql.ErrNoRows {
+		return nil, PageInfo{}, nil
+	}
+	if err != nil {
+		return nil, PageInfo{}, err
 	}
 
-	return questions, nil
+	from := `
+		FROM questions q
+		JOIN users u ON q.user_id = u.id
+		JOIN content_tags ct ON ct.content_type = ? AND ct.content_id = q.id
+		WHERE ct.tag_id = ? AND q.deleted_at IS NULL`
+
+	return queryQuestionPage(from, []interface{}{ContentTypeQuestion, tagID}, questionKeysets["latest"], page)
 }
 
-// 高级搜索
-func AdvancedSearchQuestions(conditions map[string]interface{}, page, limit int) ([]*Question, error) {
-	offset := (page - 1) * limit
-	
+// 高级搜索（viewerID不为0时排除其静音和屏蔽的作者）
+func AdvancedSearchQuestions(conditions map[string]interface{}, viewerID int, page PageQuery) ([]*Question, PageInfo, error) {
 	query := `
-		SELECT q.id, q.title, q.content, q.category_id, q.user_id, 
-			   u.username, u.avatar, q.view_count, q.answer_count, q.like_count, 
-			   q.tags, q.reward, q.is_solved, q.summary, q.created_at, q.updated_at
 		FROM questions q
 		JOIN users u ON q.user_id = u.id
 	`
 	
-	var whereConditions []string
+	whereConditions := []string{"q.deleted_at IS NULL"}
 	var args []interface{}
 	
 	if title, ok := conditions["title"].(string); ok && title != "" {
@@ -326,39 +369,13 @@ func AdvancedSearchQuestions(conditions map[string]interface{}, page, limit int)
 		}
 	}
 	
-	if len(whereConditions) > 0 {
-		query += " WHERE " + strings.Join(whereConditions, " AND ")
+	query += " WHERE " + strings.Join(whereConditions, " AND ")
+	if viewerID > 0 {
+		query += hiddenAuthorFilter("q.user_id")
+		args = append(args, viewerID, viewerID)
 	}
 	
-	query += " ORDER BY q.created_at DESC LIMIT ? OFFSET ?"
-	args = append(args, limit, offset)
-	
-	rows, err := DB.Query(query, args...)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var questions []*Question
-	for rows.Next() {
-		question := &Question{}
-		err := rows.Scan(
-			&question.ID, &question.Title, &question.Content, &question.CategoryID, &question.UserID,
-			&question.Username, &question.UserAvatar, &question.ViewCount, &question.AnswerCount, &question.LikeCount,
-			&question.Tags, &question.Reward, &question.IsSolved, &question.Summary, &question.CreatedAt, &question.UpdatedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
-		
-		if question.IsSolved {
-			question.AcceptedAnswer, _ = getAcceptedAnswer(question.ID)
-		}
-		
-		questions = append(questions, question)
-	}
-
-	return questions, nil
+	return queryQuestionPage(query, args, questionKeysets["latest"], page)
 }
 
 // 获取待解决问题
@@ -367,7 +384,7 @@ func GetPendingQuestions(limit int) ([]*Question, error) {
 		SELECT q.id, q.title, q.user_id, u.username, q.reward, q.created_at
 		FROM questions q
 		JOIN users u ON q.user_id = u.id
-		WHERE q.is_solved = 0
+		WHERE q.is_solved = 0 AND q.deleted_at IS NULL
 		ORDER BY q.created_at DESC
 		LIMIT ?
 	`
@@ -397,7 +414,7 @@ func GetHighRewardQuestions(limit int) ([]*Question, error) {
 		SELECT q.id, q.title, q.user_id, u.username, q.reward
 		FROM questions q
 		JOIN users u ON q.user_id = u.id
-		WHERE q.reward > 0
+		WHERE q.reward > 0 AND q.deleted_at IS NULL
 		ORDER BY q.reward DESC
 		LIMIT ?
 	`
@@ -427,7 +444,7 @@ func GetRelatedQuestions(questionID, limit int) ([]*Question, error) {
 		SELECT q.id, q.title, q.user_id, u.username, q.view_count, q.answer_count
 		FROM questions q
 		JOIN users u ON q.user_id = u.id
-		WHERE q.id != ? AND q.category_id = (SELECT category_id FROM questions WHERE id = ?)
+		WHERE q.id != ? AND q.category_id = (SELECT category_id FROM questions WHERE id = ?) AND q.deleted_at IS NULL
 		ORDER BY q.view_count DESC
 		LIMIT ?
 	`
@@ -463,9 +480,9 @@ func GetQuestionCount(categoryID int) (int, error) {
 	var err error
 	
 	if categoryID > 0 {
-		err = DB.QueryRow("SELECT COUNT(*) FROM questions WHERE category_id = ?", categoryID).Scan(&count)
+		err = DB.QueryRow("SELECT COUNT(*) FROM questions WHERE category_id = ? AND deleted_at IS NULL", categoryID).Scan(&count)
 	} else {
-		err = DB.QueryRow("SELECT COUNT(*) FROM questions").Scan(&count)
+		err = DB.QueryRow("SELECT COUNT(*) FROM questions WHERE deleted_at IS NULL").Scan(&count)
 	}
 	
 	return count, err
@@ -476,9 +493,9 @@ func GetSolvedQuestionCount(categoryID int) (int, error) {
 	var err error
 	
 	if categoryID > 0 {
-		err = DB.QueryRow("SELECT COUNT(*) FROM questions WHERE category_id = ? AND is_solved = 1", categoryID).Scan(&count)
+		err = DB.QueryRow("SELECT COUNT(*) FROM questions WHERE category_id = ? AND is_solved = 1 AND deleted_at IS NULL", categoryID).Scan(&count)
 	} else {
-		err = DB.QueryRow("SELECT COUNT(*) FROM questions WHERE is_solved = 1").Scan(&count)
+		err = DB.QueryRow("SELECT COUNT(*) FROM questions WHERE is_solved = 1 AND deleted_at IS NULL").Scan(&count)
 	}
 	
 	return count, err
@@ -489,9 +506,9 @@ func GetUnsolvedQuestionCount(categoryID int) (int, error) {
 	var err error
 	
 	if categoryID > 0 {
-		err = DB.QueryRow("SELECT COUNT(*) FROM questions WHERE category_id = ? AND is_solved = 0", categoryID).Scan(&count)
+		err = DB.QueryRow("SELECT COUNT(*) FROM questions WHERE category_id = ? AND is_solved = 0 AND deleted_at IS NULL", categoryID).Scan(&count)
 	} else {
-		err = DB.QueryRow("SELECT COUNT(*) FROM questions WHERE is_solved = 0").Scan(&count)
+		err = DB.QueryRow("SELECT COUNT(*) FROM questions WHERE is_solved = 0 AND deleted_at IS NULL").Scan(&count)
 	}
 	
 	return count, err
@@ -502,9 +519,9 @@ func GetTodayQuestionCount(categoryID int) (int, error) {
 	var err error
 	
 	if categoryID > 0 {
-		err = DB.QueryRow("SELECT COUNT(*) FROM questions WHERE category_id = ? AND DATE(created_at) = CURDATE()", categoryID).Scan(&count)
+		err = DB.QueryRow("SELECT COUNT(*) FROM questions WHERE category_id = ? AND DATE(created_at) = CURDATE() AND deleted_at IS NULL", categoryID).Scan(&count)
 	} else {
-		err = DB.QueryRow("SELECT COUNT(*) FROM questions WHERE DATE(created_at) = CURDATE()").Scan(&count)
+		err = DB.QueryRow("SELECT COUNT(*) FROM questions WHERE DATE(created_at) = CURDATE() AND deleted_at IS NULL").Scan(&count)
 	}
 	
 	return count, err
@@ -523,7 +540,7 @@ func getAcceptedAnswer(questionID int) (string, error) {
 	var content string
 	err := DB.QueryRow(`
 		SELECT content FROM answers 
-		WHERE question_id = ? AND is_accepted = 1 
+		WHERE question_id = ? AND is_accepted = 1 AND deleted_at IS NULL
 		LIMIT 1
 	`, questionID).Scan(&content)
 	
@@ -538,26 +555,97 @@ func getAcceptedAnswer(questionID int) (string, error) {
 	return content, err
 }
 
-// 收藏/取消收藏问题
-func ToggleQuestionFavorite(questionID, userID int) (bool, error) {
-	// 检查是否已收藏
-	var exists int
-	err := DB.QueryRow("SELECT 1 FROM question_favorites WHERE question_id = ? AND user_id = ?", questionID, userID).Scan(&exists)
-	if err == nil {
-		// 已收藏，取消收藏
-		_, err = DB.Exec("DELETE FROM question_favorites WHERE question_id = ? AND user_id = ?", questionID, userID)
-		return false, err
-	} else if err == sql.ErrNoRows {
-		// 未收藏，添加收藏
-		_, err = DB.Exec("INSERT INTO question_favorites (question_id, user_id) VALUES (?, ?)", questionID, userID)
-		return true, err
-	}
-	
-	return false, err
-}
-
 // 举报问题
 func ReportQuestion(questionID, userID int, reason string) error {
 	_, err := DB.Exec("INSERT INTO question_reports (question_id, user_id, reason) VALUES (?, ?, ?)", questionID, userID, reason)
 	return err
-} 
\ No newline at end of file
+} 
+// 将问题作为重复问题关闭
+func CloseQuestionAsDuplicate(questionID, duplicateOf, moderatorID int) error {
+	// 如果目标问题本身也是重复问题，则直接指向其原问题
+	var targetDuplicateOf int
+	err := DB.QueryRow("SELECT IFNULL(duplicate_of, 0) FROM questions WHERE id = ? AND deleted_at IS NULL", duplicateOf).Scan(&targetDuplicateOf)
+	if err != nil {
+		return err
+	}
+	if targetDuplicateOf > 0 {
+		duplicateOf = targetDuplicateOf
+	}
+
+	if duplicateOf == questionID {
+		return ErrInvalidDuplicate
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// 开放的问题直接关闭；已作为重复关闭的问题允许更换原问题
+	state, err := getQuestionState(tx, questionID, true)
+	if err != nil {
+		return err
+	}
+	next := state
+	if state.Status == QuestionStatusOpen {
+		next, err = state.Apply(QuestionActionClose, CloseReasonDuplicate)
+		if err != nil {
+			return err
+		}
+	} else if state.CloseReason != CloseReasonDuplicate {
+		return ErrInvalidTransition
+	}
+	next.DuplicateOf = duplicateOf
+
+	if err := saveQuestionState(tx, questionID, moderatorID, state, next); err != nil {
+		return err
+	}
+	if err := logQuestionState(tx, questionID, moderatorID, QuestionActionClose, CloseReasonDuplicate); err != nil {
+		return err
+	}
+
+	// 原先指向该问题的重复问题一并转到新的原问题下
+	_, err = tx.Exec("UPDATE questions SET duplicate_of = ? WHERE duplicate_of = ?", duplicateOf, questionID)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	updateSimilarityIndex(questionID)
+	notifyQuestionStateChange(questionID, moderatorID, QuestionActionClose, CloseReasonDuplicate)
+	return nil
+}
+
+// 获取被标记为某问题重复的问题列表
+func GetDuplicateQuestions(questionID int) ([]*Question, error) {
+	query := `
+		SELECT q.id, q.title, q.user_id, u.username, q.answer_count, q.created_at
+		FROM questions q
+		JOIN users u ON q.user_id = u.id
+		WHERE q.duplicate_of = ? AND q.deleted_at IS NULL
+		ORDER BY q.created_at ASC
+	`
+
+	rows, err := DB.Query(query, questionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var questions []*Question
+	for rows.Next() {
+		question := &Question{}
+		err := rows.Scan(&question.ID, &question.Title, &question.UserID, &question.Username, &question.AnswerCount, &question.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		question.DuplicateOf = questionID
+		questions = append(questions, question)
+	}
+
+	return questions, nil
+}
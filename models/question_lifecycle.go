@@ -0,0 +1,320 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// 问题状态
+const (
+	QuestionStatusOpen   = "open"
+	QuestionStatusClosed = "closed"
+)
+
+// 问题关闭原因
+const (
+	CloseReasonDuplicate = "duplicate"
+	CloseReasonOffTopic  = "off-topic"
+	CloseReasonUnclear   = "unclear"
+)
+
+// 关闭原因说明
+var CloseReasonNames = map[string]string{
+	CloseReasonDuplicate: "重复问题",
+	CloseReasonOffTopic:  "偏离主题",
+	CloseReasonUnclear:   "描述不清",
+}
+
+// 问题状态操作
+const (
+	QuestionActionClose     = "close"
+	QuestionActionReopen    = "reopen"
+	QuestionActionLock      = "lock"
+	QuestionActionUnlock    = "unlock"
+	QuestionActionProtect   = "protect"
+	QuestionActionUnprotect = "unprotect"
+	QuestionActionWiki      = "wiki"
+)
+
+// 重新开放所需的投票数
+const ReopenVotesRequired = 3
+
+// 参与重新开放投票的最低用户等级
+const ReopenVoteMinLevel = 3
+
+// 受保护问题要求的最短注册时间和最低积分
+const (
+	protectedMinAccountAge = 7 * 24 * time.Hour
+	protectedMinPoints     = 10
+)
+
+// 问题状态相关错误
+var (
+	ErrInvalidTransition  = errors.New("当前状态下不能执行该操作")
+	ErrInvalidCloseReason = errors.New("无效的关闭原因")
+	ErrQuestionClosed     = errors.New("问题已关闭，无法回答")
+	ErrQuestionLocked     = errors.New("问题已锁定，无法回答")
+	ErrQuestionProtected  = errors.New("问题受保护，新注册用户暂时无法回答")
+	ErrReopenVoteLevel    = errors.New("等级不足，无法投票重新开放")
+	ErrAlreadyVoted       = errors.New("您已经投过票了")
+)
+
+// 问题状态
+type QuestionState struct {
+	Status      string `json:"status"`
+	CloseReason string `json:"close_reason"`
+	DuplicateOf int    `json:"duplicate_of"`
+	IsLocked    bool   `json:"is_locked"`
+	IsProtected bool   `json:"is_protected"`
+	IsWiki      bool   `json:"is_wiki"`
+}
+
+// 执行状态转换，返回新状态
+func (s QuestionState) Apply(action, reason string) (QuestionState, error) {
+	next := s
+	switch action {
+	case QuestionActionClose:
+		if s.Status != QuestionStatusOpen {
+			return s, ErrInvalidTransition
+		}
+		if _, ok := CloseReasonNames[reason]; !ok {
+			return s, ErrInvalidCloseReason
+		}
+		next.Status = QuestionStatusClosed
+		next.CloseReason = reason
+	case QuestionActionReopen:
+		if s.Status != QuestionStatusClosed {
+			return s, ErrInvalidTransition
+		}
+		next.Status = QuestionStatusOpen
+		next.CloseReason = ""
+		next.DuplicateOf = 0
+	case QuestionActionLock:
+		if s.IsLocked {
+			return s, ErrInvalidTransition
+		}
+		next.IsLocked = true
+	case QuestionActionUnlock:
+		if !s.IsLocked {
+			return s, ErrInvalidTransition
+		}
+		next.IsLocked = false
+	case QuestionActionProtect:
+		if s.IsProtected {
+			return s, ErrInvalidTransition
+		}
+		next.IsProtected = true
+	case QuestionActionUnprotect:
+		if !s.IsProtected {
+			return s, ErrInvalidTransition
+		}
+		next.IsProtected = false
+	case QuestionActionWiki:
+		if s.IsWiki {
+			return s, ErrInvalidTransition
+		}
+		next.IsWiki = true
+	default:
+		return s, ErrInvalidTransition
+	}
+	return next, nil
+}
+
+// 检查问题当前是否允许该用户回答
+func (s QuestionState) CanAnswer(user *User) error {
+	if s.Status == QuestionStatusClosed {
+		return ErrQuestionClosed
+	}
+	if s.IsLocked {
+		return ErrQuestionLocked
+	}
+	if s.IsProtected && !user.IsModerator() &&
+		(time.Since(user.CreatedAt) < protectedMinAccountAge || user.Points < protectedMinPoints) {
+		return ErrQuestionProtected
+	}
+	return nil
+}
+
+// 查询执行器（DB或事务）
+type queryRower interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// 获取问题状态
+func GetQuestionState(questionID int) (QuestionState, error) {
+	return getQuestionState(DB, questionID, false)
+}
+
+func getQuestionState(q queryRower, questionID int, forUpdate bool) (QuestionState, error) {
+	var state QuestionState
+	query := `
+		SELECT status, IFNULL(close_reason, ''), IFNULL(duplicate_of, 0), is_locked, is_protected, is_wiki
+		FROM questions WHERE id = ? AND deleted_at IS NULL
+	`
+	if forUpdate {
+		query += " FOR UPDATE"
+	}
+	err := q.QueryRow(query, questionID).Scan(
+		&state.Status, &state.CloseReason, &state.DuplicateOf, &state.IsLocked, &state.IsProtected, &state.IsWiki,
+	)
+	return state, err
+}
+
+// 变更问题状态并记录日志
+func ChangeQuestionState(questionID, userID int, action, reason string) (QuestionState, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return QuestionState{}, err
+	}
+	defer tx.Rollback()
+
+	state, err := getQuestionState(tx, questionID, true)
+	if err != nil {
+		return state, err
+	}
+
+	next, err := state.Apply(action, reason)
+	if err != nil {
+		return state, err
+	}
+
+	if err := saveQuestionState(tx, questionID, userID, state, next); err != nil {
+		return state, err
+	}
+
+	// 转为社区维基后回答不再产生声望。还没有回答时退还悬赏积分；
+	// 已有回答时悬赏保留，采纳后照常发给回答者，避免提问者收回回答者已经争取的悬赏
+	if action == QuestionActionWiki {
+		_, err = tx.Exec(`
+			UPDATE users u JOIN questions q ON q.user_id = u.id
+			SET u.points = u.points + q.reward
+			WHERE q.id = ? AND q.is_solved = 0 AND q.answer_count = 0
+		`, questionID)
+		if err != nil {
+			return state, err
+		}
+		_, err = tx.Exec("UPDATE questions SET reward = 0 WHERE id = ? AND is_solved = 0 AND answer_count = 0", questionID)
+		if err != nil {
+			return state, err
+		}
+	}
+
+	if err := logQuestionState(tx, questionID, userID, action, reason); err != nil {
+		return state, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return state, err
+	}
+
+	if action == QuestionActionClose || action == QuestionActionReopen {
+		updateSimilarityIndex(questionID)
+	}
+	notifyQuestionStateChange(questionID, userID, action, reason)
+	return next, nil
+}
+
+// 保存问题状态
+func saveQuestionState(tx *sql.Tx, questionID, userID int, prev, next QuestionState) error {
+	_, err := tx.Exec("UPDATE questions SET is_locked = ?, is_protected = ?, is_wiki = ? WHERE id = ?",
+		next.IsLocked, next.IsProtected, next.IsWiki, questionID)
+	if err != nil {
+		return err
+	}
+
+	if prev.Status == next.Status && prev.CloseReason == next.CloseReason && prev.DuplicateOf == next.DuplicateOf {
+		return nil
+	}
+
+	var closeReason, duplicateOf, closedBy, closedAt interface{}
+	if next.Status == QuestionStatusClosed {
+		closeReason = next.CloseReason
+		closedBy = userID
+		closedAt = time.Now()
+		if next.DuplicateOf > 0 {
+			duplicateOf = next.DuplicateOf
+		}
+	}
+
+	_, err = tx.Exec(`
+		UPDATE questions SET status = ?, close_reason = ?, duplicate_of = ?, closed_by = ?, closed_at = ?
+		WHERE id = ?
+	`, next.Status, closeReason, duplicateOf, closedBy, closedAt, questionID)
+	if err != nil {
+		return err
+	}
+
+	// 开关状态变化后清空重新开放投票
+	_, err = tx.Exec("DELETE FROM question_reopen_votes WHERE question_id = ?", questionID)
+	return err
+}
+
+// 记录问题状态变更
+func logQuestionState(tx *sql.Tx, questionID, userID int, action, reason string) error {
+	_, err := tx.Exec("INSERT INTO question_state_logs (question_id, user_id, action, reason) VALUES (?, ?, ?, ?)",
+		questionID, userID, action, reason)
+	return err
+}
+
+// 投票重新开放问题，票数足够时自动重新开放（返回当前票数和是否已重新开放）
+func VoteReopenQuestion(questionID int, user *User) (int, bool, error) {
+	if GetUserLevel(user.Points) < ReopenVoteMinLevel && !user.IsModerator() {
+		return 0, false, ErrReopenVoteLevel
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return 0, false, err
+	}
+	defer tx.Rollback()
+
+	state, err := getQuestionState(tx, questionID, true)
+	if err != nil {
+		return 0, false, err
+	}
+	if state.Status != QuestionStatusClosed {
+		return 0, false, ErrInvalidTransition
+	}
+
+	var exists int
+	err = tx.QueryRow("SELECT 1 FROM question_reopen_votes WHERE question_id = ? AND user_id = ?", questionID, user.ID).Scan(&exists)
+	if err == nil {
+		return 0, false, ErrAlreadyVoted
+	} else if err != sql.ErrNoRows {
+		return 0, false, err
+	}
+
+	_, err = tx.Exec("INSERT INTO question_reopen_votes (question_id, user_id) VALUES (?, ?)", questionID, user.ID)
+	if err != nil {
+		return 0, false, err
+	}
+
+	var votes int
+	err = tx.QueryRow("SELECT COUNT(*) FROM question_reopen_votes WHERE question_id = ?", questionID).Scan(&votes)
+	if err != nil {
+		return 0, false, err
+	}
+
+	reopened := false
+	if votes >= ReopenVotesRequired {
+		next, _ := state.Apply(QuestionActionReopen, "")
+		if err := saveQuestionState(tx, questionID, user.ID, state, next); err != nil {
+			return 0, false, err
+		}
+		if err := logQuestionState(tx, questionID, user.ID, QuestionActionReopen, "community vote"); err != nil {
+			return 0, false, err
+		}
+		reopened = true
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, false, err
+	}
+
+	if reopened {
+		updateSimilarityIndex(questionID)
+		notifyQuestionStateChange(questionID, user.ID, QuestionActionReopen, "")
+	}
+	return votes, reopened, nil
+}
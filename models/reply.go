@@ -1,7 +1,13 @@
 package models
 
+import "strconv"
+
 // 创建回复
 func CreateReply(postID, userID int, content string) (int, error) {
+	if err := checkContentOwnerBlock("posts", postID, userID); err != nil {
+		return 0, err
+	}
+
 	result, err := DB.Exec("INSERT INTO replies (post_id, user_id, content) VALUES (?, ?, ?)",
 		postID, userID, content)
 	if err != nil {
@@ -25,6 +31,30 @@ func CreateReply(postID, userID int, content string) (int, error) {
 		return 0, err
 	}
 
+	// 通知帖子作者和被@的用户
+	if authorID, title, err := contentOwner("posts", postID); err == nil {
+		link := "/post/" + strconv.Itoa(postID)
+		Notify(Notification{
+			UserID:     authorID,
+			ActorID:    userID,
+			Type:       NotifyReply,
+			TargetType: "post",
+			TargetID:   postID,
+			Subject:    title,
+			Content:    notificationExcerpt(content),
+			Link:       link,
+		})
+		RecordMentions(MentionSource{
+			ContentType: MentionInReply,
+			ContentID:   int(replyID),
+			ActorID:     userID,
+			TargetType:  "reply",
+			TargetID:    int(replyID),
+			Subject:     title,
+			Link:        link,
+		}, content, authorID)
+	}
+
 	return int(replyID), nil
 }
 
@@ -0,0 +1,282 @@
+package models
+
+import (
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// 搜索记录模型
+type SearchQuery struct {
+	ID        int       `json:"id"`
+	Query     string    `json:"query"`
+	Source    string    `json:"source"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// 热门搜索模型
+type TrendingQuery struct {
+	Query         string  `json:"query"`
+	Count         int     `json:"count"`
+	PreviousCount int     `json:"previous_count"`
+	Score         float64 `json:"score"`
+}
+
+// 搜索建议模型
+type SearchSuggestion struct {
+	Type string `json:"type"` // query, question, article, resource, tag, user
+	ID   int    `json:"id"`
+	Text string `json:"text"`
+	URL  string `json:"url"`
+}
+
+// 搜索词最大长度
+const maxSearchQueryLength = 100
+
+// 搜索词至少被搜索这么多次才会出现在搜索建议和热门搜索中，避免公开个别用户的原始搜索内容
+const minPublicSearchCount = 3
+
+// 热门搜索的时间窗口
+var trendingWindows = map[string]time.Duration{
+	"hour": time.Hour,
+	"day":  24 * time.Hour,
+	"week": 7 * 24 * time.Hour,
+}
+
+// 记录搜索词（userID为0时记录为匿名搜索）
+func RecordSearchQuery(userID int, query, source string) error {
+	query = strings.Join(strings.Fields(query), " ")
+	if query == "" {
+		return nil
+	}
+	if utf8.RuneCountInString(query) > maxSearchQueryLength {
+		query = string([]rune(query)[:maxSearchQueryLength])
+	}
+
+	var uid interface{}
+	if userID > 0 {
+		uid = userID
+	}
+
+	_, err := DB.Exec("INSERT INTO search_queries (user_id, query, normalized, source) VALUES (?, ?, ?, ?)",
+		uid, query, strings.ToLower(query), source)
+	return err
+}
+
+// 获取用户搜索历史（相同搜索词只保留最近一次）
+func GetUserSearchHistory(userID, limit int) ([]SearchQuery, error) {
+	query := `
+		SELECT s.id, s.query, s.source, s.created_at
+		FROM search_queries s
+		JOIN (
+			SELECT MAX(id) AS id
+			FROM search_queries
+			WHERE user_id = ?
+			GROUP BY normalized
+		) latest ON s.id = latest.id
+		ORDER BY s.created_at DESC
+		LIMIT ?
+	`
+
+	rows, err := DB.Query(query, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []SearchQuery
+	for rows.Next() {
+		var item SearchQuery
+		err := rows.Scan(&item.ID, &item.Query, &item.Source, &item.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, item)
+	}
+
+	return history, nil
+}
+
+// 删除单条搜索历史（同一搜索词的所有记录一并移除）
+func DeleteSearchHistoryItem(userID, historyID int) error {
+	var normalized string
+	err := DB.QueryRow("SELECT normalized FROM search_queries WHERE id = ? AND user_id = ?", historyID, userID).Scan(&normalized)
+	if err != nil {
+		return err
+	}
+
+	// 只解除与用户的关联，保留匿名记录用于热门搜索统计
+	_, err = DB.Exec("UPDATE search_queries SET user_id = NULL WHERE user_id = ? AND normalized = ?", userID, normalized)
+	return err
+}
+
+// 清空用户搜索历史
+func ClearUserSearchHistory(userID int) error {
+	_, err := DB.Exec("UPDATE search_queries SET user_id = NULL WHERE user_id = ?", userID)
+	return err
+}
+
+// 判断热门搜索时间窗口是否有效
+func IsValidTrendingWindow(window string) bool {
+	_, ok := trendingWindows[window]
+	return ok
+}
+
+// 获取热门搜索（比较当前窗口与上一个窗口的搜索次数，上升越快得分越高）
+func GetTrendingQueries(window string, limit int) ([]TrendingQuery, error) {
+	duration, ok := trendingWindows[window]
+	if !ok {
+		duration = trendingWindows["day"]
+	}
+
+	now := time.Now()
+	windowStart := now.Add(-duration)
+	previousStart := windowStart.Add(-duration)
+
+	query := `
+		SELECT normalized,
+			   SUM(CASE WHEN created_at >= ? THEN 1 ELSE 0 END) AS current_count,
+			   SUM(CASE WHEN created_at < ? THEN 1 ELSE 0 END) AS previous_count
+		FROM search_queries
+		WHERE created_at >= ?
+		GROUP BY normalized
+		HAVING current_count >= ?
+	`
+
+	rows, err := DB.Query(query, windowStart, windowStart, previousStart, minPublicSearchCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var trending []TrendingQuery
+	for rows.Next() {
+		var item TrendingQuery
+		err := rows.Scan(&item.Query, &item.Count, &item.PreviousCount)
+		if err != nil {
+			return nil, err
+		}
+
+		item.Score = float64(item.Count)
+		if item.Count > item.PreviousCount {
+			item.Score += float64(item.Count-item.PreviousCount) / 2
+		}
+		trending = append(trending, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(trending, func(i, j int) bool {
+		if trending[i].Score != trending[j].Score {
+			return trending[i].Score > trending[j].Score
+		}
+		return trending[i].Count > trending[j].Count
+	})
+
+	if len(trending) > limit {
+		trending = trending[:limit]
+	}
+
+	return trending, nil
+}
+
+// 搜索建议来源
+var suggestionSources = []struct {
+	Type  string
+	Query string
+	URL   func(id int, text string) string
+}{
+	{
+		Type:  "question",
+		Query: "SELECT id, title FROM questions WHERE title LIKE ? AND deleted_at IS NULL ORDER BY view_count DESC LIMIT ?",
+		URL:   func(id int, text string) string { return "/qa/" + strconv.Itoa(id) },
+	},
+	{
+		Type:  "article",
+		Query: "SELECT id, title FROM tech_articles WHERE title LIKE ? AND deleted_at IS NULL ORDER BY view_count DESC LIMIT ?",
+		URL:   func(id int, text string) string { return "/tech-share/" + strconv.Itoa(id) },
+	},
+	{
+		Type:  "resource",
+		Query: "SELECT id, title FROM learning_resources WHERE title LIKE ? AND deleted_at IS NULL ORDER BY download_count DESC LIMIT ?",
+		URL:   func(id int, text string) string { return "/learning-resources/" + strconv.Itoa(id) },
+	},
+	{
+		Type:  "tag",
+		Query: "SELECT id, name FROM tags WHERE name LIKE ? ORDER BY name ASC LIMIT ?",
+		URL:   func(id int, text string) string { return "/qa?tag=" + url.QueryEscape(text) },
+	},
+	{
+		Type:  "user",
+		Query: "SELECT id, username FROM users WHERE username LIKE ? ORDER BY points DESC LIMIT ?",
+		URL:   func(id int, text string) string { return "/u/" + url.PathEscape(text) },
+	},
+}
+
+// 获取搜索建议（按前缀匹配历史搜索词、标题、标签和用户名）
+func GetSearchSuggestions(prefix string, limit int) ([]SearchSuggestion, error) {
+	prefix = strings.TrimSpace(prefix)
+	if prefix == "" {
+		return nil, nil
+	}
+	pattern := escapeLike(prefix) + "%"
+
+	var suggestions []SearchSuggestion
+
+	// 优先推荐多次被搜索过的词，最多占一半名额
+	rows, err := DB.Query(`
+		SELECT normalized, COUNT(*) AS cnt
+		FROM search_queries
+		WHERE normalized LIKE ?
+		GROUP BY normalized
+		HAVING cnt >= ?
+		ORDER BY cnt DESC
+		LIMIT ?
+	`, strings.ToLower(pattern), minPublicSearchCount, (limit+1)/2)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var text string
+		var count int
+		if err := rows.Scan(&text, &count); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		suggestions = append(suggestions, SearchSuggestion{Type: "query", Text: text, URL: "/search?q=" + url.QueryEscape(text)})
+	}
+	rows.Close()
+
+	for _, source := range suggestionSources {
+		if len(suggestions) >= limit {
+			break
+		}
+
+		rows, err := DB.Query(source.Query, pattern, limit-len(suggestions))
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			item := SearchSuggestion{Type: source.Type}
+			if err := rows.Scan(&item.ID, &item.Text); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			item.URL = source.URL(item.ID, item.Text)
+			suggestions = append(suggestions, item)
+		}
+		rows.Close()
+	}
+
+	return suggestions, nil
+}
+
+// 转义LIKE查询中的通配符
+func escapeLike(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return replacer.Replace(s)
+}
@@ -0,0 +1,294 @@
+package models
+
+import (
+	"database/sql"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// 相似度阈值：提问时提示重复，以及相似问题列表中显示的最低相似度
+const (
+	DuplicateWarningScore = 0.6
+	SimilarListMinScore   = 0.15
+)
+
+// 相似问题模型
+type SimilarQuestion struct {
+	ID          int     `json:"id"`
+	Title       string  `json:"title"`
+	AnswerCount int     `json:"answer_count"`
+	IsSolved    bool    `json:"is_solved"`
+	Score       float64 `json:"score"`
+}
+
+// 问题相似度索引（基于本地问题库的TF-IDF向量）
+type similarityIndex struct {
+	docs    map[int]map[string]float64
+	counts  map[int]map[string]int // 各问题的词频，用于增量更新时扣除文档频率
+	df      map[string]int
+	total   int
+	builtAt time.Time
+}
+
+var (
+	questionIndex      *similarityIndex
+	questionIndexMutex sync.Mutex
+	questionIndexDirty bool
+)
+
+// 索引最长缓存时间。增量更新不会重算其他问题向量中的IDF，过期后整体重建校正
+const similarityIndexTTL = time.Hour
+
+// 标题在相似度计算中的权重
+const titleWeight = 3
+
+// 英文停用词
+var similarityStopwords = map[string]bool{
+	"the": true, "a": true, "an": true, "and": true, "or": true, "of": true, "to": true,
+	"in": true, "on": true, "for": true, "is": true, "are": true, "how": true, "what": true,
+	"with": true, "it": true, "be": true, "can": true, "do": true, "does": true, "i": true,
+}
+
+// 标记相似度索引需要重建
+func invalidateSimilarityIndex() {
+	questionIndexMutex.Lock()
+	questionIndexDirty = true
+	questionIndexMutex.Unlock()
+}
+
+// 问题新增、删除、恢复或状态变化后更新索引中的该问题，不重建整个索引
+func updateSimilarityIndex(questionID int) {
+	// 已被标记为重复或已删除的问题从索引中移除
+	var title, content string
+	err := DB.QueryRow("SELECT title, content FROM questions WHERE id = ? AND duplicate_of IS NULL AND deleted_at IS NULL",
+		questionID).Scan(&title, &content)
+	if err != nil && err != sql.ErrNoRows {
+		invalidateSimilarityIndex()
+		return
+	}
+
+	questionIndexMutex.Lock()
+	defer questionIndexMutex.Unlock()
+
+	// 索引尚未建立或等待重建时，下次使用会整体重建
+	if questionIndex == nil || questionIndexDirty {
+		return
+	}
+	questionIndex.remove(questionID)
+	if err == nil {
+		questionIndex.add(questionID, questionTermCounts(title, content))
+	}
+}
+
+func (idx *similarityIndex) add(id int, counts map[string]int) {
+	for term := range counts {
+		idx.df[term]++
+	}
+	idx.total++
+	idx.counts[id] = counts
+	idx.docs[id] = idx.vector(counts)
+}
+
+func (idx *similarityIndex) remove(id int) {
+	counts, ok := idx.counts[id]
+	if !ok {
+		return
+	}
+	for term := range counts {
+		if idx.df[term]--; idx.df[term] <= 0 {
+			delete(idx.df, term)
+		}
+	}
+	idx.total--
+	delete(idx.counts, id)
+	delete(idx.docs, id)
+}
+
+// 获取相似度索引（过期或失效时重建），调用方需持有questionIndexMutex
+func getSimilarityIndex() (*similarityIndex, error) {
+	if questionIndex != nil && !questionIndexDirty && time.Since(questionIndex.builtAt) < similarityIndexTTL {
+		return questionIndex, nil
+	}
+
+	// 已被标记为重复的问题不参与匹配，统一指向原问题
+	rows, err := DB.Query("SELECT id, title, content FROM questions WHERE duplicate_of IS NULL AND deleted_at IS NULL")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	index := &similarityIndex{
+		docs:   make(map[int]map[string]float64),
+		counts: make(map[int]map[string]int),
+		df:     make(map[string]int),
+	}
+	termCounts := index.counts
+	for rows.Next() {
+		var id int
+		var title, content string
+		if err := rows.Scan(&id, &title, &content); err != nil {
+			return nil, err
+		}
+
+		counts := questionTermCounts(title, content)
+		termCounts[id] = counts
+		for term := range counts {
+			index.df[term]++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	index.total = len(termCounts)
+	for id, counts := range termCounts {
+		index.docs[id] = index.vector(counts)
+	}
+	index.builtAt = time.Now()
+
+	questionIndex = index
+	questionIndexDirty = false
+	return index, nil
+}
+
+// 计算归一化的TF-IDF向量
+func (idx *similarityIndex) vector(counts map[string]int) map[string]float64 {
+	n := float64(idx.total)
+	vec := make(map[string]float64, len(counts))
+	var norm float64
+	for term, count := range counts {
+		idf := math.Log((n+1)/float64(idx.df[term]+1)) + 1
+		weight := (1 + math.Log(float64(count))) * idf
+		vec[term] = weight
+		norm += weight * weight
+	}
+
+	if norm > 0 {
+		norm = math.Sqrt(norm)
+		for term := range vec {
+			vec[term] /= norm
+		}
+	}
+	return vec
+}
+
+// 统计问题的词频（标题加权）
+func questionTermCounts(title, content string) map[string]int {
+	counts := make(map[string]int)
+	for _, term := range tokenize(title) {
+		counts[term] += titleWeight
+	}
+	for _, term := range tokenize(content) {
+		counts[term]++
+	}
+	return counts
+}
+
+// 分词：英文和数字按单词切分，中文按相邻二字切分
+func tokenize(text string) []string {
+	var tokens []string
+	var word []rune
+	var han []rune
+
+	flushWord := func() {
+		if len(word) > 1 {
+			w := string(word)
+			if !similarityStopwords[w] {
+				tokens = append(tokens, w)
+			}
+		}
+		word = word[:0]
+	}
+	flushHan := func() {
+		if len(han) == 1 {
+			tokens = append(tokens, string(han))
+		}
+		for i := 0; i+1 < len(han); i++ {
+			tokens = append(tokens, string(han[i:i+2]))
+		}
+		han = han[:0]
+	}
+
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flushWord()
+			han = append(han, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushHan()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushHan()
+		}
+	}
+	flushWord()
+	flushHan()
+
+	return tokens
+}
+
+// 查找相似问题（按余弦相似度排序，excludeID用于排除问题本身）
+func FindSimilarQuestions(title, content string, excludeID, limit int, minScore float64) ([]*SimilarQuestion, error) {
+	type scored struct {
+		id    int
+		score float64
+	}
+	var matches []scored
+
+	// 索引会被增量更新，计算相似度期间持有锁
+	questionIndexMutex.Lock()
+	index, err := getSimilarityIndex()
+	if err != nil {
+		questionIndexMutex.Unlock()
+		return nil, err
+	}
+
+	query := index.vector(questionTermCounts(title, content))
+	if len(query) == 0 {
+		questionIndexMutex.Unlock()
+		return nil, nil
+	}
+	for id, doc := range index.docs {
+		if id == excludeID {
+			continue
+		}
+
+		var score float64
+		for term, weight := range query {
+			score += weight * doc[term]
+		}
+		if score >= minScore {
+			matches = append(matches, scored{id, score})
+		}
+	}
+	questionIndexMutex.Unlock()
+
+	if len(matches) == 0 {
+		return nil, nil
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	var questions []*SimilarQuestion
+	for _, match := range matches {
+		question := &SimilarQuestion{ID: match.id, Score: math.Round(match.score*1000) / 1000}
+		err := DB.QueryRow("SELECT title, answer_count, is_solved FROM questions WHERE id = ? AND deleted_at IS NULL", match.id).
+			Scan(&question.Title, &question.AnswerCount, &question.IsSolved)
+		if err != nil {
+			continue
+		}
+		questions = append(questions, question)
+	}
+
+	return questions, nil
+}
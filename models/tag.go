@@ -1,13 +1,86 @@
 package models
 
-// 获取所有标签
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+	"unicode/utf8"
+)
+
+// 可打标签的内容类型
+const (
+	ContentTypeQuestion = "question"
+	ContentTypePost     = "post"
+	ContentTypeArticle  = "article"
+	ContentTypeResource = "resource"
+)
+
+// 内容类型对应的数据表
+var contentTagTables = map[string]string{
+	ContentTypeQuestion: "questions",
+	ContentTypePost:     "posts",
+	ContentTypeArticle:  "tech_articles",
+	ContentTypeResource: "learning_resources",
+}
+
+// 标签名最大长度
+const maxTagLength = 50
+
+// 单个内容最多的标签数
+const maxTagsPerContent = 10
+
+// 标签相关错误
+var (
+	ErrInvalidTagMerge = errors.New("不能将标签合并到自身")
+	ErrTagNameTaken    = errors.New("该名称已被其他标签使用")
+)
+
+// 标签同义词模型
+type TagSynonym struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// 规范化标签名（去除首尾空白、统一小写）
+func NormalizeTagName(name string) string {
+	name = strings.ToLower(strings.Join(strings.Fields(name), " "))
+	if utf8.RuneCountInString(name) > maxTagLength {
+		name = string([]rune(name)[:maxTagLength])
+	}
+	return name
+}
+
+// 解析逗号分隔的标签字符串（支持中英文逗号，去重）
+func ParseTagNames(tags string) []string {
+	parts := strings.FieldsFunc(tags, func(r rune) bool {
+		return r == ',' || r == '，'
+	})
+
+	seen := make(map[string]bool)
+	var names []string
+	for _, part := range parts {
+		name := NormalizeTagName(part)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+		if len(names) >= maxTagsPerContent {
+			break
+		}
+	}
+	return names
+}
+
+// 获取所有标签（按使用次数排序）
 func GetTags() ([]*Tag, error) {
 	query := `
-		SELECT id, name
+		SELECT id, name, IFNULL(description, ''), usage_count
 		FROM tags
-		ORDER BY name ASC
+		ORDER BY usage_count DESC, name ASC
 	`
-	
+
 	rows, err := DB.Query(query)
 	if err != nil {
 		return nil, err
@@ -17,7 +90,7 @@ func GetTags() ([]*Tag, error) {
 	var tags []*Tag
 	for rows.Next() {
 		tag := &Tag{}
-		err := rows.Scan(&tag.ID, &tag.Name)
+		err := rows.Scan(&tag.ID, &tag.Name, &tag.Description, &tag.UsageCount)
 		if err != nil {
 			return nil, err
 		}
@@ -29,6 +102,392 @@ func GetTags() ([]*Tag, error) {
 
 // 创建标签
 func CreateTag(name string) error {
+	name = NormalizeTagName(name)
+	if name == "" {
+		return nil
+	}
 	_, err := DB.Exec("INSERT IGNORE INTO tags (name) VALUES (?)", name)
 	return err
-} 
\ No newline at end of file
+}
+
+// 根据ID获取标签详情（包括说明和同义词）
+func GetTagByID(id int) (*Tag, error) {
+	tag := &Tag{}
+	err := DB.QueryRow(`
+		SELECT id, name, IFNULL(description, ''), IFNULL(wiki, ''), usage_count
+		FROM tags WHERE id = ?
+	`, id).Scan(&tag.ID, &tag.Name, &tag.Description, &tag.Wiki, &tag.UsageCount)
+	if err != nil {
+		return nil, err
+	}
+
+	tag.Synonyms, err = GetTagSynonyms(tag.ID)
+	if err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+// 根据名称获取标签详情（同义词解析为主标签）
+func GetTagByName(name string) (*Tag, error) {
+	id, _, err := lookupTag(DB, NormalizeTagName(name))
+	if err != nil {
+		return nil, err
+	}
+	return GetTagByID(id)
+}
+
+// 获取标签的同义词
+func GetTagSynonyms(tagID int) ([]TagSynonym, error) {
+	rows, err := DB.Query("SELECT id, name FROM tag_synonyms WHERE tag_id = ? ORDER BY name ASC", tagID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var synonyms []TagSynonym
+	for rows.Next() {
+		var synonym TagSynonym
+		if err := rows.Scan(&synonym.ID, &synonym.Name); err != nil {
+			return nil, err
+		}
+		synonyms = append(synonyms, synonym)
+	}
+	return synonyms, nil
+}
+
+// 按名称查找标签，同义词返回其主标签
+func lookupTag(q queryRower, name string) (int, string, error) {
+	var id int
+	var canonical string
+	err := q.QueryRow("SELECT id, name FROM tags WHERE name = ?", name).Scan(&id, &canonical)
+	if err != sql.ErrNoRows {
+		return id, canonical, err
+	}
+
+	err = q.QueryRow(`
+		SELECT t.id, t.name FROM tag_synonyms s
+		JOIN tags t ON s.tag_id = t.id
+		WHERE s.name = ?
+	`, name).Scan(&id, &canonical)
+	return id, canonical, err
+}
+
+// 查找标签，不存在时创建
+func resolveTag(tx *sql.Tx, name string) (int, string, error) {
+	id, canonical, err := lookupTag(tx, name)
+	if err != sql.ErrNoRows {
+		return id, canonical, err
+	}
+
+	result, err := tx.Exec("INSERT INTO tags (name) VALUES (?)", name)
+	if err != nil {
+		return 0, "", err
+	}
+	newID, err := result.LastInsertId()
+	return int(newID), name, err
+}
+
+// 同步内容的标签关联，并将内容上的标签字符串更新为规范名称
+func SyncContentTags(contentType string, contentID int, tags string) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := syncContentTags(tx, contentType, contentID, tags); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func syncContentTags(tx *sql.Tx, contentType string, contentID int, tags string) error {
+	oldIDs, err := contentTagIDs(tx, contentType, contentID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM content_tags WHERE content_type = ? AND content_id = ?", contentType, contentID)
+	if err != nil {
+		return err
+	}
+
+	var newIDs []int
+	seen := make(map[int]bool)
+	for _, name := range ParseTagNames(tags) {
+		id, _, err := resolveTag(tx, name)
+		if err != nil {
+			return err
+		}
+		// 多个同义词可能指向同一个标签
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		_, err = tx.Exec("INSERT INTO content_tags (tag_id, content_type, content_id) VALUES (?, ?, ?)",
+			id, contentType, contentID)
+		if err != nil {
+			return err
+		}
+		newIDs = append(newIDs, id)
+	}
+
+	if err := updateTagUsage(tx, append(oldIDs, newIDs...)); err != nil {
+		return err
+	}
+	return refreshContentTagString(tx, contentType, contentID)
+}
+
+// 删除内容的全部标签关联
+func RemoveContentTags(contentType string, contentID int) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	ids, err := contentTagIDs(tx, contentType, contentID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM content_tags WHERE content_type = ? AND content_id = ?", contentType, contentID)
+	if err != nil {
+		return err
+	}
+
+	if err := updateTagUsage(tx, ids); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// 获取内容当前关联的标签ID
+func contentTagIDs(tx *sql.Tx, contentType string, contentID int) ([]int, error) {
+	rows, err := tx.Query("SELECT tag_id FROM content_tags WHERE content_type = ? AND content_id = ?", contentType, contentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// 重新统计标签的使用次数
+func updateTagUsage(tx *sql.Tx, tagIDs []int) error {
+	for _, id := range tagIDs {
+		_, err := tx.Exec(`
+			UPDATE tags SET usage_count = (SELECT COUNT(*) FROM content_tags WHERE tag_id = ?)
+			WHERE id = ?
+		`, id, id)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 根据标签关联重写内容上的标签字符串（不改变内容的更新时间）
+func refreshContentTagString(tx *sql.Tx, contentType string, contentID int) error {
+	table, ok := contentTagTables[contentType]
+	if !ok {
+		return nil
+	}
+
+	rows, err := tx.Query(`
+		SELECT t.name FROM content_tags ct
+		JOIN tags t ON ct.tag_id = t.id
+		WHERE ct.content_type = ? AND ct.content_id = ?
+		ORDER BY ct.id ASC
+	`, contentType, contentID)
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		names = append(names, name)
+	}
+	rows.Close()
+
+	_, err = tx.Exec("UPDATE "+table+" SET tags = ?, updated_at = updated_at WHERE id = ?",
+		strings.Join(names, ","), contentID)
+	return err
+}
+
+// 更新标签说明和维基
+func UpdateTagInfo(tagID int, description, wiki string) error {
+	result, err := DB.Exec("UPDATE tags SET description = ?, wiki = ? WHERE id = ?", description, wiki, tagID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		// 内容未变化时影响行数也为0，需要确认标签是否存在
+		var exists int
+		return DB.QueryRow("SELECT 1 FROM tags WHERE id = ?", tagID).Scan(&exists)
+	}
+	return nil
+}
+
+// 为标签添加同义词
+func AddTagSynonym(tagID int, name string) error {
+	name = NormalizeTagName(name)
+	if name == "" {
+		return ErrTagNameTaken
+	}
+
+	var exists int
+	if err := DB.QueryRow("SELECT 1 FROM tags WHERE id = ?", tagID).Scan(&exists); err != nil {
+		return err
+	}
+
+	// 已有同名标签时应使用合并而不是同义词
+	if _, _, err := lookupTag(DB, name); err == nil {
+		return ErrTagNameTaken
+	} else if err != sql.ErrNoRows {
+		return err
+	}
+
+	_, err := DB.Exec("INSERT INTO tag_synonyms (name, tag_id) VALUES (?, ?)", name, tagID)
+	return err
+}
+
+// 删除标签同义词
+func DeleteTagSynonym(tagID, synonymID int) error {
+	result, err := DB.Exec("DELETE FROM tag_synonyms WHERE id = ? AND tag_id = ?", synonymID, tagID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// 将源标签合并到目标标签，源标签名称保留为目标标签的同义词
+func MergeTags(sourceID, targetID int) error {
+	if sourceID == targetID {
+		return ErrInvalidTagMerge
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var sourceName string
+	err = tx.QueryRow("SELECT name FROM tags WHERE id = ? FOR UPDATE", sourceID).Scan(&sourceName)
+	if err != nil {
+		return err
+	}
+	var exists int
+	err = tx.QueryRow("SELECT 1 FROM tags WHERE id = ? FOR UPDATE", targetID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+
+	// 记录受影响的内容，合并后重写其标签字符串
+	type contentRef struct {
+		contentType string
+		contentID   int
+	}
+	rows, err := tx.Query("SELECT content_type, content_id FROM content_tags WHERE tag_id = ?", sourceID)
+	if err != nil {
+		return err
+	}
+	var affected []contentRef
+	for rows.Next() {
+		var ref contentRef
+		if err := rows.Scan(&ref.contentType, &ref.contentID); err != nil {
+			rows.Close()
+			return err
+		}
+		affected = append(affected, ref)
+	}
+	rows.Close()
+
+	_, err = tx.Exec(`
+		INSERT IGNORE INTO content_tags (tag_id, content_type, content_id, created_at)
+		SELECT ?, content_type, content_id, created_at FROM content_tags WHERE tag_id = ?
+	`, targetID, sourceID)
+	if err != nil {
+		return err
+	}
+
+	statements := []struct {
+		query string
+		args  []interface{}
+	}{
+		{"DELETE FROM content_tags WHERE tag_id = ?", []interface{}{sourceID}},
+		{"UPDATE IGNORE interest_follows SET target_id = ? WHERE target_type = 'tag' AND target_id = ?", []interface{}{targetID, sourceID}},
+		{"DELETE FROM interest_follows WHERE target_type = 'tag' AND target_id = ?", []interface{}{sourceID}},
+		{"UPDATE tag_synonyms SET tag_id = ? WHERE tag_id = ?", []interface{}{targetID, sourceID}},
+		{"DELETE FROM tags WHERE id = ?", []interface{}{sourceID}},
+		{"INSERT INTO tag_synonyms (name, tag_id) VALUES (?, ?)", []interface{}{sourceName, targetID}},
+	}
+	for _, stmt := range statements {
+		if _, err := tx.Exec(stmt.query, stmt.args...); err != nil {
+			return err
+		}
+	}
+
+	if err := updateTagUsage(tx, []int{targetID}); err != nil {
+		return err
+	}
+	for _, ref := range affected {
+		if err := refreshContentTagString(tx, ref.contentType, ref.contentID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// 将内容上已有的标签字符串迁移到标签关联表（只处理尚未建立关联的内容）
+func MigrateContentTags() {
+	for contentType, table := range contentTagTables {
+		rows, err := DB.Query(`
+			SELECT id, tags FROM `+table+`
+			WHERE tags IS NOT NULL AND tags <> ''
+			AND id NOT IN (SELECT content_id FROM content_tags WHERE content_type = ?)
+		`, contentType)
+		if err != nil {
+			log.Printf("迁移%s标签失败: %v", table, err)
+			continue
+		}
+
+		pending := make(map[int]string)
+		for rows.Next() {
+			var id int
+			var tags string
+			if err := rows.Scan(&id, &tags); err != nil {
+				log.Printf("迁移%s标签失败: %v", table, err)
+				break
+			}
+			pending[id] = tags
+		}
+		rows.Close()
+
+		for id, tags := range pending {
+			if err := SyncContentTags(contentType, id, tags); err != nil {
+				log.Printf("迁移%s标签失败 (id=%d): %v", table, id, err)
+			}
+		}
+	}
+}
@@ -2,8 +2,11 @@ package models
 
 import (
 	"database/sql"
+	"strconv"
 	"strings"
 	"time"
+
+	"aiforum/utils"
 )
 
 // 技术文章模型
@@ -84,94 +87,138 @@ func CreateTechArticle(title, content, category string, userID int, tags, coverI
 		return 0, err
 	}
 
+	// 建立标签关联
+	err = SyncContentTags(ContentTypeArticle, int(articleID), tags)
+	if err != nil {
+		return 0, err
+	}
+
 	// 给发布用户加积分
 	err = UpdateUserPoints(userID, 10)
 	if err != nil {
 		return 0, err
 	}
 
+	// 通知被@的用户
+	RecordMentions(MentionSource{
+		ContentType: MentionInArticle,
+		ContentID:   int(articleID),
+		ActorID:     userID,
+		TargetType:  "article",
+		TargetID:    int(articleID),
+		Subject:     title,
+		Link:        "/tech-share/" + strconv.Itoa(int(articleID)),
+	}, content)
+
+	DispatchWebhookEvent(WebhookEvent{
+		Name:        WebhookArticlePublished,
+		ActorID:     userID,
+		ContentType: ContentTypeArticle,
+		ContentID:   int(articleID),
+		Data: map[string]interface{}{
+			"id":       int(articleID),
+			"title":    title,
+			"summary":  summary,
+			"category": category,
+			"url":      siteURL("/tech-share/" + strconv.Itoa(int(articleID))),
+		},
+	})
+
 	return int(articleID), nil
 }
 
-// 获取技术文章列表
-func GetTechArticles(page, limit int, category, keyword, sort, topic string) ([]*TechArticle, error) {
-	offset := (page - 1) * limit
-	
-	var query string
+// 文章列表的排序方式
+var articleKeysets = map[string]keyset{
+	"latest":   latestKeyset("latest", "a.created_at", "a.id"),
+	"likes":    {name: "likes", score: "a.like_count", createdAt: "a.created_at", id: "a.id"},
+	"comments": {name: "comments", score: "a.comment_count", createdAt: "a.created_at", id: "a.id"},
+	"views":    {name: "views", score: "a.view_count", createdAt: "a.created_at", id: "a.id"},
+}
+
+// 获取技术文章列表（未知的排序方式按最新排序，搜索时排除viewerID静音和屏蔽的作者）
+func GetTechArticles(category, keyword, sort, topic string, viewerID int, page PageQuery) ([]*TechArticle, PageInfo, error) {
+	k, ok := articleKeysets[sort]
+	if !ok {
+		k = articleKeysets["latest"]
+	}
+
+	whereConditions := []string{"a.deleted_at IS NULL"}
 	var args []interface{}
-	
-	baseQuery := `
-		SELECT a.id, a.title, a.content, a.summary, a.category, a.user_id, 
-			   u.username, u.avatar, a.cover_image, a.tags, a.view_count, 
-			   a.like_count, a.comment_count, a.topic_slug, a.created_at, a.updated_at
-		FROM tech_articles a
-		JOIN users u ON a.user_id = u.id
-	`
-	
-	var whereConditions []string
-	
+
 	if category != "" {
 		whereConditions = append(whereConditions, "a.category = ?")
 		args = append(args, category)
 	}
-	
+
 	if keyword != "" {
 		whereConditions = append(whereConditions, "(a.title LIKE ? OR a.content LIKE ? OR a.tags LIKE ?)")
 		keyword = "%" + keyword + "%"
 		args = append(args, keyword, keyword, keyword)
+		if viewerID > 0 {
+			whereConditions = append(whereConditions, strings.TrimPrefix(hiddenAuthorFilter("a.user_id"), " AND "))
+			args = append(args, viewerID, viewerID)
+		}
 	}
-	
+
 	if topic != "" {
 		whereConditions = append(whereConditions, "a.topic_slug = ?")
 		args = append(args, topic)
 	}
-	
-	if len(whereConditions) > 0 {
-		query = baseQuery + " WHERE " + strings.Join(whereConditions, " AND ")
-	} else {
-		query = baseQuery
-	}
-	
-	// 排序
-	switch sort {
-	case "likes":
-		query += " ORDER BY a.like_count DESC"
-	case "comments":
-		query += " ORDER BY a.comment_count DESC"
-	case "views":
-		query += " ORDER BY a.view_count DESC"
-	default:
-		query += " ORDER BY a.created_at DESC"
+
+	return queryTechArticlePage(whereConditions, args, k, page)
+}
+
+// 获取作者的技术文章（按最新排序）
+func GetAuthorTechArticles(userID int, page PageQuery) ([]*TechArticle, PageInfo, error) {
+	return queryTechArticlePage([]string{"a.deleted_at IS NULL", "a.user_id = ?"}, []interface{}{userID},
+		articleKeysets["latest"], page)
+}
+
+// 查询一页技术文章
+func queryTechArticlePage(whereConditions []string, args []interface{}, k keyset, page PageQuery) ([]*TechArticle, PageInfo, error) {
+	query, args, err := k.apply(`
+		SELECT a.id, a.title, a.content, a.summary, a.category, a.user_id,
+			   u.username, u.avatar, a.cover_image, a.tags, a.view_count,
+			   a.like_count, a.comment_count, a.topic_slug, a.created_at, a.updated_at, `+k.scoreColumn()+`
+		FROM tech_articles a
+		JOIN users u ON a.user_id = u.id
+		WHERE `+strings.Join(whereConditions, " AND "), args, page)
+	if err != nil {
+		return nil, PageInfo{}, err
 	}
-	
-	query += " LIMIT ? OFFSET ?"
-	args = append(args, limit, offset)
-	
+
 	rows, err := DB.Query(query, args...)
 	if err != nil {
-		return nil, err
+		return nil, PageInfo{}, err
 	}
 	defer rows.Close()
 
 	var articles []*TechArticle
+	var keys []utils.Cursor
 	for rows.Next() {
 		article := &TechArticle{}
+		var score float64
 		err := rows.Scan(
 			&article.ID, &article.Title, &article.Content, &article.Summary, &article.Category, &article.UserID,
 			&article.AuthorName, &article.AuthorAvatar, &article.CoverImage, &article.Tags, &article.ViewCount,
-			&article.LikeCount, &article.CommentCount, &article.TopicSlug, &article.CreatedAt, &article.UpdatedAt,
+			&article.LikeCount, &article.CommentCount, &article.TopicSlug, &article.CreatedAt, &article.UpdatedAt, &score,
 		)
 		if err != nil {
-			return nil, err
+			return nil, PageInfo{}, err
 		}
-		
+
 		// 设置分类名称
 		article.CategoryName = getTechCategoryName(article.Category)
-		
+
 		articles = append(articles, article)
+		keys = append(keys, utils.Cursor{CreatedAt: article.CreatedAt, ID: article.ID, Score: score})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, PageInfo{}, err
 	}
 
-	return articles, nil
+	articles, info := finishPage(articles, keys, k, page)
+	return articles, info, nil
 }
 
 // 根据ID获取技术文章
@@ -183,7 +230,7 @@ func GetTechArticleByID(id int) (*TechArticle, error) {
 			   a.like_count, a.comment_count, a.topic_slug, a.created_at, a.updated_at
 		FROM tech_articles a
 		JOIN users u ON a.user_id = u.id
-		WHERE a.id = ?
+		WHERE a.id = ? AND a.deleted_at IS NULL
 	`, id).Scan(
 		&article.ID, &article.Title, &article.Content, &article.Summary, &article.Category, &article.UserID,
 		&article.AuthorName, &article.AuthorAvatar, &article.CoverImage, &article.Tags, &article.ViewCount,
@@ -204,7 +251,7 @@ func GetRelatedTechArticles(articleID, limit int) ([]*TechArticle, error) {
 	query := `
 		SELECT a.id, a.title, a.summary, a.cover_image, a.view_count, a.like_count
 		FROM tech_articles a
-		WHERE a.id != ? AND a.category = (SELECT category FROM tech_articles WHERE id = ?)
+		WHERE a.id != ? AND a.category = (SELECT category FROM tech_articles WHERE id = ?) AND a.deleted_at IS NULL
 		ORDER BY a.view_count DESC
 		LIMIT ?
 	`
@@ -228,14 +275,14 @@ func GetRelatedTechArticles(articleID, limit int) ([]*TechArticle, error) {
 	return articles, nil
 }
 
-// 获取技术文章数量
-func GetTechArticleCount(category, keyword, topic string) (int, error) {
+// 获取技术文章数量（搜索时排除viewerID静音和屏蔽的作者）
+func GetTechArticleCount(category, keyword, topic string, viewerID int) (int, error) {
 	var count int
 	var query string
 	var args []interface{}
 	
 	baseQuery := "SELECT COUNT(*) FROM tech_articles"
-	var whereConditions []string
+	whereConditions := []string{"deleted_at IS NULL"}
 	
 	if category != "" {
 		whereConditions = append(whereConditions, "category = ?")
@@ -246,6 +293,10 @@ func GetTechArticleCount(category, keyword, topic string) (int, error) {
 		whereConditions = append(whereConditions, "(title LIKE ? OR content LIKE ? OR tags LIKE ?)")
 		keyword = "%" + keyword + "%"
 		args = append(args, keyword, keyword, keyword)
+		if viewerID > 0 {
+			whereConditions = append(whereConditions, strings.TrimPrefix(hiddenAuthorFilter("user_id"), " AND "))
+			args = append(args, viewerID, viewerID)
+		}
 	}
 	
 	if topic != "" {
@@ -253,11 +304,7 @@ func GetTechArticleCount(category, keyword, topic string) (int, error) {
 		args = append(args, topic)
 	}
 	
-	if len(whereConditions) > 0 {
-		query = baseQuery + " WHERE " + strings.Join(whereConditions, " AND ")
-	} else {
-		query = baseQuery
-	}
+	query = baseQuery + " WHERE " + strings.Join(whereConditions, " AND ")
 	
 	err := DB.QueryRow(query, args...).Scan(&count)
 	return count, err
@@ -289,7 +336,24 @@ func LikeTechArticle(articleID, userID int) error {
 			return err
 		}
 		_, err = DB.Exec("UPDATE tech_articles SET like_count = like_count + 1 WHERE id = ?", articleID)
-		return err
+		if err != nil {
+			return err
+		}
+
+		// 通知作者
+		if authorID, title, err := contentOwner("tech_articles", articleID); err == nil {
+			Notify(Notification{
+				UserID:     authorID,
+				ActorID:    userID,
+				Type:       NotifyLike,
+				TargetType: "article",
+				TargetID:   articleID,
+				Subject:    title,
+				Content:    "文章：" + title,
+				Link:       "/tech-share/" + strconv.Itoa(articleID),
+			})
+		}
+		return nil
 	}
 	
 	return err
@@ -299,11 +363,10 @@ func LikeTechArticle(articleID, userID int) error {
 func GetPopularAuthors(limit int) ([]*PopularAuthor, error) {
 	query := `
 		SELECT u.id, u.username, u.avatar,
-		       COUNT(DISTINCT a.id) as article_count,
-		       COUNT(DISTINCT f.follower_id) as follower_count
+		       COUNT(a.id) as article_count,
+		       u.follower_count
 		FROM users u
-		LEFT JOIN tech_articles a ON u.id = a.user_id
-		LEFT JOIN user_follows f ON u.id = f.following_id
+		LEFT JOIN tech_articles a ON u.id = a.user_id AND a.deleted_at IS NULL
 		GROUP BY u.id
 		HAVING article_count > 0
 		ORDER BY article_count DESC, follower_count DESC
@@ -335,7 +398,7 @@ func GetRelatedTopics(limit int) ([]*Topic, error) {
 		SELECT t.id, t.name, t.slug, t.description, t.icon,
 		       COUNT(a.id) as article_count
 		FROM topics t
-		LEFT JOIN tech_articles a ON t.slug = a.topic_slug
+		LEFT JOIN tech_articles a ON t.slug = a.topic_slug AND a.deleted_at IS NULL
 		GROUP BY t.id
 		ORDER BY article_count DESC
 		LIMIT ?
@@ -374,7 +437,7 @@ func GetTopicBySlug(slug string) (*Topic, error) {
 	}
 	
 	// 获取文章数量
-	err = DB.QueryRow("SELECT COUNT(*) FROM tech_articles WHERE topic_slug = ?", slug).Scan(&topic.ArticleCount)
+	err = DB.QueryRow("SELECT COUNT(*) FROM tech_articles WHERE topic_slug = ? AND deleted_at IS NULL", slug).Scan(&topic.ArticleCount)
 	if err != nil {
 		return nil, err
 	}
@@ -382,24 +445,6 @@ func GetTopicBySlug(slug string) (*Topic, error) {
 	return topic, nil
 }
 
-// 关注/取消关注作者
-func ToggleFollowAuthor(followingID, followerID int) (bool, error) {
-	// 检查是否已关注
-	var exists int
-	err := DB.QueryRow("SELECT 1 FROM user_follows WHERE following_id = ? AND follower_id = ?", followingID, followerID).Scan(&exists)
-	if err == nil {
-		// 已关注，取消关注
-		_, err = DB.Exec("DELETE FROM user_follows WHERE following_id = ? AND follower_id = ?", followingID, followerID)
-		return false, err
-	} else if err == sql.ErrNoRows {
-		// 未关注，添加关注
-		_, err = DB.Exec("INSERT INTO user_follows (following_id, follower_id) VALUES (?, ?)", followingID, followerID)
-		return true, err
-	}
-	
-	return false, err
-}
-
 // 生成文章摘要
 func generateTechArticleSummary(content string) string {
 	if len(content) <= 200 {
@@ -410,20 +455,27 @@ func generateTechArticleSummary(content string) string {
 
 // 获取分类名称
 func getTechCategoryName(category string) string {
-	categoryMap := map[string]string{
-		"algorithm": "算法研究",
-		"development": "应用开发",
-		"industry": "行业动态",
-		"tutorial": "教程指南",
-		"research": "研究论文",
-	}
-	
-	if name, exists := categoryMap[category]; exists {
+	if name, exists := techCategoryNames[category]; exists {
 		return name
 	}
 	return category
 }
 
+// 技术文章分类的中文名称
+var techCategoryNames = map[string]string{
+	"algorithm":   "算法研究",
+	"development": "应用开发",
+	"industry":    "行业动态",
+	"tutorial":    "教程指南",
+	"research":    "研究论文",
+}
+
+// 获取技术文章分类的中文名称，分类不存在时ok为false
+func TechCategoryName(category string) (name string, ok bool) {
+	name, ok = techCategoryNames[category]
+	return name, ok
+}
+
 // 根据ID获取技术文章详情（字符串ID版本）
 func GetTechArticleByIDString(articleID string) (*TechArticle, error) {
 	article := &TechArticle{}
@@ -433,7 +485,7 @@ func GetTechArticleByIDString(articleID string) (*TechArticle, error) {
 			   a.like_count, a.comment_count, a.topic_slug, a.created_at, a.updated_at
 		FROM tech_articles a
 		JOIN users u ON a.user_id = u.id
-		WHERE a.id = ?
+		WHERE a.id = ? AND a.deleted_at IS NULL
 	`, articleID).Scan(&article.ID, &article.Title, &article.Content, &article.Summary, 
 		&article.Category, &article.UserID, &article.AuthorName, &article.AuthorAvatar, 
 		&article.AuthorBio, &article.CoverImage, &article.Tags, &article.ViewCount, 
@@ -465,48 +517,152 @@ func IsArticleLiked(articleID, userID int) bool {
 	return err == nil
 }
 
-// 检查用户是否已收藏文章
-func IsArticleFavorited(articleID, userID int) bool {
-	var exists int
-	err := DB.QueryRow("SELECT 1 FROM article_favorites WHERE article_id = ? AND user_id = ?", articleID, userID).Scan(&exists)
-	return err == nil
+// 批量检查用户点赞过哪些文章
+func GetLikedArticleIDs(userID int, articleIDs []int) (map[int]bool, error) {
+	return linkedIDs("tech_article_likes", "article_id", userID, articleIDs, "")
+}
+
+// 发表文章评论（parentID大于0时为回复评论）
+func CreateArticleComment(articleID, userID, parentID int, content string) (int, error) {
+	authorID, title, err := contentOwner("tech_articles", articleID)
+	if err != nil {
+		return 0, err
+	}
+
+	var parent interface{}
+	var parentAuthorID int
+	if parentID > 0 {
+		err := DB.QueryRow("SELECT user_id FROM article_comments WHERE id = ? AND article_id = ?", parentID, articleID).Scan(&parentAuthorID)
+		if err != nil {
+			return 0, err
+		}
+		parent = parentID
+	}
+	if err := checkNotBlockedBy(userID, authorID, parentAuthorID); err != nil {
+		return 0, err
+	}
+
+	result, err := DB.Exec("INSERT INTO article_comments (article_id, user_id, content, parent_id) VALUES (?, ?, ?, ?)",
+		articleID, userID, content, parent)
+	if err != nil {
+		return 0, err
+	}
+
+	commentID, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	// 更新评论计数
+	_, err = DB.Exec("UPDATE tech_articles SET comment_count = comment_count + 1 WHERE id = ?", articleID)
+	if err != nil {
+		return 0, err
+	}
+
+	// 通知被回复的评论者和文章作者
+	link := "/tech-share/" + strconv.Itoa(articleID)
+	if parentAuthorID > 0 {
+		Notify(Notification{
+			UserID:     parentAuthorID,
+			ActorID:    userID,
+			Type:       NotifyReply,
+			TargetType: "comment",
+			TargetID:   parentID,
+			Subject:    title,
+			Content:    notificationExcerpt(content),
+			Link:       link,
+		})
+	}
+	if authorID != parentAuthorID {
+		Notify(Notification{
+			UserID:     authorID,
+			ActorID:    userID,
+			Type:       NotifyComment,
+			TargetType: "article",
+			TargetID:   articleID,
+			Subject:    title,
+			Content:    notificationExcerpt(content),
+			Link:       link,
+		})
+	}
+	RecordMentions(MentionSource{
+		ContentType: MentionInArticleComment,
+		ContentID:   int(commentID),
+		ActorID:     userID,
+		TargetType:  "comment",
+		TargetID:    int(commentID),
+		Subject:     title,
+		Link:        link,
+	}, content, authorID, parentAuthorID)
+
+	return int(commentID), nil
 }
 
 // 获取文章评论
 func GetArticleComments(articleID int) ([]Comment, error) {
+	comments, err := GetArticleCommentsByArticleIDs([]int{articleID})
+	if err != nil {
+		return nil, err
+	}
+	return comments[articleID], nil
+}
+
+// 批量获取文章评论，按文章ID分组。评论按时间倒序，
+// 每条评论的直接回复放在Replies中按时间正序排列
+func GetArticleCommentsByArticleIDs(articleIDs []int) (map[int][]Comment, error) {
+	result := make(map[int][]Comment)
+	if len(articleIDs) == 0 {
+		return result, nil
+	}
+	in, args := inClause(articleIDs)
 	query := `
-		SELECT c.id, c.content, c.user_id, u.username, u.avatar, c.like_count, c.created_at
+		SELECT c.id, c.article_id, IFNULL(c.parent_id, 0), c.content, c.user_id, u.username, u.avatar, c.like_count, c.created_at
 		FROM article_comments c
 		JOIN users u ON c.user_id = u.id
-		WHERE c.article_id = ? AND c.parent_id IS NULL
-		ORDER BY c.created_at DESC
+		WHERE c.article_id IN (` + in + `)
+		ORDER BY c.created_at ASC, c.id ASC
 	`
-	
-	rows, err := DB.Query(query, articleID)
+
+	rows, err := DB.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var comments []Comment
+	type row struct {
+		comment   Comment
+		articleID int
+		parentID  int
+	}
+	var all []row
 	for rows.Next() {
-		comment := Comment{}
-		err := rows.Scan(&comment.ID, &comment.Content, &comment.UserID, 
-			&comment.Username, &comment.UserAvatar, &comment.LikeCount, &comment.CreatedAt)
+		var r row
+		err := rows.Scan(&r.comment.ID, &r.articleID, &r.parentID, &r.comment.Content, &r.comment.UserID,
+			&r.comment.Username, &r.comment.UserAvatar, &r.comment.LikeCount, &r.comment.CreatedAt)
 		if err != nil {
 			return nil, err
 		}
-		
-		// 获取回复
-		replies, err := GetCommentReplies(comment.ID)
-		if err == nil {
-			comment.Replies = replies
-		}
-		
-		comments = append(comments, comment)
+		all = append(all, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
-	return comments, nil
+	// 只显示顶层评论的直接回复
+	replies := make(map[int][]Comment)
+	for _, r := range all {
+		if r.parentID > 0 {
+			replies[r.parentID] = append(replies[r.parentID], r.comment)
+		}
+	}
+	for i := len(all) - 1; i >= 0; i-- {
+		r := all[i]
+		if r.parentID == 0 {
+			r.comment.Replies = replies[r.comment.ID]
+			result[r.articleID] = append(result[r.articleID], r.comment)
+		}
+	}
+	return result, nil
 }
 
 // 获取评论回复
@@ -539,6 +695,11 @@ func GetCommentReplies(commentID int) ([]Comment, error) {
 	return replies, nil
 }
 
+// 批量检查用户点赞过哪些评论
+func GetLikedCommentIDs(userID int, commentIDs []int) (map[int]bool, error) {
+	return linkedIDs("comment_likes", "comment_id", userID, commentIDs, "")
+}
+
 // 检查用户是否已点赞评论
 func IsCommentLiked(commentID, userID int) bool {
 	var exists int
@@ -552,7 +713,7 @@ func GetRelatedArticles(articleID int, category string, limit int) ([]TechArticl
 		SELECT a.id, a.title, a.cover_image, a.user_id, u.username, a.view_count, a.created_at
 		FROM tech_articles a
 		JOIN users u ON a.user_id = u.id
-		WHERE a.id != ? AND a.category = ?
+		WHERE a.id != ? AND a.category = ? AND a.deleted_at IS NULL
 		ORDER BY a.view_count DESC, a.created_at DESC
 		LIMIT ?
 	`
@@ -582,7 +743,7 @@ func GetAuthorArticles(userID, excludeArticleID int, limit int) ([]TechArticle,
 	query := `
 		SELECT a.id, a.title, a.cover_image, a.view_count, a.created_at
 		FROM tech_articles a
-		WHERE a.user_id = ? AND a.id != ?
+		WHERE a.user_id = ? AND a.id != ? AND a.deleted_at IS NULL
 		ORDER BY a.created_at DESC
 		LIMIT ?
 	`
@@ -0,0 +1,259 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+	"time"
+)
+
+// 删除的内容在回收站中保留的时间，到期后彻底删除
+const TrashRetention = 30 * 24 * time.Hour
+
+// 回答不参与标签，单独定义内容类型
+const ContentTypeAnswer = "answer"
+
+var (
+	ErrInvalidTrashType = errors.New("无效的内容类型")
+	ErrTrashNotFound    = errors.New("回收站中没有该内容")
+	ErrTrashExpired     = errors.New("内容已超过保留期限，无法恢复")
+)
+
+// 支持软删除的表
+var softDeleteTables = map[string]bool{
+	"questions":          true,
+	"answers":            true,
+	"tech_articles":      true,
+	"learning_resources": true,
+}
+
+// 可删除到回收站的内容类型对应的表和发布时获得的积分
+var trashTypes = map[string]struct {
+	table  string
+	points int
+}{
+	ContentTypeQuestion: {"questions", 5},
+	ContentTypeAnswer:   {"answers", 3},
+	ContentTypeArticle:  {"tech_articles", 10},
+	ContentTypeResource: {"learning_resources", 20},
+}
+
+// 回收站中的内容
+type TrashItem struct {
+	ContentType string    `json:"content_type"`
+	ContentID   int       `json:"content_id"`
+	Title       string    `json:"title"`
+	DeletedAt   time.Time `json:"deleted_at"`
+	PurgeAt     time.Time `json:"purge_at"`
+}
+
+// 检查是否为可删除到回收站的内容类型
+func ValidTrashType(contentType string) bool {
+	_, ok := trashTypes[contentType]
+	return ok
+}
+
+// 作者删除内容，移入回收站并撤回发布时的计数和积分
+func TrashContent(userID int, contentType string, contentID int) error {
+	kind, ok := trashTypes[contentType]
+	if !ok {
+		return ErrInvalidTrashType
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("UPDATE "+kind.table+" SET deleted_at = NOW() WHERE id = ? AND user_id = ? AND deleted_at IS NULL",
+		contentID, userID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	if err := adjustTrashCounters(tx, contentType, contentID, -1); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if contentType == ContentTypeQuestion {
+		updateSimilarityIndex(contentID)
+	}
+	return nil
+}
+
+// 作者从回收站恢复内容
+func RestoreContent(userID int, contentType string, contentID int) error {
+	return restoreContent(contentType, contentID, userID)
+}
+
+// 版主恢复任意用户删除的内容
+func UndeleteContent(contentType string, contentID int) error {
+	return restoreContent(contentType, contentID, 0)
+}
+
+// 恢复内容（ownerID为0时不限制作者），重新计入计数和积分
+func restoreContent(contentType string, contentID, ownerID int) error {
+	kind, ok := trashTypes[contentType]
+	if !ok {
+		return ErrInvalidTrashType
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var userID int
+	var deletedAt sql.NullTime
+	err = tx.QueryRow("SELECT user_id, deleted_at FROM "+kind.table+" WHERE id = ? FOR UPDATE", contentID).
+		Scan(&userID, &deletedAt)
+	if err == sql.ErrNoRows || (err == nil && (!deletedAt.Valid || (ownerID > 0 && userID != ownerID))) {
+		return ErrTrashNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if time.Since(deletedAt.Time) > TrashRetention {
+		return ErrTrashExpired
+	}
+
+	if _, err := tx.Exec("UPDATE "+kind.table+" SET deleted_at = NULL WHERE id = ?", contentID); err != nil {
+		return err
+	}
+	if err := adjustTrashCounters(tx, contentType, contentID, 1); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if contentType == ContentTypeQuestion {
+		updateSimilarityIndex(contentID)
+	}
+	return nil
+}
+
+// 删除（delta为-1）或恢复（delta为1）内容时调整作者积分和相关计数
+func adjustTrashCounters(tx *sql.Tx, contentType string, contentID, delta int) error {
+	kind := trashTypes[contentType]
+
+	switch contentType {
+	case ContentTypeQuestion:
+		_, err := tx.Exec(`
+			UPDATE categories c JOIN questions q ON q.category_id = c.id
+			SET c.post_count = GREATEST(c.post_count + ?, 0)
+			WHERE q.id = ?
+		`, delta, contentID)
+		if err != nil {
+			return err
+		}
+	case ContentTypeAnswer:
+		// 社区维基问题下的回答不产生声望；被采纳的回答删除后问题回到未解决
+		var questionID int
+		var isAccepted, isWiki bool
+		err := tx.QueryRow(`
+			SELECT a.question_id, a.is_accepted, q.is_wiki FROM answers a
+			JOIN questions q ON a.question_id = q.id
+			WHERE a.id = ?
+		`, contentID).Scan(&questionID, &isAccepted, &isWiki)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec("UPDATE questions SET answer_count = GREATEST(answer_count + ?, 0) WHERE id = ?",
+			delta, questionID)
+		if err != nil {
+			return err
+		}
+		if isAccepted {
+			if _, err := tx.Exec("UPDATE questions SET is_solved = ? WHERE id = ?", delta > 0, questionID); err != nil {
+				return err
+			}
+		}
+		if isWiki {
+			return nil
+		}
+	}
+
+	_, err := tx.Exec("UPDATE users u JOIN "+kind.table+" t ON t.user_id = u.id SET u.points = u.points + ? WHERE t.id = ?",
+		delta*kind.points, contentID)
+	return err
+}
+
+// 获取用户的回收站
+func GetUserTrash(userID int) ([]*TrashItem, error) {
+	rows, err := DB.Query(`
+		SELECT 'question', id, title, deleted_at FROM questions WHERE user_id = ? AND deleted_at IS NOT NULL
+		UNION ALL
+		SELECT 'answer', a.id, q.title, a.deleted_at FROM answers a
+		JOIN questions q ON a.question_id = q.id
+		WHERE a.user_id = ? AND a.deleted_at IS NOT NULL
+		UNION ALL
+		SELECT 'article', id, title, deleted_at FROM tech_articles WHERE user_id = ? AND deleted_at IS NOT NULL
+		UNION ALL
+		SELECT 'resource', id, title, deleted_at FROM learning_resources WHERE user_id = ? AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC
+	`, userID, userID, userID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []*TrashItem
+	for rows.Next() {
+		item := &TrashItem{}
+		if err := rows.Scan(&item.ContentType, &item.ContentID, &item.Title, &item.DeletedAt); err != nil {
+			return nil, err
+		}
+		item.PurgeAt = item.DeletedAt.Add(TrashRetention)
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// 彻底删除超过保留期限的内容，返回删除的数量
+func PurgeTrash() (int, error) {
+	cutoff := time.Now().Add(-TrashRetention)
+	purged := 0
+
+	// 回答、点赞、评论等关联数据通过外键级联删除
+	for _, contentType := range []string{ContentTypeAnswer, ContentTypeQuestion, ContentTypeArticle, ContentTypeResource} {
+		table := trashTypes[contentType].table
+		ids, err := queryIDs("SELECT id FROM "+table+" WHERE deleted_at < ?", cutoff)
+		if err != nil {
+			return purged, err
+		}
+		if len(ids) == 0 {
+			continue
+		}
+
+		args := make([]interface{}, len(ids))
+		for i, id := range ids {
+			args[i] = id
+		}
+		placeholders := "?" + strings.Repeat(", ?", len(ids)-1)
+
+		if _, err := DB.Exec("DELETE FROM "+table+" WHERE id IN ("+placeholders+")", args...); err != nil {
+			return purged, err
+		}
+		purged += len(ids)
+
+		if contentType == ContentTypeAnswer {
+			continue
+		}
+		for _, id := range ids {
+			if err := RemoveContentTags(contentType, id); err != nil {
+				log.Printf("清理标签失败 (%s=%d): %v", contentType, id, err)
+			}
+		}
+	}
+	return purged, nil
+}
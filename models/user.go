@@ -21,8 +21,8 @@ func CreateUser(username, email, password string) error {
 // 根据用户名获取用户
 func GetUserByUsername(username string) (*User, error) {
 	user := &User{}
-	err := DB.QueryRow("SELECT id, username, email, password, avatar, level, points, created_at, updated_at FROM users WHERE username = ?",
-		username).Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.Avatar, &user.Level, &user.Points, &user.CreatedAt, &user.UpdatedAt)
+	err := DB.QueryRow("SELECT id, username, email, password, avatar, level, points, role, created_at, updated_at FROM users WHERE username = ?",
+		username).Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.Avatar, &user.Level, &user.Points, &user.Role, &user.CreatedAt, &user.UpdatedAt)
 	
 	if err != nil {
 		return nil, err
@@ -33,8 +33,8 @@ func GetUserByUsername(username string) (*User, error) {
 // 根据ID获取用户
 func GetUserByID(id int) (*User, error) {
 	user := &User{}
-	err := DB.QueryRow("SELECT id, username, email, password, avatar, level, points, created_at, updated_at FROM users WHERE id = ?",
-		id).Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.Avatar, &user.Level, &user.Points, &user.CreatedAt, &user.UpdatedAt)
+	err := DB.QueryRow("SELECT id, username, email, password, avatar, level, points, role, created_at, updated_at FROM users WHERE id = ?",
+		id).Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.Avatar, &user.Level, &user.Points, &user.Role, &user.CreatedAt, &user.UpdatedAt)
 	
 	if err != nil {
 		return nil, err
@@ -42,6 +42,30 @@ func GetUserByID(id int) (*User, error) {
 	return user, nil
 }
 
+// 批量获取用户，不存在的用户不会出现在结果中
+func GetUsersByIDs(ids []int) (map[int]*User, error) {
+	users := make(map[int]*User)
+	if len(ids) == 0 {
+		return users, nil
+	}
+	in, args := inClause(ids)
+	rows, err := DB.Query("SELECT id, username, email, avatar, level, points, role, created_at, updated_at FROM users WHERE id IN ("+in+")", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		user := &User{}
+		err := rows.Scan(&user.ID, &user.Username, &user.Email, &user.Avatar, &user.Level, &user.Points, &user.Role, &user.CreatedAt, &user.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		users[user.ID] = user
+	}
+	return users, rows.Err()
+}
+
 // 检查用户名是否存在
 func UsernameExists(username string) (bool, error) {
 	var exists int
@@ -76,6 +100,23 @@ func UpdateUserPoints(id, points int) error {
 	return err
 }
 
+// 用户角色
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// 是否为管理员
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin
+}
+
+// 是否有版主权限（管理员同样具备）
+func (u *User) IsModerator() bool {
+	return u.Role == RoleModerator || u.Role == RoleAdmin
+}
+
 // 获取用户等级
 func GetUserLevel(points int) int {
 	if points >= 1000 {
@@ -65,16 +65,6 @@ type UserResource struct {
 	CreatedAt   time.Time `json:"created_at"`
 }
 
-// 用户收藏结构
-type UserFavorite struct {
-	ID        int       `json:"id"`
-	Type      string    `json:"type"` // question, share, resource
-	Title     string    `json:"title"`
-	Content   string    `json:"content"`
-	Author    string    `json:"author"`
-	CreatedAt time.Time `json:"created_at"`
-}
-
 // 用户关注结构
 type UserFollowing struct {
 	ID         int    `json:"id"`
@@ -84,6 +74,7 @@ type UserFollowing struct {
 	Followers  int    `json:"followers"`
 	Questions  int    `json:"questions"`
 	Answers    int    `json:"answers"`
+	IsMutual   bool   `json:"is_mutual"`
 	CreatedAt  time.Time `json:"created_at"`
 }
 
@@ -96,25 +87,38 @@ type UserFollower struct {
 	Followers  int    `json:"followers"`
 	Questions  int    `json:"questions"`
 	Answers    int    `json:"answers"`
+	IsMutual   bool   `json:"is_mutual"`
 	CreatedAt  time.Time `json:"created_at"`
 }
 
 // 用户消息结构
 type UserMessage struct {
-	ID        int       `json:"id"`
-	Type      string    `json:"type"` // system, question, follow, comment
-	Title     string    `json:"title"`
-	Content   string    `json:"content"`
-	Sender    string    `json:"sender"`
-	IsRead    bool      `json:"is_read"`
-	CreatedAt time.Time `json:"created_at"`
+	ID         int       `json:"id"`
+	Type       string    `json:"type"` // system, answer, accept, comment, reply, like, follow, mention, moderation
+	Title      string    `json:"title"`
+	Content    string    `json:"content"`
+	Sender     string    `json:"sender"`
+	Link       string    `json:"link"`
+	ActorCount int       `json:"actor_count"`
+	IsRead     bool      `json:"is_read"`
+	CreatedAt  time.Time `json:"created_at"`
 }
 
-// 获取用户动态
-func GetUserActivity(userID int, filter string) ([]UserActivity, error) {
-	var query string
-	var args []interface{}
+// 个人中心列表的排序（按时间倒序）
+var (
+	// 动态中提问和回答的ID可能相同，按类型区分
+	activityKeyset = keyset{name: "latest", createdAt: "created_at", kind: "type", id: "id"}
 
+	userQuestionKeyset = latestKeyset("latest", "created_at", "id")
+	userAnswerKeyset   = latestKeyset("latest", "a.created_at", "a.id")
+	userShareKeyset    = latestKeyset("latest", "created_at", "id")
+	userResourceKeyset = latestKeyset("latest", "created_at", "id")
+	followKeyset       = latestKeyset("latest", "f.created_at", "u.id")
+	userMessageKeyset  = latestKeyset("latest", "created_at", "id")
+)
+
+// 获取用户动态
+func GetUserActivity(userID int, filter string, page PageQuery) ([]UserActivity, PageInfo, error) {
 	baseQuery := `
 		SELECT 
 			'question' as type,
@@ -124,10 +128,10 @@ func GetUserActivity(userID int, filter string) ([]UserActivity, error) {
 			LEFT(q.content, 200) as content,
 			q.id as target_id,
 			q.views,
-			(SELECT COUNT(*) FROM answers WHERE question_id = q.id) as comments,
+			(SELECT COUNT(*) FROM answers WHERE question_id = q.id AND deleted_at IS NULL) as comments,
 			q.created_at
 		FROM questions q
-		WHERE q.user_id = ?
+		WHERE q.user_id = ? AND q.deleted_at IS NULL
 		
 		UNION ALL
 		
@@ -143,33 +147,29 @@ func GetUserActivity(userID int, filter string) ([]UserActivity, error) {
 			a.created_at
 		FROM answers a
 		JOIN questions q ON a.question_id = q.id
-		WHERE a.user_id = ?
-		
-		ORDER BY created_at DESC
-		LIMIT 50
+		WHERE a.user_id = ? AND a.deleted_at IS NULL AND q.deleted_at IS NULL
 	`
 
+	query := "SELECT * FROM (" + baseQuery + ") AS activities WHERE 1 = 1"
+	args := []interface{}{userID, userID}
 	if filter != "all" && filter != "" {
-		query = `
-			SELECT * FROM (
-				` + baseQuery + `
-			) as activities
-			WHERE type = ?
-			ORDER BY created_at DESC
-		`
-		args = []interface{}{userID, userID, filter}
-	} else {
-		query = baseQuery
-		args = []interface{}{userID, userID}
+		query += " AND type = ?"
+		args = append(args, filter)
+	}
+
+	query, args, err := activityKeyset.apply(query, args, page)
+	if err != nil {
+		return nil, PageInfo{}, err
 	}
 
 	rows, err := DB.Query(query, args...)
 	if err != nil {
-		return nil, err
+		return nil, PageInfo{}, err
 	}
 	defer rows.Close()
 
 	var activities []UserActivity
+	var keys []utils.Cursor
 	for rows.Next() {
 		var activity UserActivity
 		err := rows.Scan(
@@ -184,37 +184,52 @@ func GetUserActivity(userID int, filter string) ([]UserActivity, error) {
 			&activity.CreatedAt,
 		)
 		if err != nil {
-			return nil, err
+			return nil, PageInfo{}, err
 		}
 		activities = append(activities, activity)
+		keys = append(keys, utils.Cursor{CreatedAt: activity.CreatedAt, ID: activity.ID, Type: activity.Type})
 	}
 
-	return activities, nil
+	if err := rows.Err(); err != nil {
+		return nil, PageInfo{}, err
+	}
+
+	activities, info := finishPage(activities, keys, activityKeyset, page)
+	return activities, info, nil
 }
 
 // 获取用户提问
-func GetUserQuestions(userID int) ([]UserQuestion, error) {
+func GetUserQuestions(userID int, page PageQuery) ([]UserQuestion, PageInfo, error) {
 	query := `
 		SELECT 
 			id,
 			title,
 			LEFT(content, 200) as content,
-			status,
-			(SELECT COUNT(*) FROM answers WHERE question_id = questions.id) as answer_count,
-			views,
+			CASE
+				WHEN status = 'closed' THEN 'closed'
+				WHEN is_solved = 1 OR answer_count > 0 THEN 'answered'
+				ELSE 'open'
+			END as status,
+			(SELECT COUNT(*) FROM answers WHERE question_id = questions.id AND deleted_at IS NULL) as answer_count,
+			view_count,
 			created_at
 		FROM questions 
-		WHERE user_id = ?
-		ORDER BY created_at DESC
+		WHERE user_id = ? AND deleted_at IS NULL
 	`
 
-	rows, err := DB.Query(query, userID)
+	query, args, err := userQuestionKeyset.apply(query, []interface{}{userID}, page)
 	if err != nil {
-		return nil, err
+		return nil, PageInfo{}, err
+	}
+
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return nil, PageInfo{}, err
 	}
 	defer rows.Close()
 
 	var questions []UserQuestion
+	var keys []utils.Cursor
 	for rows.Next() {
 		var question UserQuestion
 		err := rows.Scan(
@@ -227,16 +242,22 @@ func GetUserQuestions(userID int) ([]UserQuestion, error) {
 			&question.CreatedAt,
 		)
 		if err != nil {
-			return nil, err
+			return nil, PageInfo{}, err
 		}
 		questions = append(questions, question)
+		keys = append(keys, utils.Cursor{CreatedAt: question.CreatedAt, ID: question.ID})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, PageInfo{}, err
 	}
 
-	return questions, nil
+	questions, info := finishPage(questions, keys, userQuestionKeyset, page)
+	return questions, info, nil
 }
 
 // 获取用户回答
-func GetUserAnswers(userID int) ([]UserAnswer, error) {
+func GetUserAnswers(userID int, page PageQuery) ([]UserAnswer, PageInfo, error) {
 	query := `
 		SELECT 
 			a.id,
@@ -249,17 +270,22 @@ func GetUserAnswers(userID int) ([]UserAnswer, error) {
 			a.created_at
 		FROM answers a
 		JOIN questions q ON a.question_id = q.id
-		WHERE a.user_id = ?
-		ORDER BY a.created_at DESC
+		WHERE a.user_id = ? AND a.deleted_at IS NULL AND q.deleted_at IS NULL
 	`
 
-	rows, err := DB.Query(query, userID)
+	query, args, err := userAnswerKeyset.apply(query, []interface{}{userID}, page)
 	if err != nil {
-		return nil, err
+		return nil, PageInfo{}, err
+	}
+
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return nil, PageInfo{}, err
 	}
 	defer rows.Close()
 
 	var answers []UserAnswer
+	var keys []utils.Cursor
 	for rows.Next() {
 		var answer UserAnswer
 		err := rows.Scan(
@@ -273,16 +299,22 @@ func GetUserAnswers(userID int) ([]UserAnswer, error) {
 			&answer.CreatedAt,
 		)
 		if err != nil {
-			return nil, err
+			return nil, PageInfo{}, err
 		}
 		answers = append(answers, answer)
+		keys = append(keys, utils.Cursor{CreatedAt: answer.CreatedAt, ID: answer.ID})
 	}
 
-	return answers, nil
+	if err := rows.Err(); err != nil {
+		return nil, PageInfo{}, err
+	}
+
+	answers, info := finishPage(answers, keys, userAnswerKeyset, page)
+	return answers, info, nil
 }
 
 // 获取用户分享
-func GetUserShares(userID int) ([]UserShare, error) {
+func GetUserShares(userID int, page PageQuery) ([]UserShare, PageInfo, error) {
 	query := `
 		SELECT 
 			id,
@@ -294,17 +326,22 @@ func GetUserShares(userID int) ([]UserShare, error) {
 			(SELECT COUNT(*) FROM comments WHERE tech_article_id = tech_articles.id) as comments,
 			created_at
 		FROM tech_articles 
-		WHERE user_id = ?
-		ORDER BY created_at DESC
+		WHERE user_id = ? AND deleted_at IS NULL
 	`
 
-	rows, err := DB.Query(query, userID)
+	query, args, err := userShareKeyset.apply(query, []interface{}{userID}, page)
 	if err != nil {
-		return nil, err
+		return nil, PageInfo{}, err
+	}
+
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return nil, PageInfo{}, err
 	}
 	defer rows.Close()
 
 	var shares []UserShare
+	var keys []utils.Cursor
 	for rows.Next() {
 		var share UserShare
 		err := rows.Scan(
@@ -318,16 +355,22 @@ func GetUserShares(userID int) ([]UserShare, error) {
 			&share.CreatedAt,
 		)
 		if err != nil {
-			return nil, err
+			return nil, PageInfo{}, err
 		}
 		shares = append(shares, share)
+		keys = append(keys, utils.Cursor{CreatedAt: share.CreatedAt, ID: share.ID})
 	}
 
-	return shares, nil
+	if err := rows.Err(); err != nil {
+		return nil, PageInfo{}, err
+	}
+
+	shares, info := finishPage(shares, keys, userShareKeyset, page)
+	return shares, info, nil
 }
 
 // 获取用户资料
-func GetUserResources(userID int) ([]UserResource, error) {
+func GetUserResources(userID int, page PageQuery) ([]UserResource, PageInfo, error) {
 	query := `
 		SELECT 
 			id,
@@ -339,17 +382,22 @@ func GetUserResources(userID int) ([]UserResource, error) {
 			views,
 			created_at
 		FROM learning_resources 
-		WHERE user_id = ?
-		ORDER BY created_at DESC
+		WHERE user_id = ? AND deleted_at IS NULL
 	`
 
-	rows, err := DB.Query(query, userID)
+	query, args, err := userResourceKeyset.apply(query, []interface{}{userID}, page)
 	if err != nil {
-		return nil, err
+		return nil, PageInfo{}, err
+	}
+
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return nil, PageInfo{}, err
 	}
 	defer rows.Close()
 
 	var resources []UserResource
+	var keys []utils.Cursor
 	for rows.Next() {
 		var resource UserResource
 		err := rows.Scan(
@@ -363,95 +411,51 @@ func GetUserResources(userID int) ([]UserResource, error) {
 			&resource.CreatedAt,
 		)
 		if err != nil {
-			return nil, err
+			return nil, PageInfo{}, err
 		}
 		resources = append(resources, resource)
+		keys = append(keys, utils.Cursor{CreatedAt: resource.CreatedAt, ID: resource.ID})
 	}
 
-	return resources, nil
-}
-
-// 获取用户收藏
-func GetUserFavorites(userID int) ([]UserFavorite, error) {
-	query := `
-		SELECT 
-			f.id,
-			f.type,
-			CASE 
-				WHEN f.type = 'question' THEN q.title
-				WHEN f.type = 'share' THEN t.title
-				WHEN f.type = 'resource' THEN lr.title
-			END as title,
-			CASE 
-				WHEN f.type = 'question' THEN LEFT(q.content, 100)
-				WHEN f.type = 'share' THEN LEFT(t.content, 100)
-				WHEN f.type = 'resource' THEN lr.description
-			END as content,
-			u.username as author,
-			f.created_at
-		FROM favorites f
-		LEFT JOIN questions q ON f.type = 'question' AND f.target_id = q.id
-		LEFT JOIN tech_articles t ON f.type = 'share' AND f.target_id = t.id
-		LEFT JOIN learning_resources lr ON f.type = 'resource' AND f.target_id = lr.id
-		LEFT JOIN users u ON 
-			(f.type = 'question' AND q.user_id = u.id) OR
-			(f.type = 'share' AND t.user_id = u.id) OR
-			(f.type = 'resource' AND lr.user_id = u.id)
-		WHERE f.user_id = ?
-		ORDER BY f.created_at DESC
-	`
-
-	rows, err := DB.Query(query, userID)
-	if err != nil {
-		return nil, err
+	if err := rows.Err(); err != nil {
+		return nil, PageInfo{}, err
 	}
-	defer rows.Close()
 
-	var favorites []UserFavorite
-	for rows.Next() {
-		var favorite UserFavorite
-		err := rows.Scan(
-			&favorite.ID,
-			&favorite.Type,
-			&favorite.Title,
-			&favorite.Content,
-			&favorite.Author,
-			&favorite.CreatedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
-		favorites = append(favorites, favorite)
-	}
-
-	return favorites, nil
+	resources, info := finishPage(resources, keys, userResourceKeyset, page)
+	return resources, info, nil
 }
 
 // 获取用户关注
-func GetUserFollowing(userID int) ([]UserFollowing, error) {
+func GetUserFollowing(userID int, page PageQuery) ([]UserFollowing, PageInfo, error) {
 	query := `
 		SELECT 
 			u.id,
 			u.username,
 			u.avatar,
 			u.bio,
-			(SELECT COUNT(*) FROM follows WHERE followed_id = u.id) as followers,
-			(SELECT COUNT(*) FROM questions WHERE user_id = u.id) as questions,
-			(SELECT COUNT(*) FROM answers WHERE user_id = u.id) as answers,
+			u.follower_count,
+			(SELECT COUNT(*) FROM questions WHERE user_id = u.id AND deleted_at IS NULL) as questions,
+			(SELECT COUNT(*) FROM answers WHERE user_id = u.id AND deleted_at IS NULL) as answers,
+			EXISTS (SELECT 1 FROM follows WHERE follower_id = u.id AND followed_id = ?) as is_mutual,
 			f.created_at
 		FROM follows f
 		JOIN users u ON f.followed_id = u.id
 		WHERE f.follower_id = ?
-		ORDER BY f.created_at DESC
 	`
 
-	rows, err := DB.Query(query, userID)
+	query, args, err := followKeyset.apply(query, []interface{}{userID, userID}, page)
 	if err != nil {
-		return nil, err
+		return nil, PageInfo{}, err
+	}
+
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return nil, PageInfo{}, err
 	}
 	defer rows.Close()
 
 	var following []UserFollowing
+	var keys []utils.Cursor
 	for rows.Next() {
 		var user UserFollowing
 		err := rows.Scan(
@@ -462,42 +466,55 @@ func GetUserFollowing(userID int) ([]UserFollowing, error) {
 			&user.Followers,
 			&user.Questions,
 			&user.Answers,
+			&user.IsMutual,
 			&user.CreatedAt,
 		)
 		if err != nil {
-			return nil, err
+			return nil, PageInfo{}, err
 		}
 		following = append(following, user)
+		keys = append(keys, utils.Cursor{CreatedAt: user.CreatedAt, ID: user.ID})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, PageInfo{}, err
 	}
 
-	return following, nil
+	following, info := finishPage(following, keys, followKeyset, page)
+	return following, info, nil
 }
 
 // 获取用户粉丝
-func GetUserFollowers(userID int) ([]UserFollower, error) {
+func GetUserFollowers(userID int, page PageQuery) ([]UserFollower, PageInfo, error) {
 	query := `
 		SELECT 
 			u.id,
 			u.username,
 			u.avatar,
 			u.bio,
-			(SELECT COUNT(*) FROM follows WHERE followed_id = u.id) as followers,
-			(SELECT COUNT(*) FROM questions WHERE user_id = u.id) as questions,
-			(SELECT COUNT(*) FROM answers WHERE user_id = u.id) as answers,
+			u.follower_count,
+			(SELECT COUNT(*) FROM questions WHERE user_id = u.id AND deleted_at IS NULL) as questions,
+			(SELECT COUNT(*) FROM answers WHERE user_id = u.id AND deleted_at IS NULL) as answers,
+			EXISTS (SELECT 1 FROM follows WHERE follower_id = ? AND followed_id = u.id) as is_mutual,
 			f.created_at
 		FROM follows f
 		JOIN users u ON f.follower_id = u.id
 		WHERE f.followed_id = ?
-		ORDER BY f.created_at DESC
 	`
 
-	rows, err := DB.Query(query, userID)
+	query, args, err := followKeyset.apply(query, []interface{}{userID, userID}, page)
 	if err != nil {
-		return nil, err
+		return nil, PageInfo{}, err
+	}
+
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return nil, PageInfo{}, err
 	}
 	defer rows.Close()
 
 	var followers []UserFollower
+	var keys []utils.Cursor
 	for rows.Next() {
 		var user UserFollower
 		err := rows.Scan(
@@ -508,41 +525,54 @@ func GetUserFollowers(userID int) ([]UserFollower, error) {
 			&user.Followers,
 			&user.Questions,
 			&user.Answers,
+			&user.IsMutual,
 			&user.CreatedAt,
 		)
 		if err != nil {
-			return nil, err
+			return nil, PageInfo{}, err
 		}
 		followers = append(followers, user)
+		keys = append(keys, utils.Cursor{CreatedAt: user.CreatedAt, ID: user.ID})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, PageInfo{}, err
 	}
 
-	return followers, nil
+	followers, info := finishPage(followers, keys, followKeyset, page)
+	return followers, info, nil
 }
 
 // 获取用户消息
-func GetUserMessages(userID int) ([]UserMessage, error) {
+func GetUserMessages(userID int, page PageQuery) ([]UserMessage, PageInfo, error) {
 	query := `
 		SELECT 
 			id,
 			type,
 			title,
-			content,
-			sender,
+			IFNULL(content, ''),
+			IFNULL(sender, ''),
+			IFNULL(link, ''),
+			actor_count,
 			is_read,
 			created_at
 		FROM messages 
 		WHERE user_id = ?
-		ORDER BY created_at DESC
-		LIMIT 50
 	`
 
-	rows, err := DB.Query(query, userID)
+	query, args, err := userMessageKeyset.apply(query, []interface{}{userID}, page)
 	if err != nil {
-		return nil, err
+		return nil, PageInfo{}, err
+	}
+
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return nil, PageInfo{}, err
 	}
 	defer rows.Close()
 
 	var messages []UserMessage
+	var keys []utils.Cursor
 	for rows.Next() {
 		var message UserMessage
 		err := rows.Scan(
@@ -551,16 +581,48 @@ func GetUserMessages(userID int) ([]UserMessage, error) {
 			&message.Title,
 			&message.Content,
 			&message.Sender,
+			&message.Link,
+			&message.ActorCount,
 			&message.IsRead,
 			&message.CreatedAt,
 		)
 		if err != nil {
-			return nil, err
+			return nil, PageInfo{}, err
 		}
 		messages = append(messages, message)
+		keys = append(keys, utils.Cursor{CreatedAt: message.CreatedAt, ID: message.ID})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, PageInfo{}, err
+	}
+
+	messages, info := finishPage(messages, keys, userMessageKeyset, page)
+	return messages, info, nil
+}
+
+// 获取单条消息
+func GetUserMessage(userID, messageID int) (*UserMessage, error) {
+	message := &UserMessage{}
+	err := DB.QueryRow(`
+		SELECT id, type, title, IFNULL(content, ''), IFNULL(sender, ''), IFNULL(link, ''), actor_count, is_read, created_at
+		FROM messages
+		WHERE id = ? AND user_id = ?
+	`, messageID, userID).Scan(
+		&message.ID, &message.Type, &message.Title, &message.Content, &message.Sender,
+		&message.Link, &message.ActorCount, &message.IsRead, &message.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
 	}
+	return message, nil
+}
 
-	return messages, nil
+// 获取未读消息数
+func GetUnreadMessageCount(userID int) (int, error) {
+	var count int
+	err := DB.QueryRow("SELECT COUNT(*) FROM messages WHERE user_id = ? AND is_read = 0", userID).Scan(&count)
+	return count, err
 }
 
 // 更新用户头像
@@ -619,70 +681,61 @@ func UpdateNotificationSettings(userID int, emailNotifications, browserNotificat
 	return err
 }
 
+// 更新@提及通知开关
+func UpdateMentionNotifications(userID int, enabled bool) error {
+	_, err := DB.Exec("UPDATE users SET mention_notifications = ? WHERE id = ?", enabled, userID)
+	return err
+}
+
 // 标记所有消息为已读
 func MarkAllMessagesRead(userID int) error {
 	_, err := DB.Exec("UPDATE messages SET is_read = 1 WHERE user_id = ?", userID)
-	return err
+	if err != nil {
+		return err
+	}
+
+	PublishUnreadCount(userID)
+	return nil
 }
 
 // 标记单条消息为已读
 func MarkMessageRead(userID, messageID int) error {
 	_, err := DB.Exec("UPDATE messages SET is_read = 1 WHERE id = ? AND user_id = ?", messageID, userID)
-	return err
+	if err != nil {
+		return err
+	}
+
+	PublishUnreadCount(userID)
+	return nil
 }
 
 // 删除消息
 func DeleteMessage(userID, messageID int) error {
 	_, err := DB.Exec("DELETE FROM messages WHERE id = ? AND user_id = ?", messageID, userID)
-	return err
-}
-
-// 关注用户
-func FollowUser(followerID, followedID int) error {
-	// 检查是否已经关注
-	var exists int
-	err := DB.QueryRow("SELECT 1 FROM follows WHERE follower_id = ? AND followed_id = ?", followerID, followedID).Scan(&exists)
-	if err == nil {
-		return nil // 已经关注了
+	if err != nil {
+		return err
 	}
 
-	_, err = DB.Exec("INSERT INTO follows (follower_id, followed_id, created_at) VALUES (?, ?, ?)",
-		followerID, followedID, time.Now())
-	return err
-}
-
-// 取消关注用户
-func UnfollowUser(followerID, followedID int) error {
-	_, err := DB.Exec("DELETE FROM follows WHERE follower_id = ? AND followed_id = ?", followerID, followedID)
-	return err
+	PublishUnreadCount(userID)
+	return nil
 }
 
-// 取消收藏
-func RemoveFavorite(userID, favoriteID int) error {
-	_, err := DB.Exec("DELETE FROM favorites WHERE id = ? AND user_id = ?", favoriteID, userID)
-	return err
-}
-
-// 删除用户提问
+// 删除用户提问（移入回收站）
 func DeleteUserQuestion(userID, questionID int) error {
-	_, err := DB.Exec("DELETE FROM questions WHERE id = ? AND user_id = ?", questionID, userID)
-	return err
+	return TrashContent(userID, ContentTypeQuestion, questionID)
 }
 
-// 删除用户回答
+// 删除用户回答（移入回收站）
 func DeleteUserAnswer(userID, answerID int) error {
-	_, err := DB.Exec("DELETE FROM answers WHERE id = ? AND user_id = ?", answerID, userID)
-	return err
+	return TrashContent(userID, ContentTypeAnswer, answerID)
 }
 
-// 删除用户分享
+// 删除用户分享（移入回收站）
 func DeleteUserShare(userID, shareID int) error {
-	_, err := DB.Exec("DELETE FROM tech_articles WHERE id = ? AND user_id = ?", shareID, userID)
-	return err
+	return TrashContent(userID, ContentTypeArticle, shareID)
 }
 
-// 删除用户资料
+// 删除用户资料（移入回收站）
 func DeleteUserResource(userID, resourceID int) error {
-	_, err := DB.Exec("DELETE FROM learning_resources WHERE id = ? AND user_id = ?", resourceID, userID)
-	return err
-} 
\ No newline at end of file
+	return TrashContent(userID, ContentTypeResource, resourceID)
+}
@@ -0,0 +1,560 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"aiforum/config"
+)
+
+// 可订阅的事件
+const (
+	WebhookQuestionCreated  = "question.created"
+	WebhookAnswerAccepted   = "answer.accepted"
+	WebhookArticlePublished = "article.published"
+	WebhookResourceUploaded = "resource.uploaded"
+)
+
+// 测试投递使用的事件，不能订阅
+const WebhookPing = "ping"
+
+// 所有可订阅的事件
+var WebhookEvents = []string{WebhookQuestionCreated, WebhookAnswerAccepted, WebhookArticlePublished, WebhookResourceUploaded}
+
+// 投递状态
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySending   = "sending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// 单次投递最多尝试次数
+const WebhookMaxAttempts = 8
+
+// 投递中状态的租约时间（进程崩溃后到期重新投递）
+const webhookSendingLease = 5 * time.Minute
+
+// 每个用户（全站订阅合计）最多创建的订阅数量
+const maxWebhooksPerOwner = 20
+
+// 投递记录保留时间
+const webhookDeliveryRetention = 30 * 24 * time.Hour
+
+var (
+	ErrInvalidWebhookURL    = errors.New("请填写以http://或https://开头的有效地址")
+	ErrInvalidWebhookEvents = errors.New("请至少选择一个有效的事件")
+	ErrTooManyWebhooks      = errors.New("订阅数量已达上限，请先删除不用的订阅")
+)
+
+// Webhook订阅，UserID为0表示管理员创建的全站订阅
+type Webhook struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"-"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"-"`
+	Events    []string  `json:"events"`
+	Tags      []string  `json:"tags"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// 投递记录
+type WebhookDelivery struct {
+	ID             int             `json:"id"`
+	WebhookID      int             `json:"webhook_id"`
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	ResponseStatus *int            `json:"response_status"`
+	LastError      string          `json:"last_error,omitempty"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+	CreatedAt      time.Time       `json:"created_at"`
+
+	// 投递时使用的订阅信息
+	URL     string `json:"-"`
+	Secret  string `json:"-"`
+	OwnerID int    `json:"-"`
+}
+
+// 论坛事件
+type WebhookEvent struct {
+	Name        string
+	ActorID     int
+	ContentType string // 用于按标签过滤的内容（回答被采纳时为所属问题）
+	ContentID   int
+	Data        map[string]interface{}
+}
+
+// 事件消息体
+type webhookPayload struct {
+	Event     string                 `json:"event"`
+	CreatedAt time.Time              `json:"created_at"`
+	Actor     *webhookActor          `json:"actor,omitempty"`
+	Tags      []string               `json:"tags"`
+	Data      map[string]interface{} `json:"data"`
+}
+
+type webhookActor struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+}
+
+// 全站订阅的user_id为NULL
+func webhookOwner(userID int) interface{} {
+	if userID == 0 {
+		return nil
+	}
+	return userID
+}
+
+// 检查订阅地址是否有效
+func ValidWebhookURL(rawURL string) bool {
+	if len(rawURL) > 500 {
+		return false
+	}
+	u, err := url.Parse(rawURL)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && u.User == nil
+}
+
+// 检查订阅的事件是否有效
+func ValidWebhookEvents(events []string) bool {
+	if len(events) == 0 {
+		return false
+	}
+	for _, event := range events {
+		valid := false
+		for _, e := range WebhookEvents {
+			if event == e {
+				valid = true
+			}
+		}
+		if !valid {
+			return false
+		}
+	}
+	return true
+}
+
+// 规范化标签过滤条件，同义词换成主标签
+func normalizeWebhookTags(tags []string) []string {
+	names := ParseTagNames(strings.Join(tags, ","))
+	for i, name := range names {
+		if _, canonical, err := lookupTag(DB, name); err == nil {
+			names[i] = canonical
+		}
+	}
+	if names == nil {
+		names = []string{}
+	}
+	return names
+}
+
+func validateWebhook(rawURL string, events []string) error {
+	if !ValidWebhookURL(rawURL) {
+		return ErrInvalidWebhookURL
+	}
+	if !ValidWebhookEvents(events) {
+		return ErrInvalidWebhookEvents
+	}
+	return nil
+}
+
+// 创建订阅，tags为空表示不按标签过滤
+func CreateWebhook(userID int, rawURL, secret string, events, tags []string) (*Webhook, error) {
+	if err := validateWebhook(rawURL, events); err != nil {
+		return nil, err
+	}
+
+	var count int
+	if err := DB.QueryRow("SELECT COUNT(*) FROM webhooks WHERE user_id <=> ?", webhookOwner(userID)).Scan(&count); err != nil {
+		return nil, err
+	}
+	if count >= maxWebhooksPerOwner {
+		return nil, ErrTooManyWebhooks
+	}
+
+	tags = normalizeWebhookTags(tags)
+	result, err := DB.Exec(`
+		INSERT INTO webhooks (user_id, url, secret, events, tags) VALUES (?, ?, ?, ?, ?)
+	`, webhookOwner(userID), rawURL, secret, strings.Join(events, ","), strings.Join(tags, ","))
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Webhook{
+		ID:        int(id),
+		UserID:    userID,
+		URL:       rawURL,
+		Secret:    secret,
+		Events:    events,
+		Tags:      tags,
+		Active:    true,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+func scanWebhook(row interface{ Scan(...interface{}) error }) (*Webhook, error) {
+	hook := &Webhook{}
+	var events, tags string
+	if err := row.Scan(&hook.ID, &hook.UserID, &hook.URL, &hook.Secret, &events, &tags, &hook.Active, &hook.CreatedAt); err != nil {
+		return nil, err
+	}
+	hook.Events = strings.Split(events, ",")
+	hook.Tags = []string{}
+	if tags != "" {
+		hook.Tags = strings.Split(tags, ",")
+	}
+	return hook, nil
+}
+
+const webhookColumns = "id, IFNULL(user_id, 0), url, secret, events, tags, active, created_at"
+
+// 获取用户的订阅（userID为0时获取全站订阅）
+func GetWebhooks(userID int) ([]*Webhook, error) {
+	rows, err := DB.Query("SELECT "+webhookColumns+" FROM webhooks WHERE user_id <=> ? ORDER BY created_at DESC",
+		webhookOwner(userID))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hooks := []*Webhook{}
+	for rows.Next() {
+		hook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		hooks = append(hooks, hook)
+	}
+	return hooks, rows.Err()
+}
+
+// 获取用户的单个订阅，不属于该用户时返回sql.ErrNoRows
+func GetWebhook(userID, webhookID int) (*Webhook, error) {
+	return scanWebhook(DB.QueryRow("SELECT "+webhookColumns+" FROM webhooks WHERE id = ? AND user_id <=> ?",
+		webhookID, webhookOwner(userID)))
+}
+
+// 修改订阅
+func UpdateWebhook(userID, webhookID int, rawURL string, events, tags []string, active bool) (*Webhook, error) {
+	if err := validateWebhook(rawURL, events); err != nil {
+		return nil, err
+	}
+
+	tags = normalizeWebhookTags(tags)
+	_, err := DB.Exec(`
+		UPDATE webhooks SET url = ?, events = ?, tags = ?, active = ? WHERE id = ? AND user_id <=> ?
+	`, rawURL, strings.Join(events, ","), strings.Join(tags, ","), active, webhookID, webhookOwner(userID))
+	if err != nil {
+		return nil, err
+	}
+	// 内容没有变化时影响行数也为0，因此重新查询来确认订阅存在
+	return GetWebhook(userID, webhookID)
+}
+
+// 删除订阅（投递记录一并删除）
+func DeleteWebhook(userID, webhookID int) error {
+	result, err := DB.Exec("DELETE FROM webhooks WHERE id = ? AND user_id <=> ?", webhookID, webhookOwner(userID))
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+const webhookDeliveryColumns = `
+	d.id, d.webhook_id, d.event, d.payload, d.status, d.attempts, d.response_status, IFNULL(d.last_error, ''),
+	d.next_attempt_at, d.delivered_at, d.created_at, w.url, w.secret, IFNULL(w.user_id, 0)`
+
+func scanWebhookDelivery(row interface{ Scan(...interface{}) error }) (*WebhookDelivery, error) {
+	d := &WebhookDelivery{}
+	var payload string
+	var responseStatus sql.NullInt64
+	var deliveredAt sql.NullTime
+	err := row.Scan(&d.ID, &d.WebhookID, &d.Event, &payload, &d.Status, &d.Attempts, &responseStatus, &d.LastError,
+		&d.NextAttemptAt, &deliveredAt, &d.CreatedAt, &d.URL, &d.Secret, &d.OwnerID)
+	if err != nil {
+		return nil, err
+	}
+	d.Payload = json.RawMessage(payload)
+	if responseStatus.Valid {
+		status := int(responseStatus.Int64)
+		d.ResponseStatus = &status
+	}
+	if deliveredAt.Valid {
+		d.DeliveredAt = &deliveredAt.Time
+	}
+	return d, nil
+}
+
+// 获取订阅最近的投递记录
+func GetWebhookDeliveries(webhookID, limit int) ([]*WebhookDelivery, error) {
+	rows, err := DB.Query(`
+		SELECT `+webhookDeliveryColumns+`
+		FROM webhook_deliveries d
+		JOIN webhooks w ON d.webhook_id = w.id
+		WHERE d.webhook_id = ?
+		ORDER BY d.id DESC
+		LIMIT ?
+	`, webhookID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []*WebhookDelivery{}
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// 获取单条投递记录
+func GetWebhookDelivery(id int) (*WebhookDelivery, error) {
+	return scanWebhookDelivery(DB.QueryRow(`
+		SELECT `+webhookDeliveryColumns+`
+		FROM webhook_deliveries d
+		JOIN webhooks w ON d.webhook_id = w.id
+		WHERE d.id = ?
+	`, id))
+}
+
+// 创建测试投递。投递记录直接处于投递中状态，由调用方立即发送，后台任务不会重复领取
+func CreateWebhookPing(hook *Webhook) (*WebhookDelivery, error) {
+	payload, err := json.Marshal(webhookPayload{
+		Event:     WebhookPing,
+		CreatedAt: time.Now(),
+		Tags:      []string{},
+		Data: map[string]interface{}{
+			"webhook_id": hook.ID,
+			"message":    "这是一条测试消息",
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := DB.Exec(`
+		INSERT INTO webhook_deliveries (webhook_id, event, payload, status, next_attempt_at) VALUES (?, ?, ?, ?, ?)
+	`, hook.ID, WebhookPing, string(payload), WebhookDeliverySending, time.Now().Add(webhookSendingLease))
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return GetWebhookDelivery(int(id))
+}
+
+// 触发论坛事件，为订阅了该事件的Webhook生成投递记录。
+// 失败只记录日志，不影响发布内容本身
+func DispatchWebhookEvent(event WebhookEvent) {
+	if err := dispatchWebhookEvent(event); err != nil {
+		log.Printf("生成Webhook投递失败 (event=%s, id=%d): %v", event.Name, event.ContentID, err)
+	}
+}
+
+func dispatchWebhookEvent(event WebhookEvent) error {
+	rows, err := DB.Query("SELECT "+webhookColumns+" FROM webhooks WHERE active = 1 AND FIND_IN_SET(?, events) > 0",
+		event.Name)
+	if err != nil {
+		return err
+	}
+	var hooks []*Webhook
+	for rows.Next() {
+		hook, err := scanWebhook(rows)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		hooks = append(hooks, hook)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil || len(hooks) == 0 {
+		return err
+	}
+
+	tags, err := contentTagNames(event.ContentType, event.ContentID)
+	if err != nil {
+		return err
+	}
+
+	payload := webhookPayload{
+		Event:     event.Name,
+		CreatedAt: time.Now(),
+		Tags:      tags,
+		Data:      event.Data,
+	}
+	if actor, err := GetUserByID(event.ActorID); err == nil {
+		payload.Actor = &webhookActor{ID: actor.ID, Username: actor.Username}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	for _, hook := range hooks {
+		if !webhookMatchesTags(hook.Tags, tags) {
+			continue
+		}
+		_, err := DB.Exec("INSERT INTO webhook_deliveries (webhook_id, event, payload) VALUES (?, ?, ?)",
+			hook.ID, event.Name, string(body))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 订阅没有设置标签，或内容带有任一订阅的标签
+func webhookMatchesTags(filter, tags []string) bool {
+	if len(filter) == 0 {
+		return true
+	}
+	for _, want := range filter {
+		for _, tag := range tags {
+			if strings.EqualFold(want, tag) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// 内容的规范标签名称
+func contentTagNames(contentType string, contentID int) ([]string, error) {
+	rows, err := DB.Query(`
+		SELECT t.name FROM content_tags ct
+		JOIN tags t ON ct.tag_id = t.id
+		WHERE ct.content_type = ? AND ct.content_id = ?
+		ORDER BY ct.id ASC
+	`, contentType, contentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// 站内链接转为完整地址
+func siteURL(path string) string {
+	return config.AppConfig.SiteURL + path
+}
+
+// 领取待投递的记录（领取后在租约时间内不会被重复领取）
+func ClaimWebhookDeliveries(limit int) ([]*WebhookDelivery, error) {
+	rows, err := DB.Query(`
+		SELECT `+webhookDeliveryColumns+`
+		FROM webhook_deliveries d
+		JOIN webhooks w ON d.webhook_id = w.id
+		WHERE d.status IN (?, ?) AND d.next_attempt_at <= NOW()
+		ORDER BY d.id
+		LIMIT ?
+	`, WebhookDeliveryPending, WebhookDeliverySending, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []*WebhookDelivery
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		candidates = append(candidates, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var deliveries []*WebhookDelivery
+	for _, d := range candidates {
+		result, err := DB.Exec(`
+			UPDATE webhook_deliveries SET status = ?, next_attempt_at = ?
+			WHERE id = ? AND status IN (?, ?) AND next_attempt_at <= NOW()
+		`, WebhookDeliverySending, time.Now().Add(webhookSendingLease), d.ID, WebhookDeliveryPending, WebhookDeliverySending)
+		if err != nil {
+			return nil, err
+		}
+		if affected, _ := result.RowsAffected(); affected == 1 {
+			deliveries = append(deliveries, d)
+		}
+	}
+	return deliveries, nil
+}
+
+// 标记投递成功
+func MarkWebhookDelivered(id, responseStatus int) error {
+	_, err := DB.Exec(`
+		UPDATE webhook_deliveries SET status = ?, attempts = attempts + 1, response_status = ?, last_error = NULL, delivered_at = NOW()
+		WHERE id = ?
+	`, WebhookDeliveryDelivered, responseStatus, id)
+	return err
+}
+
+// 记录投递失败，未超过重试次数时按指数退避重新排队。responseStatus为0表示没有收到响应
+func MarkWebhookFailed(d *WebhookDelivery, responseStatus int, sendErr error) error {
+	attempts := d.Attempts + 1
+	status := WebhookDeliveryPending
+	if attempts >= WebhookMaxAttempts {
+		status = WebhookDeliveryFailed
+	}
+
+	message := sendErr.Error()
+	if len(message) > 500 {
+		message = message[:500]
+	}
+
+	var code interface{}
+	if responseStatus > 0 {
+		code = responseStatus
+	}
+
+	// 第n次失败后等待2^(n-1)分钟
+	next := time.Now().Add(time.Duration(1<<(attempts-1)) * time.Minute)
+	_, err := DB.Exec(`
+		UPDATE webhook_deliveries SET status = ?, attempts = ?, response_status = ?, last_error = ?, next_attempt_at = ?
+		WHERE id = ?
+	`, status, attempts, code, message, next, d.ID)
+	return err
+}
+
+// 删除超过保留时间的投递记录（未完成的保留）
+func PurgeWebhookDeliveries() (int64, error) {
+	result, err := DB.Exec("DELETE FROM webhook_deliveries WHERE status IN (?, ?) AND created_at < ?",
+		WebhookDeliveryDelivered, WebhookDeliveryFailed, time.Now().Add(-webhookDeliveryRetention))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
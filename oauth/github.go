@@ -0,0 +1,91 @@
+package oauth
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+)
+
+// GitHub登录（GitHub不支持OIDC，身份信息通过REST API获取）
+type GitHubProvider struct {
+	clientID     string
+	clientSecret string
+	redirectURL  string
+
+	// 可以改为GitHub Enterprise的地址
+	AuthURL  string
+	TokenURL string
+	APIURL   string
+}
+
+// 创建GitHub登录方式
+func NewGitHubProvider(clientID, clientSecret, redirectURL string) *GitHubProvider {
+	return &GitHubProvider{
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		redirectURL:  redirectURL,
+		AuthURL:      "https://github.com/login/oauth/authorize",
+		TokenURL:     "https://github.com/login/oauth/access_token",
+		APIURL:       "https://api.github.com",
+	}
+}
+
+func (p *GitHubProvider) Name() string        { return "github" }
+func (p *GitHubProvider) DisplayName() string { return "GitHub" }
+
+// 授权页面地址（GitHub不使用nonce）
+func (p *GitHubProvider) AuthCodeURL(state, nonce, verifier string) (string, error) {
+	return authURL(p.AuthURL, url.Values{
+		"client_id":             {p.clientID},
+		"redirect_uri":          {p.redirectURL},
+		"scope":                 {"read:user user:email"},
+		"state":                 {state},
+		"code_challenge":        {codeChallenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}), nil
+}
+
+// 用授权码换取令牌并获取用户信息和主邮箱
+func (p *GitHubProvider) Exchange(ctx context.Context, code, nonce, verifier string) (*Identity, error) {
+	token, err := exchangeCode(ctx, p.TokenURL, p.clientID, p.clientSecret, p.redirectURL, code, verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	var user struct {
+		ID        int64  `json:"id"`
+		Login     string `json:"login"`
+		Name      string `json:"name"`
+		Email     string `json:"email"`
+		AvatarURL string `json:"avatar_url"`
+	}
+	if err := getJSON(ctx, p.APIURL+"/user", token.AccessToken, &user); err != nil {
+		return nil, err
+	}
+
+	identity := &Identity{
+		Provider:  p.Name(),
+		Subject:   strconv.FormatInt(user.ID, 10),
+		Email:     user.Email,
+		Name:      user.Name,
+		Username:  user.Login,
+		AvatarURL: user.AvatarURL,
+	}
+
+	// 公开邮箱可能未验证或为空，以已验证的主邮箱为准
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := getJSON(ctx, p.APIURL+"/user/emails", token.AccessToken, &emails); err == nil {
+		for _, e := range emails {
+			if e.Primary && e.Verified {
+				identity.Email = e.Email
+				identity.EmailVerified = true
+				break
+			}
+		}
+	}
+	return identity, nil
+}
@@ -0,0 +1,198 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"aiforum/config"
+)
+
+// 请求第三方服务的超时时间
+const requestTimeout = 10 * time.Second
+
+var (
+	ErrUnknownProvider = errors.New("不支持的登录方式")
+	ErrInvalidIDToken  = errors.New("无效的身份令牌")
+)
+
+var httpClient = &http.Client{Timeout: requestTimeout}
+
+// 第三方账号的身份信息
+type Identity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Username      string
+	AvatarURL     string
+}
+
+// 第三方登录方式
+type Provider interface {
+	// 路由中使用的标识，如github
+	Name() string
+	// 登录按钮上显示的名称
+	DisplayName() string
+	// 跳转到第三方授权页面的地址
+	AuthCodeURL(state, nonce, verifier string) (string, error)
+	// 用授权码换取身份信息
+	Exchange(ctx context.Context, code, nonce, verifier string) (*Identity, error)
+}
+
+var (
+	providersMu sync.RWMutex
+	providers   = map[string]Provider{}
+)
+
+// 根据配置注册启用的登录方式
+func Init() {
+	cfg := config.AppConfig
+	if cfg.OIDCIssuer != "" && cfg.OIDCClientID != "" {
+		Register(NewOIDCProvider("oidc", cfg.OIDCDisplayName, cfg.OIDCIssuer,
+			cfg.OIDCClientID, cfg.OIDCClientSecret, RedirectURL("oidc")))
+	}
+	if cfg.GitHubClientID != "" {
+		Register(NewGitHubProvider(cfg.GitHubClientID, cfg.GitHubClientSecret, RedirectURL("github")))
+	}
+}
+
+// 注册登录方式，同名的会被替换
+func Register(p Provider) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers[p.Name()] = p
+}
+
+// 获取登录方式
+func Get(name string) (Provider, error) {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	p, ok := providers[name]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	return p, nil
+}
+
+// 获取所有启用的登录方式，按名称排序
+func Providers() []Provider {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	list := make([]Provider, 0, len(providers))
+	for _, p := range providers {
+		list = append(list, p)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name() < list[j].Name() })
+	return list
+}
+
+// 授权完成后的回调地址
+func RedirectURL(name string) string {
+	return strings.TrimRight(config.AppConfig.SiteURL, "/") + "/auth/oauth/" + name + "/callback"
+}
+
+// 生成随机字符串，用于state、nonce和PKCE verifier
+func RandomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// PKCE的S256 challenge
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// 拼接授权页面地址
+func authURL(endpoint string, params url.Values) string {
+	if strings.Contains(endpoint, "?") {
+		return endpoint + "&" + params.Encode()
+	}
+	return endpoint + "?" + params.Encode()
+}
+
+// 令牌接口的响应
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// 用授权码换取令牌
+func exchangeCode(ctx context.Context, endpoint, clientID, clientSecret, redirectURL, code, verifier string) (*tokenResponse, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURL},
+		"client_id":     {clientID},
+		"client_secret": {clientSecret},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	token := &tokenResponse{}
+	if err := doJSON(req, token); err != nil && token.Error == "" {
+		return nil, err
+	}
+	if token.Error != "" {
+		return nil, fmt.Errorf("授权码无效: %s %s", token.Error, token.ErrorDescription)
+	}
+	if token.AccessToken == "" {
+		return nil, errors.New("令牌响应中缺少access_token")
+	}
+	return token, nil
+}
+
+// 发起GET请求并解析JSON响应
+func getJSON(ctx context.Context, endpoint, accessToken string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	return doJSON(req, v)
+}
+
+// 发送请求并解析JSON响应，非2xx状态码也会尝试解析以便读取错误信息
+func doJSON(req *http.Request, v interface{}) error {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	decodeErr := json.Unmarshal(body, v)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s 返回 %d", req.URL.Host, resp.StatusCode)
+	}
+	return decodeErr
+}
@@ -11,29 +11,58 @@ CREATE TABLE IF NOT EXISTS follows (
     UNIQUE(follower_id, followed_id)
 );
 
+-- 收藏夹表
+CREATE TABLE IF NOT EXISTS bookmark_collections (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    is_public BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE(user_id, name)
+);
+
 -- 用户收藏表
-CREATE TABLE IF NOT EXISTS favorites (
+CREATE TABLE IF NOT EXISTS bookmarks (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     user_id INTEGER NOT NULL,
-    type VARCHAR(20) NOT NULL, -- question, share, resource
-    target_id INTEGER NOT NULL,
+    content_type VARCHAR(20) NOT NULL, -- question, post, article, resource
+    content_id INTEGER NOT NULL,
+    collection_id INTEGER,
+    note TEXT,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (collection_id) REFERENCES bookmark_collections(id) ON DELETE SET NULL,
+    UNIQUE(user_id, content_type, content_id)
 );
 
 -- 用户消息表
 CREATE TABLE IF NOT EXISTS messages (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     user_id INTEGER NOT NULL,
-    type VARCHAR(20) NOT NULL, -- system, question, follow, comment
+    type VARCHAR(20) NOT NULL, -- system, answer, accept, comment, reply, like, follow, mention, moderation
     title VARCHAR(255) NOT NULL,
     content TEXT,
     sender VARCHAR(100),
+    link VARCHAR(255),
+    group_key VARCHAR(100),
+    actor_count INTEGER DEFAULT 1,
     is_read BOOLEAN DEFAULT FALSE,
+    emailed BOOLEAN DEFAULT FALSE,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
 );
 
+-- 合并通知的触发者记录
+CREATE TABLE IF NOT EXISTS message_actors (
+    message_id INTEGER NOT NULL,
+    actor_id INTEGER NOT NULL,
+    PRIMARY KEY (message_id, actor_id),
+    FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE
+);
+
 -- 扩展用户表，添加个人中心相关字段
 ALTER TABLE users ADD COLUMN bio TEXT;
 ALTER TABLE users ADD COLUMN phone VARCHAR(20);
@@ -45,14 +74,24 @@ ALTER TABLE users ADD COLUMN email_notifications BOOLEAN DEFAULT TRUE;
 ALTER TABLE users ADD COLUMN browser_notifications BOOLEAN DEFAULT TRUE;
 ALTER TABLE users ADD COLUMN question_notifications BOOLEAN DEFAULT TRUE;
 ALTER TABLE users ADD COLUMN follow_notifications BOOLEAN DEFAULT TRUE;
+ALTER TABLE users ADD COLUMN mention_notifications BOOLEAN DEFAULT TRUE;
+ALTER TABLE users ADD COLUMN email_digest VARCHAR(10) NOT NULL DEFAULT 'off'; -- immediate, daily, weekly, off
+ALTER TABLE users ADD COLUMN last_digest_at DATETIME;
+ALTER TABLE users ADD COLUMN follower_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN following_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN deletion_scheduled_at DATETIME;
+ALTER TABLE users ADD COLUMN totp_secret VARCHAR(64);
+ALTER TABLE users ADD COLUMN totp_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN totp_last_step INTEGER NOT NULL DEFAULT 0;
 
 -- 创建索引以提高查询性能
 CREATE INDEX IF NOT EXISTS idx_follows_follower ON follows(follower_id);
 CREATE INDEX IF NOT EXISTS idx_follows_followed ON follows(followed_id);
-CREATE INDEX IF NOT EXISTS idx_favorites_user ON favorites(user_id);
-CREATE INDEX IF NOT EXISTS idx_favorites_type_target ON favorites(type, target_id);
+CREATE INDEX IF NOT EXISTS idx_bookmarks_collection ON bookmarks(collection_id);
+CREATE INDEX IF NOT EXISTS idx_bookmarks_content ON bookmarks(content_type, content_id);
 CREATE INDEX IF NOT EXISTS idx_messages_user ON messages(user_id);
 CREATE INDEX IF NOT EXISTS idx_messages_read ON messages(user_id, is_read);
+CREATE INDEX IF NOT EXISTS idx_messages_group ON messages(user_id, group_key, is_read);
 CREATE INDEX IF NOT EXISTS idx_questions_user ON questions(user_id);
 CREATE INDEX IF NOT EXISTS idx_answers_user ON answers(user_id);
 CREATE INDEX IF NOT EXISTS idx_tech_articles_user ON tech_articles(user_id);
@@ -62,8 +101,8 @@ CREATE INDEX IF NOT EXISTS idx_learning_resources_user ON learning_resources(use
 INSERT OR IGNORE INTO follows (follower_id, followed_id) VALUES (1, 2);
 INSERT OR IGNORE INTO follows (follower_id, followed_id) VALUES (2, 1);
 
-INSERT OR IGNORE INTO favorites (user_id, type, target_id) VALUES (1, 'question', 1);
-INSERT OR IGNORE INTO favorites (user_id, type, target_id) VALUES (1, 'share', 1);
+INSERT OR IGNORE INTO bookmarks (user_id, content_type, content_id) VALUES (1, 'question', 1);
+INSERT OR IGNORE INTO bookmarks (user_id, content_type, content_id) VALUES (1, 'article', 1);
 
 INSERT OR IGNORE INTO messages (user_id, type, title, content, sender) VALUES 
 (1, 'system', '欢迎加入AI论坛', '感谢您注册AI论坛，开始您的学习之旅吧！', '系统'),
@@ -103,20 +103,23 @@ function filterActivity(filter) {
 }
 
 // 加载用户动态
-function loadUserActivity(filter = 'all') {
+function loadUserActivity(filter = 'all', cursor = '') {
     const activityList = document.getElementById('activityList');
     if (!activityList) return;
     
     // 显示加载状态
-    activityList.innerHTML = '<div class="loading">加载中...</div>';
+    if (!cursor) {
+        activityList.innerHTML = '<div class="loading">加载中...</div>';
+    }
     
     // 模拟API调用
-    fetch(`/api/user/activity?filter=${filter}`)
+    fetch(withCursor(`/api/user/activity?filter=${filter}`, cursor))
         .then(response => response.json())
         .then(data => {
             if (data.success) {
-                renderActivityList(data.activities);
-            } else {
+                renderActivityList(data.activities, Boolean(cursor));
+                renderLoadMore(activityList, data.next_cursor, () => loadUserActivity(filter, data.next_cursor));
+            } else if (!cursor) {
                 showEmptyState(activityList, '暂无动态', '您还没有任何活动记录');
             }
         })
@@ -127,11 +130,13 @@ function loadUserActivity(filter = 'all') {
 }
 
 // 渲染动态列表
-function renderActivityList(activities) {
+function renderActivityList(activities, append = false) {
     const activityList = document.getElementById('activityList');
     
     if (!activities || activities.length === 0) {
-        showEmptyState(activityList, '暂无动态', '您还没有任何活动记录');
+        if (!append) {
+            showEmptyState(activityList, '暂无动态', '您还没有任何活动记录');
+        }
         return;
     }
     
@@ -150,7 +155,11 @@ function renderActivityList(activities) {
         </div>
     `).join('');
     
-    activityList.innerHTML = html;
+    if (append) {
+        activityList.insertAdjacentHTML('beforeend', html);
+    } else {
+        activityList.innerHTML = html;
+    }
 }
 
 // 获取活动类型文本
@@ -166,18 +175,21 @@ function getActivityTypeText(type) {
 }
 
 // 加载用户提问
-function loadUserQuestions() {
+function loadUserQuestions(cursor = '') {
     const questionsList = document.getElementById('questionsList');
     if (!questionsList) return;
     
-    questionsList.innerHTML = '<div class="loading">加载中...</div>';
+    if (!cursor) {
+        questionsList.innerHTML = '<div class="loading">加载中...</div>';
+    }
     
-    fetch('/api/user/questions')
+    fetch(withCursor('/api/user/questions', cursor))
         .then(response => response.json())
         .then(data => {
             if (data.success) {
-                renderQuestionsList(data.questions);
-            } else {
+                renderQuestionsList(data.questions, Boolean(cursor));
+                renderLoadMore(questionsList, data.next_cursor, () => loadUserQuestions(data.next_cursor));
+            } else if (!cursor) {
                 showEmptyState(questionsList, '暂无提问', '您还没有发布过问题');
             }
         })
@@ -188,11 +200,13 @@ function loadUserQuestions() {
 }
 
 // 渲染提问列表
-function renderQuestionsList(questions) {
+function renderQuestionsList(questions, append = false) {
     const questionsList = document.getElementById('questionsList');
     
     if (!questions || questions.length === 0) {
-        showEmptyState(questionsList, '暂无提问', '您还没有发布过问题');
+        if (!append) {
+            showEmptyState(questionsList, '暂无提问', '您还没有发布过问题');
+        }
         return;
     }
     
@@ -219,7 +233,11 @@ function renderQuestionsList(questions) {
         </div>
     `).join('');
     
-    questionsList.innerHTML = html;
+    if (append) {
+        questionsList.insertAdjacentHTML('beforeend', html);
+    } else {
+        questionsList.innerHTML = html;
+    }
 }
 
 // 获取问题状态文本
@@ -233,18 +251,21 @@ function getQuestionStatusText(status) {
 }
 
 // 加载用户回答
-function loadUserAnswers() {
+function loadUserAnswers(cursor = '') {
     const answersList = document.getElementById('answersList');
     if (!answersList) return;
     
-    answersList.innerHTML = '<div class="loading">加载中...</div>';
+    if (!cursor) {
+        answersList.innerHTML = '<div class="loading">加载中...</div>';
+    }
     
-    fetch('/api/user/answers')
+    fetch(withCursor('/api/user/answers', cursor))
         .then(response => response.json())
         .then(data => {
             if (data.success) {
-                renderAnswersList(data.answers);
-            } else {
+                renderAnswersList(data.answers, Boolean(cursor));
+                renderLoadMore(answersList, data.next_cursor, () => loadUserAnswers(data.next_cursor));
+            } else if (!cursor) {
                 showEmptyState(answersList, '暂无回答', '您还没有回答过问题');
             }
         })
@@ -255,11 +276,13 @@ function loadUserAnswers() {
 }
 
 // 渲染回答列表
-function renderAnswersList(answers) {
+function renderAnswersList(answers, append = false) {
     const answersList = document.getElementById('answersList');
     
     if (!answers || answers.length === 0) {
-        showEmptyState(answersList, '暂无回答', '您还没有回答过问题');
+        if (!append) {
+            showEmptyState(answersList, '暂无回答', '您还没有回答过问题');
+        }
         return;
     }
     
@@ -288,22 +311,29 @@ function renderAnswersList(answers) {
         </div>
     `).join('');
     
-    answersList.innerHTML = html;
+    if (append) {
+        answersList.insertAdjacentHTML('beforeend', html);
+    } else {
+        answersList.innerHTML = html;
+    }
 }
 
 // 加载用户分享
-function loadUserShares() {
+function loadUserShares(cursor = '') {
     const sharesList = document.getElementById('sharesList');
     if (!sharesList) return;
     
-    sharesList.innerHTML = '<div class="loading">加载中...</div>';
+    if (!cursor) {
+        sharesList.innerHTML = '<div class="loading">加载中...</div>';
+    }
     
-    fetch('/api/user/shares')
+    fetch(withCursor('/api/user/shares', cursor))
         .then(response => response.json())
         .then(data => {
             if (data.success) {
-                renderSharesList(data.shares);
-            } else {
+                renderSharesList(data.shares, Boolean(cursor));
+                renderLoadMore(sharesList, data.next_cursor, () => loadUserShares(data.next_cursor));
+            } else if (!cursor) {
                 showEmptyState(sharesList, '暂无分享', '您还没有发布过分享');
             }
         })
@@ -314,11 +344,13 @@ function loadUserShares() {
 }
 
 // 渲染分享列表
-function renderSharesList(shares) {
+function renderSharesList(shares, append = false) {
     const sharesList = document.getElementById('sharesList');
     
     if (!shares || shares.length === 0) {
-        showEmptyState(sharesList, '暂无分享', '您还没有发布过分享');
+        if (!append) {
+            showEmptyState(sharesList, '暂无分享', '您还没有发布过分享');
+        }
         return;
     }
     
@@ -346,22 +378,29 @@ function renderSharesList(shares) {
         </div>
     `).join('');
     
-    sharesList.innerHTML = html;
+    if (append) {
+        sharesList.insertAdjacentHTML('beforeend', html);
+    } else {
+        sharesList.innerHTML = html;
+    }
 }
 
 // 加载用户资料
-function loadUserResources() {
+function loadUserResources(cursor = '') {
     const resourcesList = document.getElementById('resourcesList');
     if (!resourcesList) return;
     
-    resourcesList.innerHTML = '<div class="loading">加载中...</div>';
+    if (!cursor) {
+        resourcesList.innerHTML = '<div class="loading">加载中...</div>';
+    }
     
-    fetch('/api/user/resources')
+    fetch(withCursor('/api/user/resources', cursor))
         .then(response => response.json())
         .then(data => {
             if (data.success) {
-                renderResourcesList(data.resources);
-            } else {
+                renderResourcesList(data.resources, Boolean(cursor));
+                renderLoadMore(resourcesList, data.next_cursor, () => loadUserResources(data.next_cursor));
+            } else if (!cursor) {
                 showEmptyState(resourcesList, '暂无资料', '您还没有上传过资料');
             }
         })
@@ -372,11 +411,13 @@ function loadUserResources() {
 }
 
 // 渲染资料列表
-function renderResourcesList(resources) {
+function renderResourcesList(resources, append = false) {
     const resourcesList = document.getElementById('resourcesList');
     
     if (!resources || resources.length === 0) {
-        showEmptyState(resourcesList, '暂无资料', '您还没有上传过资料');
+        if (!append) {
+            showEmptyState(resourcesList, '暂无资料', '您还没有上传过资料');
+        }
         return;
     }
     
@@ -404,7 +445,11 @@ function renderResourcesList(resources) {
         </div>
     `).join('');
     
-    resourcesList.innerHTML = html;
+    if (append) {
+        resourcesList.insertAdjacentHTML('beforeend', html);
+    } else {
+        resourcesList.innerHTML = html;
+    }
 }
 
 // 加载用户收藏
@@ -438,19 +483,21 @@ function renderFavoritesList(favorites) {
         return;
     }
     
+    const typeNames = { question: '问题', post: '帖子', article: '文章', resource: '资料' };
     const html = favorites.map(favorite => `
         <div class="favorite-item">
             <div class="item-header">
                 <h4 class="item-title">${favorite.title}</h4>
-                <span class="favorite-type">${favorite.type}</span>
+                <span class="favorite-type">${typeNames[favorite.content_type] || favorite.content_type}</span>
             </div>
-            <div class="item-content">${favorite.content.substring(0, 100)}...</div>
+            <div class="item-content">${favorite.excerpt.substring(0, 100)}...</div>
+            ${favorite.note ? `<div class="favorite-note"><i class="fas fa-sticky-note"></i> ${favorite.note}</div>` : ''}
             <div class="item-meta">
                 <span><i class="fas fa-clock"></i> ${formatTime(favorite.created_at)}</span>
                 <span><i class="fas fa-user"></i> ${favorite.author}</span>
             </div>
             <div class="item-actions">
-                <button class="btn-secondary" onclick="viewFavorite(${favorite.id})">
+                <button class="btn-secondary" onclick="viewFavorite('${favorite.link}')">
                     <i class="fas fa-eye"></i> 查看
                 </button>
                 <button class="btn-delete" onclick="removeFavorite(${favorite.id})">
@@ -464,18 +511,21 @@ function renderFavoritesList(favorites) {
 }
 
 // 加载用户关注
-function loadUserFollowing() {
+function loadUserFollowing(cursor = '') {
     const followingList = document.getElementById('followingList');
     if (!followingList) return;
     
-    followingList.innerHTML = '<div class="loading">加载中...</div>';
+    if (!cursor) {
+        followingList.innerHTML = '<div class="loading">加载中...</div>';
+    }
     
-    fetch('/api/user/following')
+    fetch(withCursor('/api/user/following', cursor))
         .then(response => response.json())
         .then(data => {
             if (data.success) {
-                renderFollowingList(data.following);
-            } else {
+                renderFollowingList(data.following, Boolean(cursor));
+                renderLoadMore(followingList, data.next_cursor, () => loadUserFollowing(data.next_cursor));
+            } else if (!cursor) {
                 showEmptyState(followingList, '暂无关注', '您还没有关注任何用户');
             }
         })
@@ -486,11 +536,13 @@ function loadUserFollowing() {
 }
 
 // 渲染关注列表
-function renderFollowingList(following) {
+function renderFollowingList(following, append = false) {
     const followingList = document.getElementById('followingList');
     
     if (!following || following.length === 0) {
-        showEmptyState(followingList, '暂无关注', '您还没有关注任何用户');
+        if (!append) {
+            showEmptyState(followingList, '暂无关注', '您还没有关注任何用户');
+        }
         return;
     }
     
@@ -504,7 +556,7 @@ function renderFollowingList(following) {
                         <p class="user-bio">${user.bio || '这个人很懒，还没有写简介'}</p>
                     </div>
                 </div>
-                <span class="follow-status">已关注</span>
+                <span class="follow-status">${user.is_mutual ? '互相关注' : '已关注'}</span>
             </div>
             <div class="item-meta">
                 <span><i class="fas fa-users"></i> ${user.followers} 粉丝</span>
@@ -522,22 +574,29 @@ function renderFollowingList(following) {
         </div>
     `).join('');
     
-    followingList.innerHTML = html;
+    if (append) {
+        followingList.insertAdjacentHTML('beforeend', html);
+    } else {
+        followingList.innerHTML = html;
+    }
 }
 
 // 加载用户粉丝
-function loadUserFollowers() {
+function loadUserFollowers(cursor = '') {
     const followersList = document.getElementById('followersList');
     if (!followersList) return;
     
-    followersList.innerHTML = '<div class="loading">加载中...</div>';
+    if (!cursor) {
+        followersList.innerHTML = '<div class="loading">加载中...</div>';
+    }
     
-    fetch('/api/user/followers')
+    fetch(withCursor('/api/user/followers', cursor))
         .then(response => response.json())
         .then(data => {
             if (data.success) {
-                renderFollowersList(data.followers);
-            } else {
+                renderFollowersList(data.followers, Boolean(cursor));
+                renderLoadMore(followersList, data.next_cursor, () => loadUserFollowers(data.next_cursor));
+            } else if (!cursor) {
                 showEmptyState(followersList, '暂无粉丝', '您还没有粉丝');
             }
         })
@@ -548,11 +607,13 @@ function loadUserFollowers() {
 }
 
 // 渲染粉丝列表
-function renderFollowersList(followers) {
+function renderFollowersList(followers, append = false) {
     const followersList = document.getElementById('followersList');
     
     if (!followers || followers.length === 0) {
-        showEmptyState(followersList, '暂无粉丝', '您还没有粉丝');
+        if (!append) {
+            showEmptyState(followersList, '暂无粉丝', '您还没有粉丝');
+        }
         return;
     }
     
@@ -566,7 +627,7 @@ function renderFollowersList(followers) {
                         <p class="user-bio">${user.bio || '这个人很懒，还没有写简介'}</p>
                     </div>
                 </div>
-                <span class="follow-status">关注了您</span>
+                <span class="follow-status">${user.is_mutual ? '互相关注' : '关注了您'}</span>
             </div>
             <div class="item-meta">
                 <span><i class="fas fa-users"></i> ${user.followers} 粉丝</span>
@@ -577,29 +638,36 @@ function renderFollowersList(followers) {
                 <button class="btn-secondary" onclick="viewUserProfile(${user.id})">
                     <i class="fas fa-user"></i> 查看资料
                 </button>
-                <button class="btn-primary" onclick="followUser(${user.id})">
+                ${user.is_mutual ? '' : `<button class="btn-primary" onclick="followUser(${user.id})">
                     <i class="fas fa-user-plus"></i> 关注
-                </button>
+                </button>`}
             </div>
         </div>
     `).join('');
     
-    followersList.innerHTML = html;
+    if (append) {
+        followersList.insertAdjacentHTML('beforeend', html);
+    } else {
+        followersList.innerHTML = html;
+    }
 }
 
 // 加载用户消息
-function loadUserMessages() {
+function loadUserMessages(cursor = '') {
     const messagesList = document.getElementById('messagesList');
     if (!messagesList) return;
     
-    messagesList.innerHTML = '<div class="loading">加载中...</div>';
+    if (!cursor) {
+        messagesList.innerHTML = '<div class="loading">加载中...</div>';
+    }
     
-    fetch('/api/user/messages')
+    fetch(withCursor('/api/user/messages', cursor))
         .then(response => response.json())
         .then(data => {
             if (data.success) {
-                renderMessagesList(data.messages);
-            } else {
+                renderMessagesList(data.messages, Boolean(cursor));
+                renderLoadMore(messagesList, data.next_cursor, () => loadUserMessages(data.next_cursor));
+            } else if (!cursor) {
                 showEmptyState(messagesList, '暂无消息', '您还没有收到任何消息');
             }
         })
@@ -610,37 +678,70 @@ function loadUserMessages() {
 }
 
 // 渲染消息列表
-function renderMessagesList(messages) {
+function renderMessagesList(messages, append = false) {
     const messagesList = document.getElementById('messagesList');
     
     if (!messages || messages.length === 0) {
-        showEmptyState(messagesList, '暂无消息', '您还没有收到任何消息');
+        if (!append) {
+            showEmptyState(messagesList, '暂无消息', '您还没有收到任何消息');
+        }
         return;
     }
     
-    const html = messages.map(message => `
+    // 标题、正文、发送者来自用户输入，必须转义后再拼接
+    const html = messages.map(message => {
+        const title = escapeHtml(message.title);
+        const link = safeLink(message.link);
+        return `
         <div class="message-item ${message.is_read ? '' : 'unread'}">
             <div class="item-header">
-                <h4 class="item-title">${message.title}</h4>
-                <span class="message-type">${message.type}</span>
+                <h4 class="item-title">${link ? `<a href="${escapeHtml(link)}">${title}</a>` : title}</h4>
+                <span class="message-type">${escapeHtml(message.type)}</span>
             </div>
-            <div class="item-content">${message.content}</div>
+            <div class="item-content">${escapeHtml(message.content)}</div>
             <div class="item-meta">
                 <span><i class="fas fa-clock"></i> ${formatTime(message.created_at)}</span>
-                <span><i class="fas fa-user"></i> ${message.sender}</span>
+                <span><i class="fas fa-user"></i> ${escapeHtml(message.sender)}</span>
             </div>
             <div class="item-actions">
-                <button class="btn-secondary" onclick="markMessageRead(${message.id})">
+                <button class="btn-secondary" onclick="markMessageRead(${Number(message.id)})">
                     <i class="fas fa-check"></i> 标记已读
                 </button>
-                <button class="btn-delete" onclick="deleteMessage(${message.id})">
+                <button class="btn-delete" onclick="deleteMessage(${Number(message.id)})">
                     <i class="fas fa-trash"></i> 删除
                 </button>
             </div>
         </div>
-    `).join('');
+    `;
+    }).join('');
     
-    messagesList.innerHTML = html;
+    if (append) {
+        messagesList.insertAdjacentHTML('beforeend', html);
+    } else {
+        messagesList.innerHTML = html;
+    }
+}
+
+// 给列表接口地址加上分页游标
+function withCursor(url, cursor) {
+    if (!cursor) return url;
+    return url + (url.includes('?') ? '&' : '?') + 'cursor=' + encodeURIComponent(cursor);
+}
+
+// 在列表末尾显示“加载更多”按钮，没有下一页时移除
+function renderLoadMore(container, nextCursor, loader) {
+    const existing = container.querySelector('.load-more');
+    if (existing) existing.remove();
+    if (!nextCursor) return;
+
+    const button = document.createElement('button');
+    button.className = 'btn-secondary load-more';
+    button.innerHTML = '<i class="fas fa-chevron-down"></i> 加载更多';
+    button.onclick = () => {
+        button.disabled = true;
+        loader();
+    };
+    container.appendChild(button);
 }
 
 // 显示空状态
@@ -766,6 +867,291 @@ function changePassword() {
     });
 }
 
+// 申请注销账户
+function requestAccountDeletion() {
+    // 通过第三方登录注册、没有设置密码的账户不显示密码输入框
+    const passwordInput = document.getElementById('deletionPassword');
+    const password = passwordInput ? passwordInput.value : '';
+    if (passwordInput && !password) {
+        showMessage('请输入密码确认注销', 'error');
+        return;
+    }
+    if (!confirm('确定要注销账户吗？等待期结束后将无法恢复。')) {
+        return;
+    }
+
+    fetch('/api/user/account/deletion', {
+        method: 'POST',
+        headers: {
+            'Content-Type': 'application/json',
+        },
+        body: JSON.stringify({ password: password })
+    })
+    .then(response => response.json())
+    .then(data => {
+        if (data.success) {
+            showMessage(data.message, 'success');
+            setTimeout(() => location.reload(), 1500);
+        } else {
+            showMessage(data.error || '申请注销失败', 'error');
+        }
+    })
+    .catch(error => {
+        console.error('申请注销失败:', error);
+        showMessage('申请注销失败', 'error');
+    });
+}
+
+// 撤销注销申请
+function cancelAccountDeletion() {
+    fetch('/api/user/account/deletion', {
+        method: 'DELETE'
+    })
+    .then(response => response.json())
+    .then(data => {
+        if (data.success) {
+            showMessage(data.message, 'success');
+            setTimeout(() => location.reload(), 1500);
+        } else {
+            showMessage(data.error || '撤销注销失败', 'error');
+        }
+    })
+    .catch(error => {
+        console.error('撤销注销失败:', error);
+        showMessage('撤销注销失败', 'error');
+    });
+}
+
+// 获取两步验证密钥并显示二维码
+function enrollMFA() {
+    fetch('/api/user/mfa/enroll', {
+        method: 'POST'
+    })
+    .then(response => response.json())
+    .then(data => {
+        if (!data.success) {
+            showMessage(data.error || '生成密钥失败', 'error');
+            return;
+        }
+        document.getElementById('mfaSecret').textContent = data.secret;
+        const qrcode = document.getElementById('mfaQRCode');
+        qrcode.innerHTML = '';
+        if (typeof QRCode !== 'undefined') {
+            new QRCode(qrcode, { text: data.provisioning_uri, width: 180, height: 180 });
+        }
+        document.getElementById('mfaEnrollment').style.display = 'block';
+        document.getElementById('mfaEnrollButton').style.display = 'none';
+    })
+    .catch(error => {
+        console.error('生成密钥失败:', error);
+        showMessage('生成密钥失败', 'error');
+    });
+}
+
+// 输入验证码确认开启两步验证
+function enableMFA() {
+    const code = document.getElementById('mfaEnableCode').value.trim();
+    if (!code) {
+        showMessage('请输入验证码', 'error');
+        return;
+    }
+
+    fetch('/api/user/mfa/enable', {
+        method: 'POST',
+        headers: {
+            'Content-Type': 'application/json',
+        },
+        body: JSON.stringify({ code: code })
+    })
+    .then(response => response.json())
+    .then(data => {
+        if (data.success) {
+            showMessage(data.message, 'success');
+            document.getElementById('mfaEnrollment').style.display = 'none';
+            showRecoveryCodes(data.recovery_codes);
+        } else {
+            showMessage(data.error || '开启两步验证失败', 'error');
+        }
+    })
+    .catch(error => {
+        console.error('开启两步验证失败:', error);
+        showMessage('开启两步验证失败', 'error');
+    });
+}
+
+// 关闭两步验证
+function disableMFA() {
+    const passwordInput = document.getElementById('mfaPassword');
+    const code = document.getElementById('mfaCode').value.trim();
+    if (!code || (passwordInput && !passwordInput.value)) {
+        showMessage('请输入密码和验证码', 'error');
+        return;
+    }
+    if (!confirm('确定要关闭两步验证吗？')) {
+        return;
+    }
+
+    fetch('/api/user/mfa/disable', {
+        method: 'POST',
+        headers: {
+            'Content-Type': 'application/json',
+        },
+        body: JSON.stringify({ password: passwordInput ? passwordInput.value : '', code: code })
+    })
+    .then(response => response.json())
+    .then(data => {
+        if (data.success) {
+            showMessage(data.message, 'success');
+            setTimeout(() => location.reload(), 1500);
+        } else {
+            showMessage(data.error || '关闭两步验证失败', 'error');
+        }
+    })
+    .catch(error => {
+        console.error('关闭两步验证失败:', error);
+        showMessage('关闭两步验证失败', 'error');
+    });
+}
+
+// 重新生成恢复码
+function regenerateRecoveryCodes() {
+    const code = document.getElementById('mfaCode').value.trim();
+    if (!code) {
+        showMessage('请输入验证码', 'error');
+        return;
+    }
+
+    fetch('/api/user/mfa/recovery-codes', {
+        method: 'POST',
+        headers: {
+            'Content-Type': 'application/json',
+        },
+        body: JSON.stringify({ code: code })
+    })
+    .then(response => response.json())
+    .then(data => {
+        if (data.success) {
+            showMessage(data.message, 'success');
+            showRecoveryCodes(data.recovery_codes);
+        } else {
+            showMessage(data.error || '生成恢复码失败', 'error');
+        }
+    })
+    .catch(error => {
+        console.error('生成恢复码失败:', error);
+        showMessage('生成恢复码失败', 'error');
+    });
+}
+
+// 显示恢复码（只在生成时显示一次）
+function showRecoveryCodes(codes) {
+    document.getElementById('mfaRecoveryCodeList').textContent = (codes || []).join('\n');
+    document.getElementById('mfaRecoveryCodes').style.display = 'block';
+}
+
+// 创建个人访问令牌
+function createPersonalToken() {
+    const name = document.getElementById('tokenName').value.trim();
+    const scopes = Array.from(document.querySelectorAll('input[name="tokenScope"]:checked')).map(input => input.value);
+    if (!name || scopes.length === 0) {
+        showMessage('请填写令牌名称并选择权限范围', 'error');
+        return;
+    }
+
+    fetch('/api/user/tokens', {
+        method: 'POST',
+        headers: {
+            'Content-Type': 'application/json',
+        },
+        body: JSON.stringify({
+            name: name,
+            scopes: scopes,
+            expires_in_days: parseInt(document.getElementById('tokenExpiry').value, 10)
+        })
+    })
+    .then(response => response.json())
+    .then(data => {
+        if (data.success) {
+            showMessage(data.message, 'success');
+            document.getElementById('newPersonalTokenValue').textContent = data.token;
+            document.getElementById('newPersonalToken').style.display = 'block';
+        } else {
+            showMessage(data.error || '创建令牌失败', 'error');
+        }
+    })
+    .catch(error => {
+        console.error('创建令牌失败:', error);
+        showMessage('创建令牌失败', 'error');
+    });
+}
+
+// 删除个人访问令牌
+function revokePersonalToken(id) {
+    if (!confirm('确定要删除这个令牌吗？使用该令牌的脚本将无法继续访问。')) {
+        return;
+    }
+
+    fetch('/api/user/tokens/' + id, {
+        method: 'DELETE'
+    })
+    .then(response => response.json())
+    .then(data => {
+        if (data.success) {
+            showMessage(data.message, 'success');
+            setTimeout(() => location.reload(), 1500);
+        } else {
+            showMessage(data.error || '删除令牌失败', 'error');
+        }
+    })
+    .catch(error => {
+        console.error('删除令牌失败:', error);
+        showMessage('删除令牌失败', 'error');
+    });
+}
+
+// 绑定第三方账号，跳转到第三方授权页面
+function linkAccount(provider) {
+    fetch('/api/user/identities/' + encodeURIComponent(provider), {
+        method: 'POST'
+    })
+    .then(response => response.json())
+    .then(data => {
+        if (data.success) {
+            location.href = data.auth_url;
+        } else {
+            showMessage(data.error || '绑定失败', 'error');
+        }
+    })
+    .catch(error => {
+        console.error('绑定失败:', error);
+        showMessage('绑定失败', 'error');
+    });
+}
+
+// 解除绑定第三方账号
+function unlinkAccount(provider) {
+    if (!confirm('确定要解除绑定吗？')) {
+        return;
+    }
+
+    fetch('/api/user/identities/' + encodeURIComponent(provider), {
+        method: 'DELETE'
+    })
+    .then(response => response.json())
+    .then(data => {
+        if (data.success) {
+            showMessage(data.message, 'success');
+            setTimeout(() => location.reload(), 1500);
+        } else {
+            showMessage(data.error || '解除绑定失败', 'error');
+        }
+    })
+    .catch(error => {
+        console.error('解除绑定失败:', error);
+        showMessage('解除绑定失败', 'error');
+    });
+}
+
 // 保存通知设置
 function saveNotificationSettings() {
     const checkboxes = document.querySelectorAll('.notification-options input[type="checkbox"]');
@@ -774,6 +1160,11 @@ function saveNotificationSettings() {
     checkboxes.forEach(checkbox => {
         settings[checkbox.name] = checkbox.checked;
     });
+
+    const digest = document.querySelector('.notification-options select[name="email_digest"]');
+    if (digest) {
+        settings.email_digest = digest.value;
+    }
     
     fetch('/api/user/notifications', {
         method: 'PUT',
@@ -834,6 +1225,19 @@ function formatTime(timestamp) {
     return date.toLocaleDateString();
 }
 
+// 转义HTML特殊字符，用于拼接innerHTML
+function escapeHtml(value) {
+    return String(value ?? '').replace(/[&<>"']/g, ch => ({
+        '&': '&amp;', '<': '&lt;', '>': '&gt;', '"': '&quot;', "'": '&#39;'
+    })[ch]);
+}
+
+// 只允许站内链接，避免javascript:等协议
+function safeLink(link) {
+    if (typeof link !== 'string' || !/^\/(?![\/\\])/.test(link)) return '';
+    return link;
+}
+
 function formatFileSize(bytes) {
     if (bytes === 0) return '0 B';
     const k = 1024;
@@ -966,13 +1370,13 @@ function deleteResource(id) {
     }
 }
 
-function viewFavorite(id) {
-    window.location.href = `/favorite/${id}`;
+function viewFavorite(link) {
+    window.location.href = link;
 }
 
 function removeFavorite(id) {
     if (confirm('确定要取消收藏吗？')) {
-        fetch(`/api/favorites/${id}`, {
+        fetch(`/api/user/favorites/${id}`, {
             method: 'DELETE'
         })
         .then(response => response.json())
@@ -4,6 +4,7 @@ document.addEventListener('DOMContentLoaded', function() {
     initCarousel();
     initSearch();
     initLoginForm();
+    initOAuthLogin();
     initTagCloud();
     initNavigation();
     initProgressBar();
@@ -102,6 +103,27 @@ function initLoginForm() {
     }
 }
 
+// 第三方登录按钮
+function initOAuthLogin() {
+    const container = document.getElementById('oauthLogin');
+    if (!container) {
+        return;
+    }
+
+    fetch('/auth/oauth/providers')
+    .then(response => response.json())
+    .then(data => {
+        (data.providers || []).forEach(provider => {
+            const link = document.createElement('a');
+            link.href = provider.login_url;
+            link.className = 'btn-oauth';
+            link.textContent = '使用' + provider.display_name + '登录';
+            container.appendChild(link);
+        });
+    })
+    .catch(error => console.error('获取登录方式失败:', error));
+}
+
 function updateUserDisplay(username) {
     const userInfo = document.querySelector('.user-info');
     if (userInfo) {
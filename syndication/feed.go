@@ -0,0 +1,71 @@
+package syndication
+
+import (
+	"errors"
+	"time"
+)
+
+// 订阅格式
+const (
+	FormatRSS  = "rss"
+	FormatAtom = "atom"
+	FormatJSON = "json"
+)
+
+var ErrUnknownFormat = errors.New("不支持的订阅格式")
+
+// 订阅源
+type Feed struct {
+	Title       string
+	Description string
+	Link        string // 对应的网页
+	FeedURL     string // 订阅源自身的地址
+	Updated     time.Time
+	Items       []*Item
+}
+
+// 订阅条目，Summary和Content均为纯文本
+type Item struct {
+	ID         string // 全局唯一标识，使用条目的链接
+	Title      string
+	Link       string
+	Summary    string
+	Content    string
+	AuthorName string
+	AuthorURL  string
+	Categories []string
+	Published  time.Time
+}
+
+// 条目中最新的发布时间，没有条目时为零值。
+// 内容表的updated_at会随浏览数、点赞数刷新，不能作为内容的修改时间，内容修改通过ETag体现
+func LastPublished(items []*Item) time.Time {
+	var latest time.Time
+	for _, item := range items {
+		if item.Published.After(latest) {
+			latest = item.Published
+		}
+	}
+	return latest
+}
+
+// 按格式生成订阅内容，返回内容和Content-Type
+func Render(feed *Feed, format string) ([]byte, string, error) {
+	switch format {
+	case FormatRSS:
+		body, err := renderRSS(feed)
+		return body, "application/rss+xml; charset=utf-8", err
+	case FormatAtom:
+		body, err := renderAtom(feed)
+		return body, "application/atom+xml; charset=utf-8", err
+	case FormatJSON:
+		body, err := renderJSON(feed)
+		return body, "application/feed+json; charset=utf-8", err
+	}
+	return nil, "", ErrUnknownFormat
+}
+
+// 检查订阅格式是否有效
+func ValidFormat(format string) bool {
+	return format == FormatRSS || format == FormatAtom || format == FormatJSON
+}
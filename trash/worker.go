@@ -0,0 +1,35 @@
+package trash
+
+import (
+	"log"
+	"time"
+
+	"aiforum/models"
+)
+
+// 清理任务的轮询间隔
+const workerInterval = time.Hour
+
+// 启动后台清理任务：彻底删除回收站中超过保留期限的内容
+func StartWorker() {
+	go func() {
+		ticker := time.NewTicker(workerInterval)
+		defer ticker.Stop()
+
+		for {
+			RunOnce()
+			<-ticker.C
+		}
+	}()
+}
+
+// 执行一轮清理任务
+func RunOnce() {
+	purged, err := models.PurgeTrash()
+	if err != nil {
+		log.Printf("清理回收站失败: %v", err)
+	}
+	if purged > 0 {
+		log.Printf("已从回收站彻底删除 %d 项内容", purged)
+	}
+}
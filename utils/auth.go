@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"errors"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -30,17 +31,24 @@ func GenerateToken(userID int, username string) (string, error) {
 	return token.SignedString([]byte(config.AppConfig.JWTSecret))
 }
 
+// 登录流程中的临时令牌（两步验证、第三方登录）不能当作登录token使用
+var ErrFlowToken = errors.New("令牌不能用于登录")
+
 // 验证JWT token
 func ValidateToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		return []byte(config.AppConfig.JWTSecret), nil
-	})
+	}, jwt.WithValidMethods([]string{"HS256"}))
 
 	if err != nil {
 		return nil, err
 	}
 
 	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
+		// 登录token不带audience，带audience的都是临时令牌
+		if len(claims.Audience) > 0 {
+			return nil, ErrFlowToken
+		}
 		return claims, nil
 	}
 
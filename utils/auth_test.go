@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"testing"
+
+	"aiforum/config"
+)
+
+func init() {
+	config.AppConfig = &config.Config{JWTSecret: "test-secret"}
+}
+
+func TestValidateToken(t *testing.T) {
+	token, err := GenerateToken(42, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := ValidateToken(token)
+	if err != nil {
+		t.Fatalf("登录token验证失败: %v", err)
+	}
+	if claims.UserID != 42 || claims.Username != "alice" {
+		t.Fatalf("声明不正确: %+v", claims)
+	}
+}
+
+// 临时令牌和登录token使用同一个密钥签名，不能被当作登录token接受
+func TestValidateTokenRejectsFlowTokens(t *testing.T) {
+	mfa, err := GenerateMFAPendingToken(42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, err := GenerateOAuthState(&OAuthState{Provider: "oidc", State: "s", LinkUserID: 42})
+	if err != nil {
+		t.Fatal(err)
+	}
+	signup, err := GenerateOAuthSignup(&OAuthSignup{Provider: "oidc", Subject: "sub", Username: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, token := range map[string]string{"mfa_pending": mfa, "oauth_state": state, "oauth_signup": signup} {
+		if claims, err := ValidateToken(token); err == nil {
+			t.Errorf("%s令牌被当作登录token接受: %+v", name, claims)
+		}
+	}
+}
+
+// 反过来，登录token也不能通过临时令牌的验证
+func TestFlowTokensRejectLoginToken(t *testing.T) {
+	token, err := GenerateToken(42, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ValidateMFAPendingToken(token); err == nil {
+		t.Error("登录token通过了两步验证令牌的验证")
+	}
+	if _, err := ValidateOAuthSignup(token); err == nil {
+		t.Error("登录token通过了待注册令牌的验证")
+	}
+}
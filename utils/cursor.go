@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// 分页游标（键集分页，Type用于区分混合列表中的不同内容）
+type Cursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        int       `json:"i"`
+	Type      string    `json:"k,omitempty"`
+	// 按热度、悬赏等排序时的排序键
+	Score float64 `json:"s,omitempty"`
+	// 生成游标的排序方式，不同排序方式的游标不能混用
+	Sort string `json:"o,omitempty"`
+	// 上一页游标
+	Backward bool `json:"b,omitempty"`
+}
+
+// 无效的分页游标
+var ErrInvalidCursor = errors.New("无效的分页游标")
+
+// 将游标编码为不透明字符串
+func EncodeCursor(cursor Cursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// 解析游标字符串（空字符串表示第一页）
+func DecodeCursor(s string) (*Cursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID <= 0 {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
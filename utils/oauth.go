@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"time"
+
+	"aiforum/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// 第三方登录过程中的临时令牌有效期
+const OAuthFlowTTL = 15 * time.Minute
+
+// 区分临时令牌和登录token，避免互相冒用
+const (
+	oauthStateAudience  = "oauth_state"
+	oauthSignupAudience = "oauth_signup"
+)
+
+// 跳转到第三方授权前保存在Cookie中的状态
+type OAuthState struct {
+	Provider   string `json:"provider"`
+	State      string `json:"state"`
+	Nonce      string `json:"nonce"`
+	Verifier   string `json:"verifier"`
+	LinkUserID int    `json:"link_user_id,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// 首次登录、等待选择用户名的第三方账号
+type OAuthSignup struct {
+	Provider  string `json:"provider"`
+	Subject   string `json:"sub_id"`
+	Email     string `json:"email"`
+	Username  string `json:"username"`
+	AvatarURL string `json:"avatar_url"`
+	jwt.RegisteredClaims
+}
+
+// 生成授权状态令牌
+func GenerateOAuthState(state *OAuthState) (string, error) {
+	state.RegisteredClaims = flowClaims(oauthStateAudience, OAuthFlowTTL)
+	return signFlowClaims(state)
+}
+
+// 验证授权状态令牌
+func ValidateOAuthState(tokenString string) (*OAuthState, error) {
+	state := &OAuthState{}
+	if err := parseFlowClaims(tokenString, state, oauthStateAudience); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// 生成待注册令牌
+func GenerateOAuthSignup(signup *OAuthSignup) (string, error) {
+	signup.RegisteredClaims = flowClaims(oauthSignupAudience, OAuthFlowTTL)
+	return signFlowClaims(signup)
+}
+
+// 验证待注册令牌
+func ValidateOAuthSignup(tokenString string) (*OAuthSignup, error) {
+	signup := &OAuthSignup{}
+	if err := parseFlowClaims(tokenString, signup, oauthSignupAudience); err != nil {
+		return nil, err
+	}
+	return signup, nil
+}
+
+// 登录流程中临时令牌的声明，audience区分令牌用途
+func flowClaims(audience string, ttl time.Duration) jwt.RegisteredClaims {
+	now := time.Now()
+	return jwt.RegisteredClaims{
+		Audience:  jwt.ClaimStrings{audience},
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		IssuedAt:  jwt.NewNumericDate(now),
+	}
+}
+
+func signFlowClaims(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(config.AppConfig.JWTSecret))
+}
+
+func parseFlowClaims(tokenString string, claims jwt.Claims, audience string) error {
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(config.AppConfig.JWTSecret), nil
+	}, jwt.WithValidMethods([]string{"HS256"}), jwt.WithAudience(audience))
+	return err
+}
@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"strings"
+)
+
+// 个人访问令牌的前缀，用于和JWT区分，也便于在代码泄露扫描中识别
+const PersonalTokenPrefix = "afp_"
+
+// 生成个人访问令牌（160位随机数）
+func GeneratePersonalToken() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	encoded := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b)
+	return PersonalTokenPrefix + strings.ToLower(encoded), nil
+}
+
+// 是否为个人访问令牌
+func IsPersonalToken(token string) bool {
+	return strings.HasPrefix(token, PersonalTokenPrefix)
+}
+
+// 个人访问令牌的哈希，数据库中只保存哈希
+func HashPersonalToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TOTP参数（RFC 6238，与常见验证器App的默认值一致）
+const (
+	totpPeriod = 30
+	totpDigits = 6
+	// 允许前后各一个时间窗口的时钟偏差
+	totpSkew = 1
+)
+
+// 每次生成的恢复码数量
+const RecoveryCodeCount = 10
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// 生成TOTP密钥（160位，base32编码）
+func GenerateTOTPSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(b), nil
+}
+
+// 生成验证器App扫码使用的otpauth地址
+func TOTPProvisioningURI(secret, account, issuer string) string {
+	params := url.Values{
+		"secret":    {secret},
+		"issuer":    {issuer},
+		"algorithm": {"SHA1"},
+		"digits":    {fmt.Sprint(totpDigits)},
+		"period":    {fmt.Sprint(totpPeriod)},
+	}
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// 校验TOTP验证码，返回匹配的时间窗口序号（用于防止同一验证码重复使用）
+func ValidateTOTP(secret, code string, now time.Time) (int64, bool) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil || len(code) != totpDigits {
+		return 0, false
+	}
+
+	current := now.Unix() / totpPeriod
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if hmac.Equal([]byte(totpCode(key, step)), []byte(code)) {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// 计算指定时间窗口的验证码（RFC 4226动态截断）
+func totpCode(key []byte, step int64) string {
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000)
+}
+
+// 生成一组恢复码，格式为xxxxx-xxxxx
+func GenerateRecoveryCodes() ([]string, error) {
+	codes := make([]string, RecoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		s := strings.ToLower(totpEncoding.EncodeToString(b))[:10]
+		codes[i] = s[:5] + "-" + s[5:]
+	}
+	return codes, nil
+}
+
+// 恢复码的哈希（恢复码是随机生成的，不需要加盐的慢哈希），忽略大小写和分隔符
+func HashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// 密码验证通过、等待输入两步验证码的令牌有效期
+const MFAPendingTTL = 5 * time.Minute
+
+const mfaPendingAudience = "mfa_pending"
+
+// 等待两步验证的登录
+type MFAPending struct {
+	UserID int `json:"user_id"`
+	jwt.RegisteredClaims
+}
+
+// 生成两步验证令牌
+func GenerateMFAPendingToken(userID int) (string, error) {
+	return signFlowClaims(&MFAPending{
+		UserID:           userID,
+		RegisteredClaims: flowClaims(mfaPendingAudience, MFAPendingTTL),
+	})
+}
+
+// 验证两步验证令牌，返回用户ID
+func ValidateMFAPendingToken(tokenString string) (int, error) {
+	pending := &MFAPending{}
+	if err := parseFlowClaims(tokenString, pending, mfaPendingAudience); err != nil {
+		return 0, err
+	}
+	return pending.UserID, nil
+}
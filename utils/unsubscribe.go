@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"aiforum/config"
+)
+
+// 退订链接有效期，邮件可能在收件箱里放很久，有效期取得较长
+const UnsubscribeTTL = 90 * 24 * time.Hour
+
+// 无效的退订链接
+var ErrInvalidUnsubscribeToken = errors.New("无效的退订链接")
+
+// 退订链接已过期
+var ErrUnsubscribeTokenExpired = errors.New("退订链接已过期，请登录后在个人中心修改邮件设置")
+
+// 生成邮件退订令牌（无需登录即可退订），格式为“用户ID.过期时间.签名”
+func GenerateUnsubscribeToken(userID int) string {
+	payload := strconv.Itoa(userID) + "." + strconv.FormatInt(time.Now().Add(UnsubscribeTTL).Unix(), 10)
+	return payload + "." + unsubscribeSignature(payload)
+}
+
+// 验证退订令牌，返回用户ID
+func ValidateUnsubscribeToken(token string) (int, error) {
+	i := strings.LastIndex(token, ".")
+	if i < 0 {
+		return 0, ErrInvalidUnsubscribeToken
+	}
+	payload, signature := token[:i], token[i+1:]
+	if !hmac.Equal([]byte(signature), []byte(unsubscribeSignature(payload))) {
+		return 0, ErrInvalidUnsubscribeToken
+	}
+
+	id, expires, ok := strings.Cut(payload, ".")
+	if !ok {
+		return 0, ErrInvalidUnsubscribeToken
+	}
+	userID, err := strconv.Atoi(id)
+	if err != nil || userID <= 0 {
+		return 0, ErrInvalidUnsubscribeToken
+	}
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidUnsubscribeToken
+	}
+	if time.Now().Unix() > expiresAt {
+		return 0, ErrUnsubscribeTokenExpired
+	}
+	return userID, nil
+}
+
+func unsubscribeSignature(payload string) string {
+	mac := hmac.New(sha256.New, []byte(config.AppConfig.JWTSecret))
+	mac.Write([]byte("unsubscribe:" + payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,59 @@
+package webhooks
+
+import (
+	"log"
+	"time"
+
+	"aiforum/models"
+)
+
+// 投递任务的轮询间隔
+const workerInterval = 10 * time.Second
+
+// 每轮投递的数量上限
+const deliveryBatchSize = 50
+
+// 启动后台投递任务：发送待投递和到期重试的Webhook，并清理过期的投递记录
+func StartWorker() {
+	go func() {
+		ticker := time.NewTicker(workerInterval)
+		defer ticker.Stop()
+
+		for {
+			RunOnce()
+			<-ticker.C
+		}
+	}()
+}
+
+// 执行一轮投递任务
+func RunOnce() {
+	deliveries, err := models.ClaimWebhookDeliveries(deliveryBatchSize)
+	if err != nil {
+		log.Printf("领取Webhook投递失败: %v", err)
+		return
+	}
+	for _, d := range deliveries {
+		if err := Deliver(d); err != nil {
+			log.Printf("记录Webhook投递结果失败 (id=%d): %v", d.ID, err)
+		}
+	}
+
+	purged, err := models.PurgeWebhookDeliveries()
+	if err != nil {
+		log.Printf("清理Webhook投递记录失败: %v", err)
+	}
+	if purged > 0 {
+		log.Printf("已清理 %d 条过期的Webhook投递记录", purged)
+	}
+}
+
+// 发送一次投递并记录结果，返回的错误只表示结果没有保存成功
+func Deliver(d *models.WebhookDelivery) error {
+	status, sendErr := send(d)
+	if sendErr != nil {
+		log.Printf("Webhook投递失败 (id=%d, url=%s): %v", d.ID, d.URL, sendErr)
+		return models.MarkWebhookFailed(d, status, sendErr)
+	}
+	return models.MarkWebhookDelivered(d.ID, status)
+}